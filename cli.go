@@ -0,0 +1,300 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"pdf-vector-extractor/crop"
+	"pdf-vector-extractor/detect"
+	"pdf-vector-extractor/extract"
+	"pdf-vector-extractor/output"
+	"pdf-vector-extractor/pipeline"
+)
+
+// 命令行退出码
+const (
+	exitOK      = 0   // 全部成功
+	exitFailure = 1   // 处理失败，没有生成输出
+	exitUsage   = 2   // 参数错误
+	exitPartial = 3   // 已生成输出，但部分文件或页面失败
+	exitCancel  = 130 // 被Ctrl+C或SIGTERM中断，未完成的输出已删除
+)
+
+// 命令行子命令
+var cliCommands = map[string]string{
+	"extract": "逐个导出裁剪后的图块，每个图块一个文件",
+	"combine": "提取并拼接所有图块，生成一个输出文件",
+	"inspect": "列出PDF的页数、页面尺寸和内容统计，不生成输出",
+}
+
+// 判断参数是否为命令行子命令
+func isCLICommand(arg string) bool {
+	if _, ok := cliCommands[arg]; ok {
+		return true
+	}
+	switch arg {
+	case "help", "-h", "-help", "--help":
+		return true
+	}
+	return false
+}
+
+// 运行命令行模式，返回退出码
+func runCLI(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		printCLIUsage(stderr)
+		return exitUsage
+	}
+
+	cmd := args[0]
+	if _, ok := cliCommands[cmd]; !ok {
+		printCLIUsage(stderr)
+		if cmd == "help" || cmd == "-h" || cmd == "-help" || cmd == "--help" {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	config := pipeline.DefaultConfig()
+	config.OutputPath = "."
+
+	fs := flag.NewFlagSet(cmd, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	bindConfigFlags(fs, config)
+	quiet := fs.Bool("quiet", false, "不输出处理进度")
+	progressMode := fs.String("progress", "", "进度输出方式: log、line、json（输出到标准输出），默认在终端中为line，否则为log")
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "用法: %s %s [选项] [输入目录]\n\n%s\n\n选项:\n", filepath.Base(os.Args[0]), cmd, cliCommands[cmd])
+		fs.PrintDefaults()
+	}
+
+	// 允许选项出现在输入目录之后
+	var positional []string
+	for rest := args[1:]; ; {
+		if err := fs.Parse(rest); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return exitOK
+			}
+			return exitUsage
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		positional = append(positional, fs.Arg(0))
+		rest = fs.Args()[1:]
+	}
+	if len(positional) > 1 {
+		fmt.Fprintf(stderr, "多余的参数: %v\n", positional[1:])
+		return exitUsage
+	}
+	if len(positional) == 1 {
+		config.InputDir = positional[0]
+	}
+	if config.InputDir == "" {
+		fmt.Fprintln(stderr, "请指定输入目录（-input 或位置参数）")
+		return exitUsage
+	}
+	if err := config.Validate(); err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+
+	// 进度输出到标准错误，单行进度和JSON方式下不再输出日志
+	if *progressMode == "" {
+		*progressMode = defaultProgressMode(stderr)
+	}
+	progress, err := newProgressPrinter(*progressMode, stdout, stderr)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+	if *quiet && *progressMode != progressJSON {
+		progress = nil
+	}
+	log.SetOutput(stderr)
+	if *quiet || progress != nil {
+		log.SetOutput(io.Discard)
+	}
+
+	extractor := pipeline.NewPDFExtractor(config)
+	extractor.OnProgress(progress)
+	if cmd == "inspect" {
+		return runInspect(extractor, stdout, stderr)
+	}
+
+	if err := os.MkdirAll(config.OutputPath, 0755); err != nil {
+		fmt.Fprintf(stderr, "无法创建输出文件夹: %v\n", err)
+		return exitFailure
+	}
+
+	// 收到中断信号时取消处理；再次中断则按默认方式立即退出
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	var report *pipeline.ProcessReport
+	if cmd == "extract" {
+		report, err = extractor.ExtractTiles(ctx)
+	} else {
+		report, err = extractor.ProcessDirectory(ctx)
+	}
+	if errors.Is(err, context.Canceled) {
+		fmt.Fprintln(stderr, "处理已取消，未完成的输出已删除")
+		return exitCancel
+	}
+
+	if report != nil && report.Total > 0 {
+		fmt.Fprint(stderr, report.Summary())
+		if report.ReportPath != "" {
+			fmt.Fprintf(stderr, "\n报告已保存到: %s\n", report.ReportPath)
+		}
+	}
+	switch {
+	case err != nil:
+		fmt.Fprintf(stderr, "处理失败: %v\n", err)
+		return exitFailure
+	case len(report.Failures) > 0:
+		return exitPartial
+	}
+	return exitOK
+}
+
+// 将所有配置项注册为命令行参数，默认值取自当前配置
+func bindConfigFlags(fs *flag.FlagSet, c *pipeline.Config) {
+	fs.StringVar(&c.InputDir, "input", c.InputDir, "包含PDF文件的输入目录")
+	fs.StringVar(&c.OutputPath, "output", c.OutputPath, "输出文件夹")
+	fs.IntVar(&c.BoxSize, "box", c.BoxSize, "裁剪框大小 (像素)")
+	fs.StringVar(&c.OutputType, "format", c.OutputType, "输出格式: "+strings.Join(output.Formats(), "、"))
+	fs.IntVar(&c.Spacing, "spacing", c.Spacing, "图块行间距 (像素)")
+	fs.IntVar(&c.ColumnSpacing, "column-spacing", c.ColumnSpacing, "图块列间距 (像素)")
+	fs.IntVar(&c.Columns, "columns", c.Columns, "网格列数，0表示由 -rows 决定，都为0时自动排成接近正方形")
+	fs.IntVar(&c.Rows, "rows", c.Rows, "网格行数，仅在 -columns 为0时生效，1为横向一行")
+	fs.StringVar(&c.FillOrder, "fill", c.FillOrder, "网格填充顺序: row（先行后列）、column（先列后行）")
+	fs.IntVar(&c.Margin, "margin", c.Margin, "拼接结果的外边距 (像素)")
+	fs.StringVar(&c.Background, "background", c.Background, "背景颜色: #rrggbb、#rrggbbaa，none为透明")
+	fs.IntVar(&c.PackWidth, "pack-width", c.PackWidth, "紧密排版 (-layouter pack) 的拼接结果宽度 (像素)，0表示自动")
+	fs.StringVar(&c.Atlas, "atlas", c.Atlas, "在拼接结果旁写入图集描述: json、csv，留空不生成")
+	fs.StringVar(&c.PaperSize, "paper", c.PaperSize, "按纸张分页输出: "+strings.Join(pipeline.PaperSizes(), "、")+" 或 宽x高（毫米），留空不分页")
+	fs.BoolVar(&c.Landscape, "landscape", c.Landscape, "纸张横向")
+	fs.Float64Var(&c.PaperMargin, "paper-margin", c.PaperMargin, "页边距 (毫米)")
+	fs.BoolVar(&c.CropMarks, "crop-marks", c.CropMarks, "分页输出时在页边距中绘制裁切线")
+	fs.BoolVar(&c.PageNumbers, "page-numbers", c.PageNumbers, "分页输出时绘制页码")
+	fs.IntVar(&c.RenderDPI, "dpi", c.RenderDPI, "矢量页面渲染分辨率 (DPI)")
+	fs.IntVar(&c.OutputDPI, "output-dpi", c.OutputDPI, "PDF/AI输出分辨率 (DPI)，0表示与渲染分辨率一致")
+	fs.StringVar(&c.PageSelection, "pages", c.PageSelection, "页面选择: 1、all、1-3,7、odd、even")
+	fs.IntVar(&c.DetectThreshold, "detect-threshold", c.DetectThreshold, "检测阈值 (0-255)，0表示使用检测器的默认值: edge 边缘强度50，adaptive 比邻域暗10，background 与背景色相差32")
+	fs.IntVar(&c.DetectMargin, "detect-margin", c.DetectMargin, "检测到的内容区域四周的边距 (像素)")
+	fs.StringVar(&c.VectorFallback, "vector-fallback", c.VectorFallback, "矢量检测器 (-detector vector) 在只含位图的页面上使用的检测方法: "+strings.Join(detect.Methods(), "、"))
+	fs.StringVar(&c.SegmentMethod, "segment-method", c.SegmentMethod, "分割检测器标记内容的方法: "+strings.Join(detect.Methods(), "、"))
+	fs.IntVar(&c.MergeDistance, "merge-distance", c.MergeDistance, "分割检测器 (-detector segment) 合并相邻内容的距离 (像素)")
+	fs.IntVar(&c.MinArea, "min-area", c.MinArea, "分割检测器丢弃的小内容面积 (平方像素)")
+	fs.StringVar(&c.FitMode, "fit", c.FitMode, "内容缩放到裁剪框的方式: none（不缩放）、contain（完整放入）、cover（铺满）、downscale（只缩小）")
+	fs.StringVar(&c.ResampleFilter, "filter", c.ResampleFilter, "缩放时的重采样滤镜: "+strings.Join(crop.Filters(), "、"))
+	fs.StringVar(&c.CenterMode, "center", c.CenterMode, "内容中心点: bbox（外接矩形）、centroid（墨迹重心）、baseline（各图块基线对齐）")
+	fs.StringVar(&c.AlignHorizontal, "align-x", c.AlignHorizontal, "内容在裁剪框中的水平对齐: left、center、right")
+	fs.StringVar(&c.AlignVertical, "align-y", c.AlignVertical, "内容在裁剪框中的垂直对齐: top、middle、bottom")
+	fs.BoolVar(&c.Recursive, "recursive", c.Recursive, "递归扫描子目录")
+	fs.Var((*listFlag)(&c.Include), "include", "只处理匹配的文件，逗号分隔的模式，如 *.pdf,drawings/**/*.pdf（可重复）")
+	fs.Var((*listFlag)(&c.Exclude), "exclude", "跳过匹配的文件和目录，逗号分隔的模式，如 draft*,old（可重复）")
+	fs.StringVar(&c.Symlinks, "symlinks", c.Symlinks, "符号链接处理方式: files（只包含链接的文件）、follow（进入链接的目录）、skip（忽略）")
+	fs.StringVar(&c.SortOrder, "sort", c.SortOrder, "文件排序方式: natural（自然排序）、name（字节顺序）、mtime（修改时间）、pinyin（拼音）")
+	fs.StringVar(&c.OrderFile, "order-file", c.OrderFile, "排序清单文件，每行一个文件名，.csv文件取第一列，优先于 -sort")
+	fs.BoolVar(&c.PerDirectory, "per-dir", c.PerDirectory, "每个子目录分别生成输出，保存到输出文件夹中对应的子目录")
+	fs.BoolVar(&c.TraceRaster, "trace", c.TraceRaster, "输出SVG、AI、PDF时将位图图块描摹为矢量路径")
+	fs.IntVar(&c.TraceThreshold, "trace-threshold", c.TraceThreshold, "描摹阈值 (0-255)")
+	fs.Float64Var(&c.TraceCornerAngle, "trace-corner", c.TraceCornerAngle, "描摹拐角阈值 (度)")
+	fs.IntVar(&c.TraceSpeckle, "trace-speckle", c.TraceSpeckle, "描摹斑点过滤面积 (像素)")
+	fs.IntVar(&c.Concurrency, "workers", c.Concurrency, "并行处理的文件数，0表示使用全部CPU")
+	fs.StringVar(&c.Extractor, "extractor", c.Extractor, "页面提取器: "+strings.Join(pipeline.Extractors(), "、"))
+	fs.StringVar(&c.Detector, "detector", c.Detector, "内容检测器: "+strings.Join(pipeline.Detectors(), "、"))
+	fs.StringVar(&c.Cropper, "cropper", c.Cropper, "裁剪器: "+strings.Join(pipeline.Croppers(), "、"))
+	fs.StringVar(&c.Layouter, "layouter", c.Layouter, "排版器: "+strings.Join(pipeline.Layouters(), "、"))
+}
+
+// 逗号分隔的列表参数，可多次指定
+type listFlag []string
+
+func (l *listFlag) String() string {
+	if l == nil {
+		return ""
+	}
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*l = append(*l, item)
+		}
+	}
+	return nil
+}
+
+// 输出每个PDF选定页面的信息
+func runInspect(pe *pipeline.PDFExtractor, stdout, stderr io.Writer) int {
+	files, err := pe.FindPDFFiles()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitFailure
+	}
+
+	failed := 0
+	for i, file := range files {
+		log.Printf("检查文件 (%d/%d): %s", i+1, len(files), filepath.Base(file))
+
+		processor := extract.NewProcessor(pe.Config().RenderDPI)
+		pageCount, err := processor.PageCount(file)
+		if err != nil {
+			fmt.Fprintf(stdout, "%s\t错误: %v\n", filepath.Base(file), err)
+			failed++
+			continue
+		}
+		fmt.Fprintf(stdout, "%s\t共 %d 页\n", filepath.Base(file), pageCount)
+
+		pages, err := extract.ParsePageSelection(pe.Config().PageSelection, pageCount)
+		if err != nil {
+			fmt.Fprintf(stdout, "  错误: %v\n", err)
+			failed++
+			continue
+		}
+		for _, pageNr := range pages {
+			page, err := processor.ExtractVectorPaths(file, pageNr)
+			if err != nil {
+				fmt.Fprintf(stdout, "  第%d页\t错误: %v\n", pageNr, err)
+				failed++
+				continue
+			}
+			fmt.Fprintf(stdout, "  第%d页\t%.1f x %.1f pt\t旋转 %d\t路径 %d\t图像 %d\t文字 %d\n",
+				pageNr, page.MediaBox.Width(), page.MediaBox.Height(), page.Rotate,
+				len(page.Paths), page.ImageCount, page.TextCount)
+		}
+	}
+
+	if failed > 0 {
+		return exitPartial
+	}
+	return exitOK
+}
+
+// 输出命令行总体用法
+func printCLIUsage(w io.Writer) {
+	name := filepath.Base(os.Args[0])
+	fmt.Fprintf(w, "用法: %s <命令> [选项] [输入目录]\n\n不带命令运行时启动图形界面。\n\n命令:\n", name)
+	for _, cmd := range []string{"extract", "combine", "inspect"} {
+		fmt.Fprintf(w, "  %-8s %s\n", cmd, cliCommands[cmd])
+	}
+	fmt.Fprintf(w, "\n使用 \"%s <命令> -h\" 查看命令的选项。\n", name)
+	fmt.Fprintf(w, "\n退出码: 0 成功，1 失败，2 参数错误，3 部分文件失败，130 已中断\n")
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// 在dir中写入一个只有一页的测试PDF，页面中间为黑色方块
+func writeTestPDF(t *testing.T, dir, name string) {
+	t.Helper()
+	content := "0 g 100 100 200 200 re f"
+	objs := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 400 400] /Resources << >> /Contents 4 0 R >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+	}
+
+	var buf bytes.Buffer
+	// pdfcpu从文件末尾之前512字节处查找交叉引用表，用注释行把文件补足长度
+	buf.WriteString("%PDF-1.4\n%" + strings.Repeat("x", 512) + "\n")
+	offsets := make([]int, len(objs))
+	for i, obj := range objs {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objs)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objs)+1, xref)
+
+	if err := os.WriteFile(filepath.Join(dir, name), buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// 在dir中写入无法解析的PDF
+func writeBrokenPDF(t *testing.T, dir, name string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte("%PDF-1.4 broken\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// 输出目录中指定扩展名的文件
+func outputFiles(t *testing.T, dir, ext string) []string {
+	t.Helper()
+	matches, err := filepath.Glob(filepath.Join(dir, "*"+ext))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return matches
+}
+
+func TestRunCLIUsageErrors(t *testing.T) {
+	input := t.TempDir()
+	writeTestPDF(t, input, "a.pdf")
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"无参数", nil, exitUsage},
+		{"帮助", []string{"help"}, exitOK},
+		{"命令帮助", []string{"combine", "-h"}, exitOK},
+		{"未知命令", []string{"merge", input}, exitUsage},
+		{"未知选项", []string{"combine", "-nope", input}, exitUsage},
+		{"缺少输入目录", []string{"combine"}, exitUsage},
+		{"多余的参数", []string{"combine", input, input}, exitUsage},
+		{"无效的输出格式", []string{"combine", "-format", "bmp", input}, exitUsage},
+		{"无效的缩放方式", []string{"combine", "-fit", "stretch", input}, exitUsage},
+		{"无效的进度方式", []string{"combine", "-progress", "bar", input}, exitUsage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if got := runCLI(tt.args, &stdout, &stderr); got != tt.want {
+				t.Errorf("退出码 %d, 期望 %d\n%s", got, tt.want, stderr.String())
+			}
+		})
+	}
+}
+
+func TestRunCLIExitCodes(t *testing.T) {
+	tests := []struct {
+		name  string
+		files func(t *testing.T, dir string)
+		want  int
+		pngs  int
+	}{
+		{"全部成功", func(t *testing.T, dir string) {
+			writeTestPDF(t, dir, "a.pdf")
+			writeTestPDF(t, dir, "b.pdf")
+		}, exitOK, 1},
+		{"部分失败", func(t *testing.T, dir string) {
+			writeTestPDF(t, dir, "a.pdf")
+			writeBrokenPDF(t, dir, "broken.pdf")
+		}, exitPartial, 1},
+		{"全部失败", func(t *testing.T, dir string) {
+			writeBrokenPDF(t, dir, "broken.pdf")
+		}, exitFailure, 0},
+		{"没有PDF文件", func(t *testing.T, dir string) {}, exitFailure, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input, out := t.TempDir(), t.TempDir()
+			tt.files(t, input)
+			var stdout, stderr bytes.Buffer
+			if got := runCLI([]string{"combine", "-quiet", "-output", out, input}, &stdout, &stderr); got != tt.want {
+				t.Errorf("退出码 %d, 期望 %d\n%s", got, tt.want, stderr.String())
+			}
+			if pngs := outputFiles(t, out, ".png"); len(pngs) != tt.pngs {
+				t.Errorf("输出 %v, 期望 %d 个PNG", pngs, tt.pngs)
+			}
+		})
+	}
+}
+
+func TestRunCLIExtract(t *testing.T) {
+	input, out := t.TempDir(), t.TempDir()
+	writeTestPDF(t, input, "a.pdf")
+	writeTestPDF(t, input, "b.pdf")
+	var stdout, stderr bytes.Buffer
+	// 选项可以出现在输入目录之后
+	if got := runCLI([]string{"extract", input, "-quiet", "-output", out, "-format", "svg"}, &stdout, &stderr); got != exitOK {
+		t.Fatalf("退出码 %d, 期望 %d\n%s", got, exitOK, stderr.String())
+	}
+	if svgs := outputFiles(t, out, ".svg"); len(svgs) != 2 {
+		t.Errorf("输出 %v, 期望每个图块一个SVG", svgs)
+	}
+}
+
+func TestRunCLIInspect(t *testing.T) {
+	input := t.TempDir()
+	writeTestPDF(t, input, "a.pdf")
+	var stdout, stderr bytes.Buffer
+	if got := runCLI([]string{"inspect", "-quiet", input}, &stdout, &stderr); got != exitOK {
+		t.Fatalf("退出码 %d, 期望 %d\n%s", got, exitOK, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "a.pdf\t共 1 页") || !strings.Contains(stdout.String(), "路径 1") {
+		t.Errorf("检查结果:\n%s", stdout.String())
+	}
+
+	writeBrokenPDF(t, input, "broken.pdf")
+	stdout.Reset()
+	if got := runCLI([]string{"inspect", "-quiet", input}, &stdout, &stderr); got != exitPartial {
+		t.Errorf("含损坏文件时退出码 %d, 期望 %d", got, exitPartial)
+	}
+}
@@ -0,0 +1,84 @@
+// Package crop 按内容比例把页面图像裁剪或缩放到固定大小的方框中
+package crop
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// 计算裁剪窗口：返回源图像中的裁剪区域及其在boxSize方框中的偏移
+func Window(center image.Point, contentBounds image.Rectangle, boxSize int) (image.Rectangle, image.Point) {
+	// 计算内容的宽高比
+	contentWidth := contentBounds.Dx()
+	contentHeight := contentBounds.Dy()
+	contentRatio := float64(contentWidth) / float64(contentHeight)
+
+	// 根据内容比例调整裁剪区域
+	var cropWidth, cropHeight int
+	if contentRatio > 1.0 {
+		// 宽度大于高度
+		cropWidth = boxSize
+		cropHeight = int(float64(boxSize) / contentRatio)
+	} else {
+		// 高度大于宽度
+		cropHeight = boxSize
+		cropWidth = int(float64(boxSize) * contentRatio)
+	}
+
+	// 确保不超过boxSize
+	if cropWidth > boxSize {
+		cropWidth = boxSize
+	}
+	if cropHeight > boxSize {
+		cropHeight = boxSize
+	}
+
+	// 计算裁剪区域
+	halfWidth := cropWidth / 2
+	halfHeight := cropHeight / 2
+	origin := image.Point{X: center.X - halfWidth, Y: center.Y - halfHeight}
+	cropBounds := image.Rectangle{Min: origin, Max: origin.Add(image.Point{X: cropWidth, Y: cropHeight})}
+
+	// 计算在目标图像中的位置（居中）
+	offset := image.Point{X: (boxSize - cropWidth) / 2, Y: (boxSize - cropHeight) / 2}
+
+	return cropBounds, offset
+}
+
+// 智能裁剪 - 考虑内容比例
+func SmartCrop(img image.Image, center image.Point, contentBounds image.Rectangle, boxSize int) image.Image {
+	cropBounds, offset := Window(center, contentBounds, boxSize)
+
+	// 创建裁剪后的图像，居中放置在标准boxSize中
+	croppedImg := image.NewRGBA(image.Rect(0, 0, boxSize, boxSize))
+
+	// 填充白色背景
+	white := color.RGBA{255, 255, 255, 255}
+	draw.Draw(croppedImg, croppedImg.Bounds(), &image.Uniform{white}, image.Point{}, draw.Src)
+
+	copyWindow(croppedImg, img, cropBounds, offset)
+	return croppedImg
+}
+
+// 把源图像中cropBounds区域的像素原样复制到dst的offset处，超出源图像的部分不复制
+func copyWindow(dst *image.RGBA, img image.Image, cropBounds image.Rectangle, offset image.Point) {
+	cropWidth, cropHeight := cropBounds.Dx(), cropBounds.Dy()
+	offsetX, offsetY := offset.X, offset.Y
+
+	// 复制像素
+	for y := 0; y < cropHeight; y++ {
+		for x := 0; x < cropWidth; x++ {
+			srcX := cropBounds.Min.X + x
+			srcY := cropBounds.Min.Y + y
+			dstX := offsetX + x
+			dstY := offsetY + y
+
+			// 检查源图像边界
+			if srcX >= img.Bounds().Min.X && srcX < img.Bounds().Max.X &&
+				srcY >= img.Bounds().Min.Y && srcY < img.Bounds().Max.Y {
+				dst.Set(dstX, dstY, img.At(srcX, srcY))
+			}
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package crop
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"strings"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// 内容缩放到方框的方式
+const (
+	FitNone      = "none"      // 不缩放，按内容比例裁剪方框大小的窗口
+	FitContain   = "contain"   // 等比缩放使整个内容放入方框
+	FitCover     = "cover"     // 等比缩放使内容铺满方框，超出部分裁掉
+	FitDownscale = "downscale" // 同contain，但只缩小不放大
+)
+
+// 缩放时的重采样滤镜
+const (
+	FilterNearest    = "nearest"
+	FilterBilinear   = "bilinear"
+	FilterCatmullRom = "catmullrom"
+	FilterLanczos    = "lanczos"
+)
+
+// 默认重采样滤镜，放大和缩小的效果都较好
+const DefaultFilter = FilterCatmullRom
+
+// 所有缩放方式
+func FitModes() []string {
+	return []string{FitNone, FitContain, FitCover, FitDownscale}
+}
+
+// 所有重采样滤镜
+func Filters() []string {
+	return []string{FilterNearest, FilterBilinear, FilterCatmullRom, FilterLanczos}
+}
+
+// Lanczos-3 核
+var lanczos3 = &xdraw.Kernel{Support: 3, At: func(t float64) float64 {
+	if t == 0 {
+		return 1
+	}
+	pt := math.Pi * t
+	return 3 * math.Sin(pt) * math.Sin(pt/3) / (pt * pt)
+}}
+
+// 查找重采样滤镜，空为默认滤镜
+func lookupFilter(name string) (xdraw.Scaler, error) {
+	switch strings.ToLower(name) {
+	case FilterNearest:
+		return xdraw.NearestNeighbor, nil
+	case FilterBilinear:
+		return xdraw.BiLinear, nil
+	case "", FilterCatmullRom:
+		return xdraw.CatmullRom, nil
+	case FilterLanczos:
+		return lanczos3, nil
+	}
+	return nil, fmt.Errorf("未知的重采样滤镜: %s（可选 %s）", name, strings.Join(Filters(), "、"))
+}
+
+// 检查缩放方式和重采样滤镜
+func ValidateFit(mode, filter string) error {
+	switch mode {
+	case "", FitNone, FitContain, FitCover, FitDownscale:
+	default:
+		return fmt.Errorf("未知的缩放方式: %s（可选 %s）", mode, strings.Join(FitModes(), "、"))
+	}
+	_, err := lookupFilter(filter)
+	return err
+}
+
+// 按缩放方式计算源图像中的裁剪区域及其缩放后在boxSize方框中的位置
+// 内容以center为中心；不缩放时与Window相同
+func FitWindow(center image.Point, contentBounds image.Rectangle, boxSize int, mode string) (src, dst image.Rectangle) {
+	w, h := contentBounds.Dx(), contentBounds.Dy()
+	if mode == "" || mode == FitNone || w <= 0 || h <= 0 {
+		src, offset := Window(center, contentBounds, boxSize)
+		return src, image.Rectangle{Min: offset, Max: offset.Add(src.Size())}
+	}
+
+	box := float64(boxSize)
+	var scale float64
+	switch mode {
+	case FitCover:
+		// 按短边铺满方框，窗口为以短边为边长的正方形
+		side := min(w, h)
+		w, h = side, side
+		scale = box / float64(side)
+	case FitDownscale:
+		scale = min(box/float64(max(w, h)), 1)
+	default:
+		scale = box / float64(max(w, h))
+	}
+
+	origin := image.Pt(center.X-w/2, center.Y-h/2)
+	src = image.Rectangle{Min: origin, Max: origin.Add(image.Pt(w, h))}
+	size := image.Pt(
+		min(max(int(math.Round(float64(w)*scale)), 1), boxSize),
+		min(max(int(math.Round(float64(h)*scale)), 1), boxSize),
+	)
+	offset := image.Pt((boxSize-size.X)/2, (boxSize-size.Y)/2)
+	return src, image.Rectangle{Min: offset, Max: offset.Add(size)}
+}
+
+// 把源图像中的src区域缩放到boxSize方框中的dst区域，方框的其余部分和超出源图像的部分填充fill
+// 大小相同时直接复制像素
+func Resample(img image.Image, src, dst image.Rectangle, boxSize int, filter string, fill color.RGBA) (*image.RGBA, error) {
+	scaler, err := lookupFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	out := image.NewRGBA(image.Rect(0, 0, boxSize, boxSize))
+	if fill.A > 0 {
+		xdraw.Draw(out, out.Bounds(), &image.Uniform{fill}, image.Point{}, xdraw.Src)
+	}
+
+	if src.Size() == dst.Size() {
+		copyWindow(out, img, src, dst.Min)
+		return out, nil
+	}
+
+	// 只缩放源图像内的部分，目标区域按比例对应
+	clipped := src.Intersect(img.Bounds())
+	if clipped.Empty() {
+		return out, nil
+	}
+	sx := float64(dst.Dx()) / float64(src.Dx())
+	sy := float64(dst.Dy()) / float64(src.Dy())
+	dr := image.Rect(
+		dst.Min.X+int(math.Round(float64(clipped.Min.X-src.Min.X)*sx)),
+		dst.Min.Y+int(math.Round(float64(clipped.Min.Y-src.Min.Y)*sy)),
+		dst.Min.X+int(math.Round(float64(clipped.Max.X-src.Min.X)*sx)),
+		dst.Min.Y+int(math.Round(float64(clipped.Max.Y-src.Min.Y)*sy)),
+	)
+	scaler.Scale(out, dr, img, clipped, xdraw.Src, nil)
+	return out, nil
+}
@@ -0,0 +1,128 @@
+package crop
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+// 白底页面，rect区域为黑色
+func testPage(rect image.Rectangle) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 400, 400))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(img, rect, image.Black, image.Point{}, draw.Src)
+	return img
+}
+
+func TestResampleFill(t *testing.T) {
+	// 100x50的内容不缩放放入200方框，上下各留50像素
+	content := image.Rect(150, 175, 250, 225)
+	img := testPage(content)
+	src, dst := FitWindow(image.Pt(200, 200), content, 200, FitNone)
+
+	tests := []struct {
+		name string
+		fill color.RGBA
+	}{
+		{"不透明背景", color.RGBA{255, 240, 200, 255}},
+		{"透明", color.RGBA{}},
+	}
+	for _, tt := range tests {
+		tile, err := Resample(img, src, dst, 200, DefaultFilter, tt.fill)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// 裁剪窗口以外的方框部分为填充颜色，窗口内为页面像素
+		if got := tile.RGBAAt(100, 20); got != tt.fill {
+			t.Errorf("%s: 方框空白处 %v, 期望 %v", tt.name, got, tt.fill)
+		}
+		if got := tile.RGBAAt(100, 100); got != (color.RGBA{0, 0, 0, 255}) {
+			t.Errorf("%s: 内容 %v, 期望黑色", tt.name, got)
+		}
+	}
+
+	// 超出页面的窗口部分同样为填充颜色
+	fill := color.RGBA{0, 0, 255, 255}
+	tile, err := Resample(img, image.Rect(-50, 0, 150, 200), image.Rect(0, 0, 200, 200), 200, DefaultFilter, fill)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := tile.RGBAAt(10, 10); got != fill {
+		t.Errorf("页面外 %v, 期望 %v", got, fill)
+	}
+	if got := tile.RGBAAt(60, 10); got != (color.RGBA{255, 255, 255, 255}) {
+		t.Errorf("页面内 %v, 期望白色", got)
+	}
+}
+
+func TestFitWindow(t *testing.T) {
+	// 以(200, 200)为中心的100x50内容放入200方框
+	content := image.Rect(150, 175, 250, 225)
+	tests := []struct {
+		mode     string
+		src, dst image.Rectangle
+	}{
+		// 不缩放：按内容比例裁剪方框大小的窗口
+		{FitNone, image.Rect(100, 150, 300, 250), image.Rect(0, 50, 200, 150)},
+		// 完整放入：放大2倍，上下留白
+		{FitContain, image.Rect(150, 175, 250, 225), image.Rect(0, 50, 200, 150)},
+		// 铺满：按短边取正方形窗口，放大4倍
+		{FitCover, image.Rect(175, 175, 225, 225), image.Rect(0, 0, 200, 200)},
+		// 只缩小：内容小于方框时保持原大小并居中
+		{FitDownscale, image.Rect(150, 175, 250, 225), image.Rect(50, 75, 150, 125)},
+	}
+	for _, tt := range tests {
+		src, dst := FitWindow(image.Pt(200, 200), content, 200, tt.mode)
+		if src != tt.src || dst != tt.dst {
+			t.Errorf("%s: 裁剪区域 %v 放入 %v, 期望 %v 放入 %v", tt.mode, src, dst, tt.src, tt.dst)
+		}
+	}
+
+	// 内容大于方框时只缩小与完整放入相同
+	large := image.Rect(0, 100, 400, 300)
+	src, dst := FitWindow(image.Pt(200, 200), large, 200, FitDownscale)
+	if src != large || dst != image.Rect(0, 50, 200, 150) {
+		t.Errorf("只缩小大内容: %v 放入 %v", src, dst)
+	}
+}
+
+func TestResampleScales(t *testing.T) {
+	content := image.Rect(150, 175, 250, 225)
+	img := testPage(content)
+	for _, filter := range Filters() {
+		src, dst := FitWindow(image.Pt(200, 200), content, 200, FitContain)
+		tile, err := Resample(img, src, dst, 200, filter, color.RGBA{255, 255, 255, 255})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// 放大后内容铺满dst，边缘以内为黑色，dst以外为填充色
+		for _, p := range []image.Point{{5, 55}, {100, 100}, {194, 144}} {
+			if got := tile.RGBAAt(p.X, p.Y); got.R > 16 {
+				t.Errorf("%s: 内容 (%d, %d) 为 %v, 期望黑色", filter, p.X, p.Y, got)
+			}
+		}
+		for _, p := range []image.Point{{100, 45}, {100, 155}} {
+			if got := tile.RGBAAt(p.X, p.Y); got.R < 240 {
+				t.Errorf("%s: 空白 (%d, %d) 为 %v, 期望白色", filter, p.X, p.Y, got)
+			}
+		}
+	}
+	if _, err := Resample(img, content, content, 200, "box", color.RGBA{}); err == nil {
+		t.Error("未知的滤镜应返回错误")
+	}
+}
+
+func TestValidateFit(t *testing.T) {
+	for _, mode := range append(FitModes(), "") {
+		if err := ValidateFit(mode, ""); err != nil {
+			t.Errorf("%q: %v", mode, err)
+		}
+	}
+	if err := ValidateFit("stretch", ""); err == nil {
+		t.Error("未知的缩放方式应返回错误")
+	}
+	if err := ValidateFit(FitContain, "box"); err == nil {
+		t.Error("未知的滤镜应返回错误")
+	}
+}
@@ -0,0 +1,78 @@
+package detect
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"math"
+)
+
+// 图像某个区域中内容（墨迹）的分布，用于按视觉中心对齐
+type Ink struct {
+	Bounds   image.Rectangle // 内容像素的外接矩形
+	Centroid image.Point     // 按墨量加权的重心，长尾或下伸部分只占很少的墨量
+	Baseline int             // 基线：内容主体底部的下一行，下伸部分（如g、y的尾巴）在基线之下
+}
+
+// 测量区域r中的内容分布：内容像素按检测参数opts标记，与检测器对内容的判定一致；
+// 墨量按与区域边缘估计的背景色的差值计算，区域中没有内容时ok为false
+func MeasureInk(ctx context.Context, img image.Image, r image.Rectangle, opts Options) (ink Ink, ok bool, err error) {
+	r = r.Intersect(img.Bounds())
+	if r.Empty() {
+		return Ink{}, false, nil
+	}
+	mask, err := contentMask(ctx, subImage{img, r}, opts)
+	if err != nil {
+		return Ink{}, false, err
+	}
+	bg := estimateBackground(img, r)
+
+	rows := make([]int, r.Dy()) // 每行的内容像素数
+	var sumX, sumY, total float64
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if !mask[(y-r.Min.Y)*r.Dx()+x-r.Min.X] {
+				continue
+			}
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			px := image.Rect(x, y, x+1, y+1)
+			if ink.Bounds.Empty() {
+				ink.Bounds = px
+			} else {
+				ink.Bounds = ink.Bounds.Union(px)
+			}
+			rows[y-r.Min.Y]++
+			// 边缘检测等方法标记的像素可能接近背景色，至少计1
+			w := float64(max(colorDistance(c, bg), 1))
+			sumX += (float64(x) + 0.5) * w
+			sumY += (float64(y) + 0.5) * w
+			total += w
+		}
+	}
+	if total == 0 {
+		return Ink{}, false, nil
+	}
+	ink.Centroid = image.Pt(int(math.Floor(sumX/total)), int(math.Floor(sumY/total)))
+
+	// 主体部分每行的内容较多，下伸部分只有少数字形：
+	// 基线取内容像素数不少于最多一行1/3的最低一行之下
+	most := 0
+	for _, n := range rows {
+		most = max(most, n)
+	}
+	for i := len(rows) - 1; i >= 0; i-- {
+		if rows[i]*3 >= most {
+			ink.Baseline = r.Min.Y + i + 1
+			break
+		}
+	}
+	return ink, true, nil
+}
+
+// 图像中的一个区域，按区域范围参与检测
+type subImage struct {
+	image.Image
+	r image.Rectangle
+}
+
+func (s subImage) Bounds() image.Rectangle { return s.r }
@@ -0,0 +1,33 @@
+package detect
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+func TestMeasureInkUsesOptions(t *testing.T) {
+	// 白底上与背景相差25的浅灰色方块，默认容差下不算内容
+	img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	draw.Draw(img, img.Bounds(), &image.Uniform{color.White}, image.Point{}, draw.Src)
+	square := image.Rect(20, 30, 50, 70)
+	draw.Draw(img, square, &image.Uniform{color.Gray{230}}, image.Point{}, draw.Src)
+
+	if _, ok, err := MeasureInk(context.Background(), img, img.Bounds(), Options{Method: MethodBackground}); err != nil || ok {
+		t.Errorf("默认容差: ok = %v, err = %v, 期望没有内容", ok, err)
+	}
+	ink, ok, err := MeasureInk(context.Background(), img, img.Bounds(), Options{Method: MethodBackground, Threshold: 10})
+	if err != nil || !ok {
+		t.Fatalf("容差10: ok = %v, err = %v, 期望找到内容", ok, err)
+	}
+	if ink.Bounds != square || ink.Centroid != image.Pt(35, 50) || ink.Baseline != 70 {
+		t.Errorf("容差10: %+v, 期望外接矩形 %v、重心 (35,50)、基线 70", ink, square)
+	}
+
+	// 区域之外的内容不参与测量
+	if _, ok, _ := MeasureInk(context.Background(), img, image.Rect(60, 0, 100, 100), Options{Method: MethodBackground, Threshold: 10}); ok {
+		t.Error("区域中没有内容时期望ok为false")
+	}
+}
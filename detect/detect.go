@@ -0,0 +1,131 @@
+// Package detect 检测页面图像中的内容边界和中心点
+package detect
+
+import (
+	"context"
+	"image"
+	"image/color"
+)
+
+// 检测图像的内容边界，返回内容中心点和带边距的内容区域
+func Bounds(img image.Image) (image.Point, image.Rectangle, error) {
+	return BoundsContext(context.Background(), img)
+}
+
+// 同Bounds，每个处理步骤之前检查ctx是否已取消
+func BoundsContext(ctx context.Context, img image.Image) (image.Point, image.Rectangle, error) {
+	return edgeBounds(ctx, img, DefaultEdgeThreshold, DefaultMargin)
+}
+
+// 按边缘强度阈值检测内容边界，内容区域四周加上margin
+func edgeBounds(ctx context.Context, img image.Image, threshold uint8, margin int) (image.Point, image.Rectangle, error) {
+	if err := ctx.Err(); err != nil {
+		return image.Point{}, image.Rectangle{}, err
+	}
+	// 转换为灰度以便更好的处理
+	grayImg := toGrayscale(img)
+
+	if err := ctx.Err(); err != nil {
+		return image.Point{}, image.Rectangle{}, err
+	}
+	// 边缘检测
+	edges := detectEdges(grayImg)
+
+	if err := ctx.Err(); err != nil {
+		return image.Point{}, image.Rectangle{}, err
+	}
+	// 找到内容区域
+	contentBounds := findContentBounds(edges, threshold, margin)
+
+	// 计算中心点
+	center := image.Point{
+		X: (contentBounds.Min.X + contentBounds.Max.X) / 2,
+		Y: (contentBounds.Min.Y + contentBounds.Max.Y) / 2,
+	}
+
+	return center, contentBounds, nil
+}
+
+// 转换为灰度图像
+func toGrayscale(img image.Image) *image.Gray {
+	bounds := img.Bounds()
+	gray := image.NewGray(bounds)
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.GrayModel.Convert(img.At(x, y))
+			gray.Set(x, y, c)
+		}
+	}
+
+	return gray
+}
+
+// 简单的边缘检测
+func detectEdges(img *image.Gray) *image.Gray {
+	bounds := img.Bounds()
+	edges := image.NewGray(bounds)
+
+	for y := bounds.Min.Y + 1; y < bounds.Max.Y - 1; y++ {
+		for x := bounds.Min.X + 1; x < bounds.Max.X - 1; x++ {
+			// Sobel算子
+			gx := int(img.GrayAt(x+1, y-1).Y) + 2*int(img.GrayAt(x+1, y).Y) + int(img.GrayAt(x+1, y+1).Y) -
+				 int(img.GrayAt(x-1, y-1).Y) - 2*int(img.GrayAt(x-1, y).Y) - int(img.GrayAt(x-1, y+1).Y)
+
+			gy := int(img.GrayAt(x-1, y+1).Y) + 2*int(img.GrayAt(x, y+1).Y) + int(img.GrayAt(x+1, y+1).Y) -
+				 int(img.GrayAt(x-1, y-1).Y) - 2*int(img.GrayAt(x, y-1).Y) - int(img.GrayAt(x+1, y-1).Y)
+
+			magnitude := int(float64(gx*gx + gy*gy) * 0.5)
+			if magnitude > 255 {
+				magnitude = 255
+			}
+
+			edges.SetGray(x, y, color.Gray{Y: uint8(magnitude)})
+		}
+	}
+
+	return edges
+}
+
+// 找到内容边界
+func findContentBounds(edges *image.Gray, threshold uint8, margin int) image.Rectangle {
+	bounds := edges.Bounds()
+
+	minX, minY := bounds.Max.X, bounds.Max.Y
+	maxX, maxY := bounds.Min.X, bounds.Min.Y
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if edges.GrayAt(x, y).Y > threshold {
+				if x < minX {
+					minX = x
+				}
+				if x > maxX {
+					maxX = x
+				}
+				if y < minY {
+					minY = y
+				}
+				if y > maxY {
+					maxY = y
+				}
+			}
+		}
+	}
+
+	// 没有边缘像素（空白图像）时返回整个图像，与其他检测方法一致
+	if minX > maxX {
+		return bounds
+	}
+
+	// 添加一些边距
+	minX = max(bounds.Min.X, minX-margin)
+	minY = max(bounds.Min.Y, minY-margin)
+	maxX = min(bounds.Max.X, maxX+margin)
+	maxY = min(bounds.Max.Y, maxY+margin)
+
+	return image.Rectangle{
+		Min: image.Point{X: minX, Y: minY},
+		Max: image.Point{X: maxX, Y: maxY},
+	}
+}
@@ -0,0 +1,174 @@
+package detect
+
+import (
+	"context"
+	"image"
+	"sort"
+)
+
+// 分割出的一个独立内容
+type Region struct {
+	Center image.Point
+	Bounds image.Rectangle // 带边距的内容区域
+}
+
+// 分割参数
+type SegmentOptions struct {
+	Options           // 标记内容像素的检测方法、阈值和每个内容的边距
+	MergeDistance int // 间距不超过该值 (像素) 的连通区域合并为一个内容，使一个词的各个字形保持在一起
+	MinArea       int // 外接矩形面积小于该值 (平方像素) 的内容视为噪点丢弃
+}
+
+// 按连通区域把页面分割为多个独立内容，按从上到下、从左到右的阅读顺序返回
+// 没有符合条件的内容时返回空切片
+func Segments(ctx context.Context, img image.Image, opts SegmentOptions) ([]Region, error) {
+	if img.Bounds().Empty() {
+		return nil, nil
+	}
+	mask, err := contentMask(ctx, img, opts.Options)
+	if err != nil {
+		return nil, err
+	}
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+
+	// 膨胀后再标记连通区域，距离在合并距离内的区域因此相连
+	connect := mask
+	if r := (opts.MergeDistance + 1) / 2; r > 0 {
+		connect = dilate(mask, w, h, r)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	labels, count := labelComponents(connect, w, h)
+
+	// 外接矩形只统计原始边缘像素，不含膨胀的部分
+	boxes := make([]image.Rectangle, count)
+	for i := 0; i < w*h; i++ {
+		if !mask[i] {
+			continue
+		}
+		px := image.Rect(i%w, i/w, i%w+1, i/w+1)
+		if l := labels[i] - 1; boxes[l].Empty() {
+			boxes[l] = px
+		} else {
+			boxes[l] = boxes[l].Union(px)
+		}
+	}
+
+	var regions []Region
+	for _, box := range boxes {
+		if box.Empty() || box.Dx()*box.Dy() < opts.MinArea {
+			continue
+		}
+		r := withMargin(box.Add(bounds.Min), bounds, opts.Margin)
+		regions = append(regions, Region{
+			Center: image.Pt((r.Min.X+r.Max.X)/2, (r.Min.Y+r.Max.Y)/2),
+			Bounds: r,
+		})
+	}
+	sortReadingOrder(regions)
+	return regions, nil
+}
+
+// 方形结构元素的膨胀，半径r，按行和列分两次计算
+func dilate(mask []bool, w, h, r int) []bool {
+	rows := make([]bool, len(mask))
+	for y := 0; y < h; y++ {
+		spread(mask[y*w:(y+1)*w], rows[y*w:(y+1)*w], r)
+	}
+	out := make([]bool, len(mask))
+	col := make([]bool, h)
+	dst := make([]bool, h)
+	for x := 0; x < w; x++ {
+		for y := 0; y < h; y++ {
+			col[y] = rows[y*w+x]
+		}
+		spread(col, dst, r)
+		for y := 0; y < h; y++ {
+			out[y*w+x] = dst[y]
+		}
+	}
+	return out
+}
+
+// 一维膨胀：dst[i]为src在[i-r, i+r]内是否有真值
+func spread(src, dst []bool, r int) {
+	last := -r - 1 // 最近一个真值的位置
+	for i := range src {
+		if src[i] {
+			last = i
+		}
+		dst[i] = i-last <= r
+	}
+	last = len(src) + r + 1
+	for i := len(src) - 1; i >= 0; i-- {
+		if src[i] {
+			last = i
+		}
+		dst[i] = dst[i] || last-i <= r
+	}
+}
+
+// 标记8连通区域，返回每个像素的区域编号（从1开始，0为背景）和区域数
+func labelComponents(mask []bool, w, h int) ([]int32, int) {
+	labels := make([]int32, len(mask))
+	var stack []int
+	count := 0
+	for start := range mask {
+		if !mask[start] || labels[start] != 0 {
+			continue
+		}
+		count++
+		labels[start] = int32(count)
+		stack = append(stack[:0], start)
+		for len(stack) > 0 {
+			i := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			x, y := i%w, i/w
+			for dy := -1; dy <= 1; dy++ {
+				for dx := -1; dx <= 1; dx++ {
+					nx, ny := x+dx, y+dy
+					if nx < 0 || ny < 0 || nx >= w || ny >= h {
+						continue
+					}
+					if n := ny*w + nx; mask[n] && labels[n] == 0 {
+						labels[n] = int32(count)
+						stack = append(stack, n)
+					}
+				}
+			}
+		}
+	}
+	return labels, count
+}
+
+// 按阅读顺序排列：纵向有重叠的内容视为同一行，行内从左到右
+// 先按上边缘、再按左边缘排序，左上角相同时按下边缘和右边缘，结果与标记顺序无关
+func sortReadingOrder(regions []Region) {
+	sort.SliceStable(regions, func(i, j int) bool {
+		a, b := regions[i].Bounds, regions[j].Bounds
+		switch {
+		case a.Min.Y != b.Min.Y:
+			return a.Min.Y < b.Min.Y
+		case a.Min.X != b.Min.X:
+			return a.Min.X < b.Min.X
+		case a.Max.Y != b.Max.Y:
+			return a.Max.Y < b.Max.Y
+		}
+		return a.Max.X < b.Max.X
+	})
+	for start := 0; start < len(regions); {
+		bottom := regions[start].Bounds.Max.Y
+		end := start + 1
+		for end < len(regions) && regions[end].Bounds.Min.Y < bottom {
+			bottom = max(bottom, regions[end].Bounds.Max.Y)
+			end++
+		}
+		row := regions[start:end]
+		sort.SliceStable(row, func(i, j int) bool {
+			return row[i].Bounds.Min.X < row[j].Bounds.Min.X
+		})
+		start = end
+	}
+}
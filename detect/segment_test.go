@@ -0,0 +1,112 @@
+package detect
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/draw"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// 白底图像上绘制黑色矩形
+func blocksImage(blocks ...image.Rectangle) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 300, 200))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	for _, b := range blocks {
+		draw.Draw(img, b, &image.Uniform{color.Black}, image.Point{}, draw.Src)
+	}
+	return img
+}
+
+// 分割结果的区域
+func regionBounds(t *testing.T, img image.Image, opts SegmentOptions) []image.Rectangle {
+	t.Helper()
+	regions, err := Segments(context.Background(), img, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var bounds []image.Rectangle
+	for _, r := range regions {
+		bounds = append(bounds, r.Bounds)
+	}
+	return bounds
+}
+
+func TestSegmentsMergeDistance(t *testing.T) {
+	// 两个方块相距10像素
+	img := blocksImage(image.Rect(20, 20, 60, 60), image.Rect(70, 20, 110, 60))
+	opts := SegmentOptions{Options: Options{Method: MethodBackground}}
+
+	opts.MergeDistance = 5
+	if got := regionBounds(t, img, opts); len(got) != 2 {
+		t.Errorf("合并距离5: %v, 期望2个内容", got)
+	}
+	opts.MergeDistance = 10
+	got := regionBounds(t, img, opts)
+	// 外接矩形只包含原始像素，不含膨胀部分
+	if len(got) != 1 || got[0] != image.Rect(20, 20, 109, 59) {
+		t.Errorf("合并距离10: %v, 期望合并为1个内容", got)
+	}
+}
+
+func TestSegmentsMinArea(t *testing.T) {
+	// 大方块和3x3的噪点
+	img := blocksImage(image.Rect(20, 20, 60, 60), image.Rect(200, 100, 203, 103))
+	opts := SegmentOptions{Options: Options{Method: MethodBackground}}
+	if got := regionBounds(t, img, opts); len(got) != 2 {
+		t.Errorf("最小面积0: %v, 期望2个内容", got)
+	}
+	// 面积按不含边距的外接矩形计算，噪点面积为9
+	opts.MinArea = 9
+	if got := regionBounds(t, img, opts); len(got) != 2 {
+		t.Errorf("最小面积9: %v, 期望保留噪点", got)
+	}
+	opts.MinArea = 10
+	if got := regionBounds(t, img, opts); len(got) != 1 || got[0].Min != image.Pt(20, 20) {
+		t.Errorf("最小面积10: %v, 期望只保留大方块", got)
+	}
+}
+
+func TestSegmentsReadingOrder(t *testing.T) {
+	// 第一行右侧的方块比左侧的高，纵向重叠仍算同一行；第二行在下方
+	img := blocksImage(
+		image.Rect(200, 10, 240, 50),
+		image.Rect(20, 30, 60, 70),
+		image.Rect(120, 120, 160, 160),
+		image.Rect(10, 130, 50, 170),
+	)
+	got := regionBounds(t, img, SegmentOptions{Options: Options{Method: MethodBackground}})
+	want := []image.Point{{20, 30}, {200, 10}, {10, 130}, {120, 120}}
+	if len(got) != len(want) {
+		t.Fatalf("分割结果 %v", got)
+	}
+	for i, p := range want {
+		if got[i].Min != p {
+			t.Errorf("第%d个内容 %v, 期望从 %v 开始", i+1, got[i], p)
+		}
+	}
+}
+
+func TestSortReadingOrderDeterministic(t *testing.T) {
+	// 上边缘或左上角相同的区域同样有确定的顺序，结果与输入顺序无关
+	want := []Region{
+		{Bounds: image.Rect(0, 0, 10, 10)},
+		{Bounds: image.Rect(0, 0, 10, 20)},
+		{Bounds: image.Rect(20, 0, 30, 10)},
+		{Bounds: image.Rect(20, 5, 30, 10)},
+		{Bounds: image.Rect(40, 0, 50, 10)},
+		{Bounds: image.Rect(0, 30, 10, 40)},
+		{Bounds: image.Rect(15, 30, 25, 40)},
+	}
+	rng := rand.New(rand.NewSource(1))
+	for n := 0; n < 50; n++ {
+		regions := append([]Region(nil), want...)
+		rng.Shuffle(len(regions), func(i, j int) { regions[i], regions[j] = regions[j], regions[i] })
+		sortReadingOrder(regions)
+		if !reflect.DeepEqual(regions, want) {
+			t.Fatalf("排序结果 %v, 期望 %v", regions, want)
+		}
+	}
+}
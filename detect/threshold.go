@@ -0,0 +1,282 @@
+package detect
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"sort"
+	"strings"
+)
+
+// 内容检测方法
+const (
+	MethodEdge       = "edge"       // Sobel边缘检测
+	MethodOtsu       = "otsu"       // 按灰度直方图自动选取全局阈值 (Otsu)
+	MethodAdaptive   = "adaptive"   // 与邻域平均灰度比较的局部阈值，适合光照不均的扫描件
+	MethodBackground = "background" // 从页面边缘估计背景颜色，与背景色差别足够大的像素为内容
+)
+
+// 各检测方法的默认阈值和默认边距
+const (
+	DefaultEdgeThreshold       = 50 // 边缘强度
+	DefaultAdaptiveOffset      = 10 // 比邻域平均灰度暗（或亮）的程度
+	DefaultBackgroundTolerance = 32 // 与背景色各通道的最大差值
+	DefaultMargin              = 20
+)
+
+// 所有检测方法
+func Methods() []string {
+	return []string{MethodEdge, MethodOtsu, MethodAdaptive, MethodBackground}
+}
+
+// 检测参数
+type Options struct {
+	Method    string // 检测方法，空为edge
+	Threshold int    // 阈值 (0-255)，0表示使用该方法的默认值；Otsu自动选取阈值，不使用该值
+	Margin    int    // 内容区域四周的边距 (像素)
+}
+
+// 检查检测方法和阈值
+func (o Options) Validate() error {
+	switch o.Method {
+	case "", MethodEdge, MethodOtsu, MethodAdaptive, MethodBackground:
+	default:
+		return fmt.Errorf("未知的检测方法: %s（可选 %s）", o.Method, strings.Join(Methods(), "、"))
+	}
+	if o.Threshold < 0 || o.Threshold > 255 {
+		return fmt.Errorf("检测阈值应在0-255之间: %d", o.Threshold)
+	}
+	if o.Margin < 0 {
+		return fmt.Errorf("检测边距不能为负数: %d", o.Margin)
+	}
+	return nil
+}
+
+// 实际使用的阈值
+func (o Options) threshold() int {
+	if o.Threshold > 0 {
+		return o.Threshold
+	}
+	switch o.Method {
+	case MethodAdaptive:
+		return DefaultAdaptiveOffset
+	case MethodBackground:
+		return DefaultBackgroundTolerance
+	}
+	return DefaultEdgeThreshold
+}
+
+// 按检测方法返回内容中心点和带边距的内容区域，没有找到内容时返回整个图像
+func Detect(ctx context.Context, img image.Image, opts Options) (image.Point, image.Rectangle, error) {
+	if bounds := img.Bounds(); bounds.Empty() {
+		return image.Pt((bounds.Min.X+bounds.Max.X)/2, (bounds.Min.Y+bounds.Max.Y)/2), bounds, nil
+	}
+	if opts.Method == "" || opts.Method == MethodEdge {
+		return edgeBounds(ctx, img, uint8(opts.threshold()), opts.Margin)
+	}
+	mask, err := contentMask(ctx, img, opts)
+	if err != nil {
+		return image.Point{}, image.Rectangle{}, err
+	}
+	bounds := img.Bounds()
+	w := bounds.Dx()
+	var box image.Rectangle
+	for i, ink := range mask {
+		if !ink {
+			continue
+		}
+		px := image.Rect(i%w, i/w, i%w+1, i/w+1)
+		if box.Empty() {
+			box = px
+		} else {
+			box = box.Union(px)
+		}
+	}
+	if box.Empty() {
+		return image.Pt((bounds.Min.X+bounds.Max.X)/2, (bounds.Min.Y+bounds.Max.Y)/2), bounds, nil
+	}
+	r := withMargin(box.Add(bounds.Min), bounds, opts.Margin)
+	return image.Pt((r.Min.X+r.Max.X)/2, (r.Min.Y+r.Max.Y)/2), r, nil
+}
+
+// 外接矩形四周加上边距，不超出图像，与findContentBounds的取整方式一致
+func withMargin(box, bounds image.Rectangle, margin int) image.Rectangle {
+	return image.Rect(
+		max(bounds.Min.X, box.Min.X-margin),
+		max(bounds.Min.Y, box.Min.Y-margin),
+		min(bounds.Max.X, box.Max.X-1+margin),
+		min(bounds.Max.Y, box.Max.Y-1+margin),
+	)
+}
+
+// 按检测方法标记内容像素，按行排列，与图像同样大小
+func contentMask(ctx context.Context, img image.Image, opts Options) ([]bool, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	mask := make([]bool, w*h)
+
+	if opts.Method == MethodBackground {
+		bg := estimateBackground(img, bounds)
+		tolerance := opts.threshold()
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				c := color.NRGBAModel.Convert(img.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.NRGBA)
+				mask[y*w+x] = colorDistance(c, bg) > tolerance
+			}
+		}
+		return mask, nil
+	}
+
+	gray := toGrayscale(img)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	switch opts.Method {
+	case MethodOtsu:
+		t := otsuThreshold(gray)
+		// 背景为浅色时较暗的一类为内容，否则较亮的一类为内容
+		darkInk := borderGray(gray) > t
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				v := int(gray.GrayAt(bounds.Min.X+x, bounds.Min.Y+y).Y)
+				mask[y*w+x] = (darkInk && v <= t) || (!darkInk && v > t)
+			}
+		}
+	case MethodAdaptive:
+		adaptiveMask(gray, mask, opts.threshold())
+	default:
+		edges := detectEdges(gray)
+		t := uint8(opts.threshold())
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				mask[y*w+x] = edges.GrayAt(bounds.Min.X+x, bounds.Min.Y+y).Y > t
+			}
+		}
+	}
+	return mask, nil
+}
+
+// Otsu阈值：使前景和背景两类灰度的类间方差最大，灰度不大于阈值的为一类
+func otsuThreshold(gray *image.Gray) int {
+	var hist [256]int
+	bounds := gray.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			hist[gray.GrayAt(x, y).Y]++
+		}
+	}
+	total := bounds.Dx() * bounds.Dy()
+	sum := 0.0
+	for v, n := range hist {
+		sum += float64(v * n)
+	}
+
+	best, bestVar := 0, -1.0
+	sumB, weightB := 0.0, 0
+	for t := 0; t < 256; t++ {
+		weightB += hist[t]
+		if weightB == 0 {
+			continue
+		}
+		weightF := total - weightB
+		if weightF == 0 {
+			break
+		}
+		sumB += float64(t * hist[t])
+		meanB := sumB / float64(weightB)
+		meanF := (sum - sumB) / float64(weightF)
+		if v := float64(weightB) * float64(weightF) * (meanB - meanF) * (meanB - meanF); v > bestVar {
+			best, bestVar = t, v
+		}
+	}
+	return best
+}
+
+// 局部阈值：比邻域平均灰度暗offset以上的像素为内容，背景为深色时取亮的像素
+// 邻域为边长约为图像短边1/16的正方形，用积分图计算平均值
+func adaptiveMask(gray *image.Gray, mask []bool, offset int) {
+	bounds := gray.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	integral := make([]int64, (w+1)*(h+1))
+	for y := 0; y < h; y++ {
+		var row int64
+		for x := 0; x < w; x++ {
+			row += int64(gray.GrayAt(bounds.Min.X+x, bounds.Min.Y+y).Y)
+			integral[(y+1)*(w+1)+x+1] = integral[y*(w+1)+x+1] + row
+		}
+	}
+
+	r := max(min(w, h)/32, 7)
+	darkInk := borderGray(gray) > 127
+	for y := 0; y < h; y++ {
+		y0, y1 := max(y-r, 0), min(y+r+1, h)
+		for x := 0; x < w; x++ {
+			x0, x1 := max(x-r, 0), min(x+r+1, w)
+			sum := integral[y1*(w+1)+x1] - integral[y0*(w+1)+x1] - integral[y1*(w+1)+x0] + integral[y0*(w+1)+x0]
+			mean := int(sum / int64((x1-x0)*(y1-y0)))
+			v := int(gray.GrayAt(bounds.Min.X+x, bounds.Min.Y+y).Y)
+			mask[y*w+x] = (darkInk && v < mean-offset) || (!darkInk && v > mean+offset)
+		}
+	}
+}
+
+// 页面边缘一圈像素的灰度中位数，用于判断背景是浅色还是深色；空图像按白色背景处理
+func borderGray(gray *image.Gray) int {
+	var values []int
+	forBorder(gray.Bounds(), func(x, y int) {
+		values = append(values, int(gray.GrayAt(x, y).Y))
+	})
+	if len(values) == 0 {
+		return 255
+	}
+	sort.Ints(values)
+	return values[len(values)/2]
+}
+
+// 从bounds边缘的像素估计背景颜色，各通道分别取中位数，不受边缘附近少量内容的影响
+// 区域为空时按白色背景处理
+func estimateBackground(img image.Image, bounds image.Rectangle) color.NRGBA {
+	var rs, gs, bs, as []int
+	forBorder(bounds, func(x, y int) {
+		c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+		rs, gs, bs, as = append(rs, int(c.R)), append(gs, int(c.G)), append(bs, int(c.B)), append(as, int(c.A))
+	})
+	if len(rs) == 0 {
+		return color.NRGBA{255, 255, 255, 255}
+	}
+	median := func(v []int) uint8 {
+		sort.Ints(v)
+		return uint8(v[len(v)/2])
+	}
+	return color.NRGBA{median(rs), median(gs), median(bs), median(as)}
+}
+
+// 遍历图像四周宽度为短边1/50（至少1像素）的边框
+func forBorder(bounds image.Rectangle, f func(x, y int)) {
+	band := max(min(bounds.Dx(), bounds.Dy())/50, 1)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if x-bounds.Min.X < band || bounds.Max.X-x <= band || y-bounds.Min.Y < band || bounds.Max.Y-y <= band {
+				f(x, y)
+			}
+		}
+	}
+}
+
+// 两个颜色各通道差值的最大值，透明像素按透明度参与比较
+func colorDistance(a, b color.NRGBA) int {
+	d := func(x, y uint8) int {
+		if x > y {
+			return int(x - y)
+		}
+		return int(y - x)
+	}
+	if a.A == 0 && b.A == 0 {
+		return 0
+	}
+	return max(d(a.R, b.R), d(a.G, b.G), d(a.B, b.B), d(a.A, b.A))
+}
@@ -0,0 +1,73 @@
+package detect
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+func TestDetectEmptyImage(t *testing.T) {
+	for _, r := range []image.Rectangle{{}, image.Rect(5, 5, 5, 20), image.Rect(0, 3, 10, 3)} {
+		img := image.NewRGBA(r)
+		for _, method := range Methods() {
+			_, bounds, err := Detect(context.Background(), img, Options{Method: method, Margin: DefaultMargin})
+			if err != nil {
+				t.Errorf("Detect(%v, %s) 错误: %v", r, method, err)
+			}
+			if bounds != img.Bounds() {
+				t.Errorf("Detect(%v, %s) = %v, 期望整个图像", r, method, bounds)
+			}
+		}
+		regions, err := Segments(context.Background(), img, SegmentOptions{Options: Options{Method: MethodBackground}})
+		if err != nil || len(regions) != 0 {
+			t.Errorf("Segments(%v) = %v %v, 期望空结果", r, regions, err)
+		}
+	}
+}
+
+func TestBackgroundMediansEmpty(t *testing.T) {
+	if got := borderGray(image.NewGray(image.Rectangle{})); got != 255 {
+		t.Errorf("borderGray(空图像) = %d, 期望 255", got)
+	}
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	if got := estimateBackground(img, image.Rect(2, 2, 2, 2)); got != (color.NRGBA{255, 255, 255, 255}) {
+		t.Errorf("estimateBackground(空区域) = %v, 期望白色", got)
+	}
+}
+
+func TestDetectMethodsFindSquare(t *testing.T) {
+	// 米色背景上的深色方块
+	img := image.NewRGBA(image.Rect(0, 0, 200, 160))
+	draw.Draw(img, img.Bounds(), &image.Uniform{color.RGBA{230, 220, 190, 255}}, image.Point{}, draw.Src)
+	square := image.Rect(60, 40, 100, 80)
+	draw.Draw(img, square, &image.Uniform{color.RGBA{40, 40, 40, 255}}, image.Point{}, draw.Src)
+
+	for _, method := range []string{MethodOtsu, MethodAdaptive, MethodBackground} {
+		_, bounds, err := Detect(context.Background(), img, Options{Method: method})
+		if err != nil {
+			t.Fatalf("%s: %v", method, err)
+		}
+		if !bounds.Inset(-2).In(square.Inset(-4)) || !square.Inset(2).In(bounds) {
+			t.Errorf("%s: 内容区域 %v, 期望接近 %v", method, bounds, square)
+		}
+	}
+}
+
+func TestDetectBlankImage(t *testing.T) {
+	// 没有内容的纯色图像，所有检测方法都返回整个图像
+	for _, c := range []color.RGBA{{255, 255, 255, 255}, {230, 220, 190, 255}} {
+		img := image.NewRGBA(image.Rect(10, 20, 110, 120))
+		draw.Draw(img, img.Bounds(), &image.Uniform{c}, image.Point{}, draw.Src)
+		for _, method := range Methods() {
+			center, bounds, err := Detect(context.Background(), img, Options{Method: method, Margin: DefaultMargin})
+			if err != nil {
+				t.Fatalf("%s: %v", method, err)
+			}
+			if bounds != img.Bounds() || center != image.Pt(60, 70) {
+				t.Errorf("%s %v: 内容区域 %v 中心 %v, 期望整个图像", method, c, bounds, center)
+			}
+		}
+	}
+}
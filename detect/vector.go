@@ -0,0 +1,43 @@
+package detect
+
+import (
+	"image"
+	"math"
+
+	"pdf-vector-extractor/extract"
+)
+
+// 按矢量路径几何检测的方法名，只适用于有矢量数据的页面，不能用于标记内容像素
+const MethodVector = "vector"
+
+// 按矢量路径的精确几何计算内容中心点和带边距的内容区域（图像像素坐标）
+// 与渲染分辨率无关，曲线、线宽、斜接和裁剪路径都按几何计算；不是矢量页面或没有可见路径时ok为false
+func VectorBounds(page *extract.Page, margin int) (center image.Point, bounds image.Rectangle, ok bool) {
+	if page == nil || page.Vector == nil || page.Image == nil {
+		return image.Point{}, image.Rectangle{}, false
+	}
+	box, ok := page.Vector.ContentBox()
+	if !ok {
+		return image.Point{}, image.Rectangle{}, false
+	}
+
+	// 四个角变换到图像空间后取外接框，页面旋转时同样适用
+	m := page.Vector.DeviceMatrix(page.Scale)
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, p := range []extract.VectorPoint{{X: box.LLX, Y: box.LLY}, {X: box.URX, Y: box.LLY}, {X: box.LLX, Y: box.URY}, {X: box.URX, Y: box.URY}} {
+		d := m.Apply(p)
+		minX, minY = math.Min(minX, d.X), math.Min(minY, d.Y)
+		maxX, maxY = math.Max(maxX, d.X), math.Max(maxY, d.Y)
+	}
+
+	// 向外取整到覆盖内容的像素（细线至少1像素），再加上边距
+	x0, y0 := int(math.Floor(minX)), int(math.Floor(minY))
+	x1, y1 := max(int(math.Ceil(maxX)), x0+1), max(int(math.Ceil(maxY)), y0+1)
+	r := image.Rect(x0-margin, y0-margin, x1+margin, y1+margin).Intersect(page.Image.Bounds())
+	if r.Empty() {
+		return image.Point{}, image.Rectangle{}, false
+	}
+	center = image.Pt(int(math.Round((minX+maxX)/2)), int(math.Round((minY+maxY)/2)))
+	return center, r, true
+}
@@ -0,0 +1,286 @@
+package extract
+
+import (
+	"image/color"
+	"math"
+)
+
+// 不含任何点的外接框，与任何框合并后得到该框
+var emptyBox = PageBox{LLX: math.Inf(1), LLY: math.Inf(1), URX: math.Inf(-1), URY: math.Inf(-1)}
+
+// 是否不含任何点；宽或高为0的框（如水平细线）不算空
+func (b PageBox) none() bool {
+	return b.LLX > b.URX || b.LLY > b.URY
+}
+
+func (b PageBox) intersect(o PageBox) PageBox {
+	return PageBox{math.Max(b.LLX, o.LLX), math.Max(b.LLY, o.LLY), math.Min(b.URX, o.URX), math.Min(b.URY, o.URY)}
+}
+
+func (b PageBox) union(o PageBox) PageBox {
+	return PageBox{math.Min(b.LLX, o.LLX), math.Min(b.LLY, o.LLY), math.Max(b.URX, o.URX), math.Max(b.URY, o.URY)}
+}
+
+func (b PageBox) contains(o PageBox) bool {
+	return b.LLX <= o.LLX && b.LLY <= o.LLY && b.URX >= o.URX && b.URY >= o.URY
+}
+
+func (b PageBox) extend(p VectorPoint) PageBox {
+	return PageBox{math.Min(b.LLX, p.X), math.Min(b.LLY, p.Y), math.Max(b.URX, p.X), math.Max(b.URY, p.Y)}
+}
+
+// 页面上所有可见路径和位图的精确几何外接框（页面空间，单位：点），没有可见内容时ok为false
+// 曲线按贝塞尔极值点计算，描边计入线宽、斜接和方形线帽，并与裁剪路径取交集
+// 白色不透明或完全透明的路径在白底上不可见，不计入；覆盖整个页面的填充视为背景，不计入
+func (vp *VectorPage) ContentBox() (box PageBox, ok bool) {
+	box = emptyBox
+	for i := range vp.Paths {
+		p := &vp.Paths[i]
+		pb := emptyBox
+		if p.Fill && visiblePaint(p.FillColor, p.FillAlpha) {
+			fill := geometryBox(p.Segments, p.CTM)
+			if !p.Stroke && fill.contains(vp.MediaBox) {
+				continue
+			}
+			pb = pb.union(fill)
+		}
+		if p.Stroke && visiblePaint(p.StrokeColor, p.StrokeAlpha) {
+			pb = pb.union(strokeBox(p))
+		}
+		for _, clip := range p.Clips {
+			pb = pb.intersect(geometryBox(clip.Segments, clip.CTM))
+		}
+		if pb = pb.intersect(vp.MediaBox); !pb.none() {
+			box = box.union(pb)
+		}
+	}
+	// 位图按其外接框计入，同样不计覆盖整个页面的背景图
+	for i := range vp.Images {
+		im := &vp.Images[i]
+		if im.Alpha <= 0 {
+			continue
+		}
+		ib := im.box()
+		if ib.contains(vp.MediaBox) {
+			continue
+		}
+		if ib = ib.intersect(vp.MediaBox); !ib.none() {
+			box = box.union(ib)
+		}
+	}
+	return box, !box.none()
+}
+
+// 在白色背景上是否可见
+func visiblePaint(c color.RGBA, alpha float64) bool {
+	return alpha > 0 && !(c.R == 255 && c.G == 255 && c.B == 255 && alpha >= 1)
+}
+
+// 路径变换到页面空间后的几何外接框，三次曲线按导数为0处的极值点计算
+func geometryBox(segments []PathSegment, ctm Matrix) PageBox {
+	box := emptyBox
+	var start, last VectorPoint
+	for _, seg := range segments {
+		switch seg.Op {
+		case SegmentMoveTo:
+			start = ctm.Apply(seg.Points[0])
+			last = start
+			box = box.extend(last)
+		case SegmentLineTo:
+			last = ctm.Apply(seg.Points[0])
+			box = box.extend(last)
+		case SegmentCubicTo:
+			p1, p2, p3 := ctm.Apply(seg.Points[0]), ctm.Apply(seg.Points[1]), ctm.Apply(seg.Points[2])
+			box = box.extend(p3)
+			for _, t := range cubicExtrema(last.X, p1.X, p2.X, p3.X) {
+				box = box.extend(cubicPoint(last, p1, p2, p3, t))
+			}
+			for _, t := range cubicExtrema(last.Y, p1.Y, p2.Y, p3.Y) {
+				box = box.extend(cubicPoint(last, p1, p2, p3, t))
+			}
+			last = p3
+		case SegmentClose:
+			last = start
+		}
+	}
+	return box
+}
+
+// 三次贝塞尔曲线某一坐标分量在(0,1)内的极值参数
+func cubicExtrema(p0, p1, p2, p3 float64) []float64 {
+	// 导数 3(a t² + b t + c)
+	a := -p0 + 3*p1 - 3*p2 + p3
+	b := 2 * (p0 - 2*p1 + p2)
+	c := p1 - p0
+	var ts []float64
+	add := func(t float64) {
+		if t > 0 && t < 1 {
+			ts = append(ts, t)
+		}
+	}
+	if math.Abs(a) < 1e-12 {
+		if math.Abs(b) > 1e-12 {
+			add(-c / b)
+		}
+		return ts
+	}
+	d := b*b - 4*a*c
+	if d < 0 {
+		return ts
+	}
+	sq := math.Sqrt(d)
+	add((-b + sq) / (2 * a))
+	add((-b - sq) / (2 * a))
+	return ts
+}
+
+func cubicPoint(p0, p1, p2, p3 VectorPoint, t float64) VectorPoint {
+	mt := 1 - t
+	a, b, c, d := mt*mt*mt, 3*mt*mt*t, 3*mt*t*t, t*t*t
+	return VectorPoint{
+		X: a*p0.X + b*p1.X + c*p2.X + d*p3.X,
+		Y: a*p0.Y + b*p1.Y + c*p2.Y + d*p3.Y,
+	}
+}
+
+// 描边的外接框：几何外接框外扩半个线宽，再加上斜接的尖角和方形线帽的角
+// 线宽与渲染时一致，按CTM的平均缩放系数换算；虚线按实线计算
+func strokeBox(p *VectorPath) PageBox {
+	hw := p.LineWidth * p.CTM.Scale() / 2
+	geom := geometryBox(p.Segments, p.CTM)
+	if geom.none() {
+		return emptyBox
+	}
+	box := PageBox{geom.LLX - hw, geom.LLY - hw, geom.URX + hw, geom.URY + hw}
+	if hw == 0 {
+		return box
+	}
+	for _, sub := range strokeOutlines(p.Segments, p.CTM) {
+		box = sub.extendStroke(box, hw, p.LineJoin, p.LineCap, p.MiterLimit)
+	}
+	return box
+}
+
+// 子路径的顶点及其两侧的切线方向，用于计算斜接和线帽
+type strokeOutline struct {
+	points   []VectorPoint
+	tangents [][2]VectorPoint // 每个顶点的进入和离开方向，曲线取控制点方向
+	closed   bool
+}
+
+// 按子路径拆分，记录每个顶点处的进入和离开方向
+func strokeOutlines(segments []PathSegment, ctm Matrix) []strokeOutline {
+	var result []strokeOutline
+	var cur strokeOutline
+	flush := func() {
+		if len(cur.points) > 1 {
+			result = append(result, cur)
+		}
+		cur = strokeOutline{}
+	}
+	// 追加一段：起点处的离开方向和终点处的进入方向
+	add := func(out, end, in VectorPoint) {
+		n := len(cur.points)
+		cur.tangents[n-1][1] = out
+		cur.points = append(cur.points, end)
+		cur.tangents = append(cur.tangents, [2]VectorPoint{in, {}})
+	}
+	var start VectorPoint
+	for _, seg := range segments {
+		switch seg.Op {
+		case SegmentMoveTo:
+			flush()
+			start = ctm.Apply(seg.Points[0])
+			cur.points = []VectorPoint{start}
+			cur.tangents = make([][2]VectorPoint, 1)
+		case SegmentLineTo:
+			if len(cur.points) == 0 {
+				continue
+			}
+			p := ctm.Apply(seg.Points[0])
+			d := sub(p, cur.points[len(cur.points)-1])
+			add(d, p, d)
+		case SegmentCubicTo:
+			if len(cur.points) == 0 {
+				continue
+			}
+			p0 := cur.points[len(cur.points)-1]
+			p1, p2, p3 := ctm.Apply(seg.Points[0]), ctm.Apply(seg.Points[1]), ctm.Apply(seg.Points[2])
+			add(firstNonZero(sub(p1, p0), sub(p2, p0), sub(p3, p0)), p3, firstNonZero(sub(p3, p2), sub(p3, p1), sub(p3, p0)))
+		case SegmentClose:
+			if len(cur.points) > 1 {
+				last := cur.points[len(cur.points)-1]
+				if d := sub(start, last); d != (VectorPoint{}) {
+					add(d, start, d)
+				}
+				// 闭合点与起点重合，起点的进入方向为最后一段的方向
+				n := len(cur.points)
+				cur.tangents[0][0] = cur.tangents[n-1][0]
+				cur.points, cur.tangents = cur.points[:n-1], cur.tangents[:n-1]
+				cur.closed = true
+			}
+			flush()
+		}
+	}
+	flush()
+	return result
+}
+
+// 把斜接的尖角和方形线帽的角计入外接框
+func (o strokeOutline) extendStroke(box PageBox, hw float64, join, lineCap int, miterLimit float64) PageBox {
+	n := len(o.points)
+	for i, v := range o.points {
+		in, out := unit(o.tangents[i][0]), unit(o.tangents[i][1])
+		open := !o.closed && (i == 0 || i == n-1)
+		if open {
+			// 方形线帽：沿切线方向延伸半个线宽
+			if lineCap != 2 {
+				continue
+			}
+			d := out
+			if i == n-1 {
+				d = VectorPoint{-in.X, -in.Y}
+			}
+			e := VectorPoint{v.X - d.X*hw, v.Y - d.Y*hw}
+			box = box.extend(VectorPoint{e.X - d.Y*hw, e.Y + d.X*hw})
+			box = box.extend(VectorPoint{e.X + d.Y*hw, e.Y - d.X*hw})
+			continue
+		}
+		if join != 0 {
+			continue
+		}
+		// 斜接：尖角长度与线宽之比为 1/sin(θ/2)，θ为两段之间的夹角，超过斜接限制时按斜角处理
+		cosHalf := math.Sqrt(math.Max((1+in.X*out.X+in.Y*out.Y)/2, 0))
+		if cosHalf < 1e-9 || 1/cosHalf > miterLimit {
+			continue
+		}
+		bisector := unit(VectorPoint{in.X - out.X, in.Y - out.Y})
+		if bisector == (VectorPoint{}) {
+			continue
+		}
+		length := hw / cosHalf
+		box = box.extend(VectorPoint{v.X + bisector.X*length, v.Y + bisector.Y*length})
+	}
+	return box
+}
+
+func sub(a, b VectorPoint) VectorPoint {
+	return VectorPoint{a.X - b.X, a.Y - b.Y}
+}
+
+func unit(p VectorPoint) VectorPoint {
+	l := math.Hypot(p.X, p.Y)
+	if l == 0 {
+		return VectorPoint{}
+	}
+	return VectorPoint{p.X / l, p.Y / l}
+}
+
+func firstNonZero(ps ...VectorPoint) VectorPoint {
+	for _, p := range ps {
+		if p != (VectorPoint{}) {
+			return p
+		}
+	}
+	return VectorPoint{}
+}
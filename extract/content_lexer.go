@@ -0,0 +1,220 @@
+package extract
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+)
+
+// 内容流词法单元类型
+type contentTokenKind int
+
+const (
+	tokenNumber contentTokenKind = iota
+	tokenName
+	tokenString
+	tokenArray
+	tokenDict
+	tokenKeyword
+)
+
+// 内容流中的一个操作数或操作符
+type contentToken struct {
+	kind  contentTokenKind
+	num   float64
+	str   string
+	array []contentToken
+}
+
+// PDF内容流词法分析器
+type contentLexer struct {
+	data []byte
+	pos  int
+}
+
+func newContentLexer(data []byte) *contentLexer {
+	return &contentLexer{data: data}
+}
+
+func isPDFWhitespace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '\f' || c == 0
+}
+
+func isPDFDelimiter(c byte) bool {
+	switch c {
+	case '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return false
+}
+
+// 跳过空白和注释
+func (l *contentLexer) skipWhitespace() {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		if isPDFWhitespace(c) {
+			l.pos++
+			continue
+		}
+		if c == '%' {
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+			continue
+		}
+		return
+	}
+}
+
+// 读取下一个词法单元，到达末尾时ok为false
+func (l *contentLexer) next() (tok contentToken, ok bool, err error) {
+	l.skipWhitespace()
+	if l.pos >= len(l.data) {
+		return contentToken{}, false, nil
+	}
+
+	c := l.data[l.pos]
+	switch {
+	case c == '/':
+		l.pos++
+		start := l.pos
+		for l.pos < len(l.data) && !isPDFWhitespace(l.data[l.pos]) && !isPDFDelimiter(l.data[l.pos]) {
+			l.pos++
+		}
+		return contentToken{kind: tokenName, str: string(l.data[start:l.pos])}, true, nil
+
+	case c == '(':
+		s, err := l.readLiteralString()
+		return contentToken{kind: tokenString, str: s}, err == nil, err
+
+	case c == '<' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '<':
+		l.pos += 2
+		items, err := l.readUntil(">>")
+		return contentToken{kind: tokenDict, array: items}, err == nil, err
+
+	case c == '<':
+		l.pos++
+		end := bytes.IndexByte(l.data[l.pos:], '>')
+		if end < 0 {
+			return contentToken{}, false, fmt.Errorf("十六进制字符串未结束")
+		}
+		s := string(l.data[l.pos : l.pos+end])
+		l.pos += end + 1
+		return contentToken{kind: tokenString, str: s}, true, nil
+
+	case c == '[':
+		l.pos++
+		items, err := l.readUntil("]")
+		return contentToken{kind: tokenArray, array: items}, err == nil, err
+
+	case c == ']' || c == '>' || c == ')' || c == '{' || c == '}':
+		// 不成对的分隔符，按关键字返回由调用者处理
+		l.pos++
+		return contentToken{kind: tokenKeyword, str: string(c)}, true, nil
+
+	case c == '+' || c == '-' || c == '.' || (c >= '0' && c <= '9'):
+		start := l.pos
+		l.pos++
+		for l.pos < len(l.data) {
+			d := l.data[l.pos]
+			if (d >= '0' && d <= '9') || d == '.' || d == '-' || d == '+' {
+				l.pos++
+				continue
+			}
+			break
+		}
+		v, err := strconv.ParseFloat(string(l.data[start:l.pos]), 64)
+		if err != nil {
+			// 容错：畸形数字按0处理
+			v = 0
+		}
+		return contentToken{kind: tokenNumber, num: v}, true, nil
+	}
+
+	start := l.pos
+	for l.pos < len(l.data) && !isPDFWhitespace(l.data[l.pos]) && !isPDFDelimiter(l.data[l.pos]) {
+		l.pos++
+	}
+	return contentToken{kind: tokenKeyword, str: string(l.data[start:l.pos])}, true, nil
+}
+
+// 读取数组或字典内部的所有元素，直到遇到结束标记
+func (l *contentLexer) readUntil(end string) ([]contentToken, error) {
+	var items []contentToken
+	for {
+		l.skipWhitespace()
+		if l.pos >= len(l.data) {
+			return items, fmt.Errorf("缺少结束标记 %s", end)
+		}
+		if bytes.HasPrefix(l.data[l.pos:], []byte(end)) {
+			l.pos += len(end)
+			return items, nil
+		}
+		tok, ok, err := l.next()
+		if err != nil {
+			return items, err
+		}
+		if !ok {
+			return items, fmt.Errorf("缺少结束标记 %s", end)
+		}
+		items = append(items, tok)
+	}
+}
+
+// 读取括号字符串，支持嵌套括号和转义
+func (l *contentLexer) readLiteralString() (string, error) {
+	l.pos++ // 跳过 '('
+	var buf bytes.Buffer
+	depth := 1
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '\\':
+			if l.pos < len(l.data) {
+				buf.WriteByte(l.data[l.pos])
+				l.pos++
+			}
+		case '(':
+			depth++
+			buf.WriteByte(c)
+		case ')':
+			depth--
+			if depth == 0 {
+				return buf.String(), nil
+			}
+			buf.WriteByte(c)
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	return buf.String(), fmt.Errorf("字符串未结束")
+}
+
+// 跳过内联图像数据（BI ... ID <数据> EI）
+func (l *contentLexer) skipInlineImage() {
+	// 先跳过图像字典直到ID
+	for {
+		tok, ok, err := l.next()
+		if err != nil || !ok {
+			return
+		}
+		if tok.kind == tokenKeyword && tok.str == "ID" {
+			break
+		}
+	}
+	if l.pos < len(l.data) {
+		l.pos++ // ID后的单个空白
+	}
+	// 查找被空白包围的EI
+	for l.pos+2 <= len(l.data) {
+		if l.data[l.pos] == 'E' && l.data[l.pos+1] == 'I' &&
+			(l.pos == 0 || isPDFWhitespace(l.data[l.pos-1])) &&
+			(l.pos+2 == len(l.data) || isPDFWhitespace(l.data[l.pos+2]) || isPDFDelimiter(l.data[l.pos+2])) {
+			l.pos += 2
+			return
+		}
+		l.pos++
+	}
+	l.pos = len(l.data)
+}
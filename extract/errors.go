@@ -0,0 +1,83 @@
+package extract
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/filter"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+)
+
+// 提取失败的错误类别，可用 errors.Is 判断
+var (
+	ErrEncrypted         = errors.New("PDF已加密")
+	ErrNoContent         = errors.New("页面没有内容")
+	ErrUnsupportedFilter = errors.New("不支持的压缩过滤器")
+	ErrCorruptXRef       = errors.New("交叉引用表损坏")
+	ErrEmptyPage         = errors.New("页面为空")
+)
+
+// 带文件和页码信息的提取错误
+type Error struct {
+	File string
+	Page int
+	Kind error // 上面定义的错误类别之一，无法归类时为nil
+	Err  error // 原始错误
+}
+
+func (e *Error) Error() string {
+	name := filepath.Base(e.File)
+	switch {
+	case e.Kind != nil && e.Err != nil:
+		return fmt.Sprintf("%s 第%d页: %v: %v", name, e.Page, e.Kind, e.Err)
+	case e.Kind != nil:
+		return fmt.Sprintf("%s 第%d页: %v", name, e.Page, e.Kind)
+	}
+	return fmt.Sprintf("%s 第%d页: %v", name, e.Page, e.Err)
+}
+
+func (e *Error) Unwrap() []error {
+	var errs []error
+	if e.Kind != nil {
+		errs = append(errs, e.Kind)
+	}
+	if e.Err != nil {
+		errs = append(errs, e.Err)
+	}
+	return errs
+}
+
+// 将pdfcpu返回的错误归类
+func classifyPDFError(err error) error {
+	if err == nil {
+		return nil
+	}
+	msg := strings.ToLower(err.Error())
+
+	switch {
+	case errors.Is(err, pdfcpu.ErrWrongPassword),
+		strings.Contains(msg, "password"), strings.Contains(msg, "encrypt"):
+		return ErrEncrypted
+	case errors.Is(err, model.ErrNoContent):
+		return ErrNoContent
+	case errors.Is(err, filter.ErrUnsupportedFilter), strings.Contains(msg, "unsupported filter"),
+		strings.Contains(msg, "filter not supported"):
+		return ErrUnsupportedFilter
+	case strings.Contains(msg, "xref"), strings.Contains(msg, "startxref"),
+		errors.Is(err, pdfcpu.ErrCorruptHeader), strings.Contains(msg, "trailer"):
+		return ErrCorruptXRef
+	}
+	return nil
+}
+
+// 创建提取错误，自动归类
+func newError(file string, page int, err error) *Error {
+	var ee *Error
+	if errors.As(err, &ee) {
+		return ee
+	}
+	return &Error{File: file, Page: page, Kind: classifyPDFError(err), Err: err}
+}
@@ -0,0 +1,51 @@
+package extract
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExtractErrorKinds(t *testing.T) {
+	dir := t.TempDir()
+	corrupt := filepath.Join(dir, "corrupt.pdf")
+	if err := os.WriteFile(corrupt, []byte("%PDF-1.4\n"+strings.Repeat("x", 600)+"\n%%EOF\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name, path string
+		kind       error
+		detail     string // 错误信息中应包含的说明
+	}{
+		{"交叉引用表损坏", corrupt, ErrCorruptXRef, ""},
+		{"空页面", writeTestPDF(t, 100, 100, "q Q", "<< >>"), ErrEmptyPage, "没有找到路径或图像"},
+		{"只有文本", writeTestPDF(t, 100, 100, "BT /F1 12 Tf (a) Tj (b) Tj ET", "<< >>"), ErrEmptyPage, "2 处文本"},
+	}
+	for _, tt := range tests {
+		_, err := NewProcessor(72).ExtractPage(context.Background(), tt.path, 1)
+		if !errors.Is(err, tt.kind) {
+			t.Errorf("%s: 错误 %v, 期望类别 %v", tt.name, err, tt.kind)
+			continue
+		}
+		var ee *Error
+		if !errors.As(err, &ee) || ee.File != tt.path || ee.Page != 1 {
+			t.Errorf("%s: 错误缺少文件和页码: %#v", tt.name, err)
+		}
+		if !strings.Contains(err.Error(), tt.detail) || !strings.HasPrefix(err.Error(), filepath.Base(tt.path)) {
+			t.Errorf("%s: 错误信息 %q", tt.name, err.Error())
+		}
+	}
+}
+
+func TestNewErrorKeepsExisting(t *testing.T) {
+	inner := &Error{File: "a.pdf", Page: 2, Kind: ErrEncrypted}
+	if got := newError("b.pdf", 3, inner); got != inner {
+		t.Errorf("newError 期望返回原有的 *Error, 得到 %v", got)
+	}
+	if got := newError("b.pdf", 3, errors.New("something else")); got.Kind != nil || got.Page != 3 {
+		t.Errorf("无法归类的错误: %#v", got)
+	}
+}
@@ -0,0 +1,139 @@
+package extract
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// 页面中绘制的位图：CTM把单位正方形映射到页面空间，图像第一行在单位正方形的上边
+type PageImage struct {
+	Image image.Image
+	CTM   Matrix
+	Alpha float64    // 填充透明度
+	Clips []ClipPath // 绘制时生效的裁剪路径
+	Order int        // 在Paths[Order]之前绘制，等于路径数时在所有路径之后
+}
+
+// 没有绘制的图像数：无法解码的图像、图像蒙版和内联图像
+func (vp *VectorPage) SkippedImages() int {
+	return max(vp.ImageCount-len(vp.Images), 0)
+}
+
+// 记录图像XObject，无法解码时只计数
+func (pi *pathInterpreter) drawImage(ref types.Object, sd *types.StreamDict) {
+	img := pi.decodeImage(ref, sd)
+	if img == nil || img.Bounds().Empty() {
+		return
+	}
+	pi.page.Images = append(pi.page.Images, PageImage{
+		Image: img,
+		CTM:   pi.state.ctm,
+		Alpha: pi.state.fillAlpha,
+		Clips: pi.state.clips,
+		Order: len(pi.page.Paths),
+	})
+}
+
+// 解码图像XObject，同一对象只解码一次；图像蒙版的颜色来自填充色，不在此处理
+func (pi *pathInterpreter) decodeImage(ref types.Object, sd *types.StreamDict) image.Image {
+	objNr := 0
+	if ir, ok := ref.(types.IndirectRef); ok {
+		objNr = ir.ObjectNumber.Value()
+		if img, ok := pi.images[objNr]; ok {
+			return img
+		}
+	}
+
+	var img image.Image
+	if mask := sd.BooleanEntry("ImageMask"); mask == nil || !*mask {
+		if pdfImg, err := pdfcpu.ExtractImage(pi.pdf, sd, false, "", objNr, false); err == nil && pdfImg != nil {
+			img, _, _ = image.Decode(pdfImg)
+		}
+	}
+
+	if objNr > 0 {
+		if pi.images == nil {
+			pi.images = make(map[int]image.Image)
+		}
+		pi.images[objNr] = img
+	}
+	return img
+}
+
+// 逆矩阵，不可逆时ok为false
+func (m Matrix) invert() (Matrix, bool) {
+	det := m[0]*m[3] - m[1]*m[2]
+	if det == 0 {
+		return Matrix{}, false
+	}
+	return Matrix{
+		m[3] / det, -m[1] / det,
+		-m[2] / det, m[0] / det,
+		(m[2]*m[5] - m[3]*m[4]) / det, (m[1]*m[4] - m[0]*m[5]) / det,
+	}, true
+}
+
+// 单位正方形的四个角
+var unitSquare = [4]VectorPoint{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 1, Y: 1}, {X: 0, Y: 1}}
+
+// 把位图按CTM绘制到目标图像上：边缘按单位正方形的覆盖率抗锯齿，像素取最近邻采样
+func compositeImage(dst *image.RGBA, pimg *PageImage, base Matrix, clip *coverageMask) {
+	m := pimg.CTM.Multiply(base)
+	inv, ok := m.invert()
+	if !ok {
+		return
+	}
+	square := polyline{closed: true}
+	for _, p := range unitSquare {
+		square.points = append(square.points, m.Apply(p))
+	}
+	mask := rasterizePolygons([]polyline{square}, false, dst.Bounds())
+	if mask.rect.Empty() {
+		return
+	}
+
+	src := pimg.Image
+	sb := src.Bounds()
+	sw, sh := sb.Dx(), sb.Dy()
+	w := mask.rect.Dx()
+	for y := mask.rect.Min.Y; y < mask.rect.Max.Y; y++ {
+		for x := mask.rect.Min.X; x < mask.rect.Max.X; x++ {
+			cov := min(mask.a[(y-mask.rect.Min.Y)*w+(x-mask.rect.Min.X)], 1)
+			if clip != nil {
+				cov *= clip.at(x, y)
+			}
+			if cov <= 0 {
+				continue
+			}
+			// 像素中心变换回单位正方形，再换算为源图像像素
+			u := inv.Apply(VectorPoint{X: float64(x) + 0.5, Y: float64(y) + 0.5})
+			sx := sb.Min.X + min(max(int(math.Floor(u.X*float64(sw))), 0), sw-1)
+			sy := sb.Min.Y + min(max(int(math.Floor((1-u.Y)*float64(sh))), 0), sh-1)
+			c := color.NRGBAModel.Convert(src.At(sx, sy)).(color.NRGBA)
+
+			a := float64(cov) * pimg.Alpha * float64(c.A) / 255
+			i := dst.PixOffset(x, y)
+			px := dst.Pix[i : i+4 : i+4]
+			px[0] = uint8(float64(c.R)*a + float64(px[0])*(1-a) + 0.5)
+			px[1] = uint8(float64(c.G)*a + float64(px[1])*(1-a) + 0.5)
+			px[2] = uint8(float64(c.B)*a + float64(px[2])*(1-a) + 0.5)
+			px[3] = uint8(255*a + float64(px[3])*(1-a) + 0.5)
+		}
+	}
+}
+
+// 位图在页面空间中的外接框，与裁剪路径取交集
+func (pimg *PageImage) box() PageBox {
+	b := emptyBox
+	for _, p := range unitSquare {
+		b = b.extend(pimg.CTM.Apply(p))
+	}
+	for _, clip := range pimg.Clips {
+		b = b.intersect(geometryBox(clip.Segments, clip.CTM))
+	}
+	return b
+}
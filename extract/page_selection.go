@@ -0,0 +1,79 @@
+package extract
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 解析页面选择表达式，返回升序且去重的页码列表
+// 支持: 空（第1页）、all/全部、odd/奇数、even/偶数、单页 7、范围 1-3、开放范围 5- 或 -3，逗号分隔可组合
+func ParsePageSelection(spec string, pageCount int) ([]int, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		spec = "1"
+	}
+
+	selected := make(map[int]bool)
+	addRange := func(from, to int) {
+		for p := max(from, 1); p <= min(to, pageCount); p++ {
+			selected[p] = true
+		}
+	}
+
+	for _, part := range strings.FieldsFunc(spec, func(r rune) bool { return r == ',' || r == '，' || r == ' ' }) {
+		switch strings.ToLower(part) {
+		case "all", "全部":
+			addRange(1, pageCount)
+			continue
+		case "odd", "奇数":
+			for p := 1; p <= pageCount; p += 2 {
+				selected[p] = true
+			}
+			continue
+		case "even", "偶数":
+			for p := 2; p <= pageCount; p += 2 {
+				selected[p] = true
+			}
+			continue
+		}
+
+		if from, to, ok := strings.Cut(part, "-"); ok {
+			start, end := 1, pageCount
+			var err error
+			if from != "" {
+				if start, err = strconv.Atoi(from); err != nil {
+					return nil, fmt.Errorf("无效的页码范围: %s", part)
+				}
+			}
+			if to != "" {
+				if end, err = strconv.Atoi(to); err != nil {
+					return nil, fmt.Errorf("无效的页码范围: %s", part)
+				}
+			}
+			if start > end {
+				return nil, fmt.Errorf("页码范围起点大于终点: %s", part)
+			}
+			addRange(start, end)
+			continue
+		}
+
+		p, err := strconv.Atoi(part)
+		if err != nil || p < 1 {
+			return nil, fmt.Errorf("无效的页码: %s", part)
+		}
+		addRange(p, p)
+	}
+
+	pages := make([]int, 0, len(selected))
+	for p := range selected {
+		pages = append(pages, p)
+	}
+	sort.Ints(pages)
+
+	if len(pages) == 0 {
+		return nil, fmt.Errorf("页面选择 \"%s\" 在共 %d 页的文件中没有匹配的页面", spec, pageCount)
+	}
+	return pages, nil
+}
@@ -0,0 +1,61 @@
+package extract
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePageSelection(t *testing.T) {
+	tests := []struct {
+		spec  string
+		count int
+		want  []int
+	}{
+		{"", 5, []int{1}},
+		{"  ", 5, []int{1}},
+		{"3", 5, []int{3}},
+		{"all", 3, []int{1, 2, 3}},
+		{"ALL", 3, []int{1, 2, 3}},
+		{"全部", 2, []int{1, 2}},
+		{"odd", 5, []int{1, 3, 5}},
+		{"奇数", 4, []int{1, 3}},
+		{"even", 5, []int{2, 4}},
+		{"偶数", 1, nil},
+		{"1-3", 5, []int{1, 2, 3}},
+		{"4-", 6, []int{4, 5, 6}},
+		{"-2", 6, []int{1, 2}},
+		{"-", 3, []int{1, 2, 3}},
+		{"1-3,7", 10, []int{1, 2, 3, 7}},
+		{"1-3，7", 10, []int{1, 2, 3, 7}},
+		{"7 1", 10, []int{1, 7}},
+		{"2,2,1-2", 5, []int{1, 2}},
+		{"odd,2", 5, []int{1, 2, 3, 5}},
+		// 超出页数的部分忽略
+		{"3-10", 4, []int{3, 4}},
+		{"1,9", 3, []int{1}},
+	}
+	for _, tt := range tests {
+		got, err := ParsePageSelection(tt.spec, tt.count)
+		if tt.want == nil {
+			if err == nil {
+				t.Errorf("ParsePageSelection(%q, %d) = %v, 期望错误", tt.spec, tt.count, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParsePageSelection(%q, %d) 错误: %v", tt.spec, tt.count, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParsePageSelection(%q, %d) = %v, 期望 %v", tt.spec, tt.count, got, tt.want)
+		}
+	}
+}
+
+func TestParsePageSelectionErrors(t *testing.T) {
+	for _, spec := range []string{"0", "-1-3", "a", "1-b", "x-3", "5-2", "9", "1.5"} {
+		if got, err := ParsePageSelection(spec, 5); err == nil {
+			t.Errorf("ParsePageSelection(%q, 5) = %v, 期望错误", spec, got)
+		}
+	}
+}
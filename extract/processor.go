@@ -0,0 +1,137 @@
+// Package extract 解析PDF页面，提取矢量路径或嵌入位图，并渲染为图像
+package extract
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+)
+
+// PDF页面提取器
+type Processor struct {
+	renderDPI int // 矢量页面渲染分辨率
+
+	// 最近读取的PDF上下文，避免多页文件重复解析
+	ctx     *model.Context
+	ctxPath string
+}
+
+func NewProcessor(renderDPI int) *Processor {
+	if renderDPI <= 0 {
+		renderDPI = DefaultRenderDPI
+	}
+	return &Processor{renderDPI: renderDPI}
+}
+
+// 提取出的页面：渲染或嵌入的图像，以及可用时的矢量内容
+type Page struct {
+	Image  image.Image
+	Vector *VectorPage // 仅矢量页面非nil
+	Scale  float64     // 页面点到图像像素的缩放系数
+}
+
+// 提取PDF指定页面为图像（页码从1开始）
+func (proc *Processor) ExtractPDFAsImage(pdfPath string, pageNr int) (image.Image, error) {
+	page, err := proc.ExtractPage(context.Background(), pdfPath, pageNr)
+	if err != nil {
+		return nil, err
+	}
+	return page.Image, nil
+}
+
+// 提取PDF指定页面，矢量页面同时返回路径数据；ctx取消时返回ctx的错误
+func (proc *Processor) ExtractPage(ctx context.Context, pdfPath string, pageNr int) (*Page, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	pdfCtx, err := proc.context(pdfPath)
+	if err != nil {
+		return nil, newError(pdfPath, pageNr, err)
+	}
+
+	// 优先渲染页面矢量内容
+	page, err := proc.extractPageVectors(pdfCtx, pageNr)
+	if err != nil {
+		return nil, newError(pdfPath, pageNr, err)
+	}
+	if len(page.Paths) > 0 {
+		img, err := proc.RenderPage(ctx, page)
+		if err != nil {
+			return nil, err
+		}
+		return &Page{
+			Image:  img,
+			Vector: page,
+			Scale:  NewPageRasterizer(proc.renderDPI).DPI / 72,
+		}, nil
+	}
+
+	if page.ImageCount == 0 {
+		detail := fmt.Errorf("没有找到路径或图像")
+		if page.TextCount > 0 {
+			detail = fmt.Errorf("页面只包含 %d 处文本，未转为轮廓", page.TextCount)
+		}
+		return nil, &Error{File: pdfPath, Page: pageNr, Kind: ErrEmptyPage, Err: detail}
+	}
+
+	// 没有矢量内容时提取嵌入的位图
+	img, err := proc.extractEmbeddedImage(pdfPath, pageNr)
+	if err != nil {
+		return nil, err
+	}
+	return &Page{Image: img, Scale: 1}, nil
+}
+
+// 提取页面中嵌入的第一张可解码位图
+func (proc *Processor) extractEmbeddedImage(pdfPath string, pageNr int) (image.Image, error) {
+	// 创建临时目录存储提取的图像
+	tempDir, err := os.MkdirTemp("", "pdf_extract_")
+	if err != nil {
+		return nil, fmt.Errorf("创建临时目录失败: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// 设置配置
+	conf := model.NewDefaultConfiguration()
+	conf.ValidationMode = model.ValidationRelaxed
+
+	// 尝试提取PDF中的图像
+	err = api.ExtractImagesFile(pdfPath, tempDir, []string{strconv.Itoa(pageNr)}, conf)
+	if err != nil {
+		return nil, newError(pdfPath, pageNr, err)
+	}
+
+	// 查找提取的图像文件
+	files, err := filepath.Glob(filepath.Join(tempDir, "*.*"))
+	if err != nil || len(files) == 0 {
+		return nil, &Error{File: pdfPath, Page: pageNr, Kind: ErrUnsupportedFilter,
+			Err: fmt.Errorf("页面中的图像无法导出")}
+	}
+
+	// 读取第一个图像文件
+	for _, file := range files {
+		imgFile, err := os.Open(file)
+		if err != nil {
+			continue
+		}
+
+		// 尝试解码图像
+		img, _, err := image.Decode(imgFile)
+		imgFile.Close()
+		if err != nil {
+			continue
+		}
+
+		return img, nil
+	}
+
+	return nil, &Error{File: pdfPath, Page: pageNr, Kind: ErrUnsupportedFilter,
+		Err: fmt.Errorf("页面中的 %d 个图像均无法解码", len(files))}
+}
@@ -0,0 +1,687 @@
+package extract
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"sort"
+)
+
+const (
+	// 默认渲染分辨率
+	DefaultRenderDPI = 300
+	// 每个像素的垂直子扫描线数量，用于抗锯齿
+	rasterSubsamples = 4
+	// 曲线展平容差（像素）
+	flattenTolerance = 0.2
+	// 圆形连接/线帽的多边形边数
+	roundSegments = 16
+)
+
+// 纯Go实现的页面光栅化器，不依赖CGO
+type PageRasterizer struct {
+	DPI float64
+}
+
+func NewPageRasterizer(dpi int) *PageRasterizer {
+	if dpi <= 0 {
+		dpi = DefaultRenderDPI
+	}
+	return &PageRasterizer{DPI: float64(dpi)}
+}
+
+// 页面空间（点）到图像像素空间的变换，包含页面旋转和Y轴翻转
+func (vp *VectorPage) DeviceMatrix(scale float64) Matrix {
+	b := vp.MediaBox
+	switch ((vp.Rotate % 360) + 360) % 360 {
+	case 90:
+		return Matrix{0, scale, scale, 0, -b.LLY * scale, -b.LLX * scale}
+	case 180:
+		return Matrix{-scale, 0, 0, scale, b.URX * scale, -b.LLY * scale}
+	case 270:
+		return Matrix{0, -scale, -scale, 0, b.URY * scale, b.URX * scale}
+	}
+	return Matrix{scale, 0, 0, -scale, -b.LLX * scale, b.URY * scale}
+}
+
+// 按缩放系数计算页面渲染后的像素尺寸
+func (vp *VectorPage) DeviceSize(scale float64) (int, int) {
+	w := int(math.Ceil(vp.MediaBox.Width() * scale))
+	h := int(math.Ceil(vp.MediaBox.Height() * scale))
+	if r := ((vp.Rotate % 360) + 360) % 360; r == 90 || r == 270 {
+		w, h = h, w
+	}
+	return max(w, 1), max(h, 1)
+}
+
+// 将页面矢量内容渲染为白底RGBA图像
+func (pr *PageRasterizer) Render(page *VectorPage) *image.RGBA {
+	img, _ := pr.RenderContext(context.Background(), page)
+	return img
+}
+
+// 同Render，ctx取消时停止渲染并返回ctx的错误
+func (pr *PageRasterizer) RenderContext(ctx context.Context, page *VectorPage) (*image.RGBA, error) {
+	scale := pr.DPI / 72
+	w, h := page.DeviceSize(scale)
+
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	white := color.RGBA{255, 255, 255, 255}
+	draw.Draw(img, img.Bounds(), &image.Uniform{white}, image.Point{}, draw.Src)
+
+	if err := renderPaths(ctx, img, page.Paths, page.Images, page.DeviceMatrix(scale)); err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
+// 使用配置的分辨率渲染页面
+func (proc *Processor) RenderPage(ctx context.Context, page *VectorPage) (*image.RGBA, error) {
+	return NewPageRasterizer(proc.renderDPI).RenderContext(ctx, page)
+}
+
+// 将路径和位图按绘制顺序绘制到目标图像上，base为用户空间之外的附加变换
+func renderPaths(ctx context.Context, dst *image.RGBA, paths []VectorPath, images []PageImage, base Matrix) error {
+	clipCache := make(map[clipKey]*coverageMask)
+	bounds := dst.Bounds()
+
+	// 绘制在第i条路径之前的位图
+	next := 0
+	drawImages := func(i int) {
+		for ; next < len(images) && images[next].Order <= i; next++ {
+			clip := clipMaskFor(images[next].Clips, base, bounds, clipCache)
+			if clip == nil || !clip.rect.Empty() {
+				compositeImage(dst, &images[next], base, clip)
+			}
+		}
+	}
+
+	for i := range paths {
+		drawImages(i)
+		// 每绘制一批路径检查一次是否已取消
+		if i%64 == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		p := &paths[i]
+		m := p.CTM.Multiply(base)
+
+		clip := clipMaskFor(p.Clips, base, bounds, clipCache)
+		if clip != nil && clip.rect.Empty() {
+			continue
+		}
+
+		if p.Fill {
+			polys := flattenPath(p.Segments, m, true)
+			mask := rasterizePolygons(polys, p.EvenOdd, bounds)
+			compositeMask(dst, mask, clip, p.FillColor, p.FillAlpha)
+		}
+		if p.Stroke {
+			width := p.LineWidth * m.Scale()
+			if width < 1 {
+				// 零宽或极细线条按设备最细线绘制
+				width = 1
+			}
+			lines := flattenPath(p.Segments, m, false)
+			if len(p.Dash) > 0 {
+				lines = applyDash(lines, p.Dash, p.DashPhase, m.Scale())
+			}
+			polys := strokePolylines(lines, width, p.LineCap, p.LineJoin, p.MiterLimit)
+			mask := rasterizePolygons(polys, false, bounds)
+			compositeMask(dst, mask, clip, p.StrokeColor, p.StrokeAlpha)
+		}
+	}
+	drawImages(len(paths))
+	return nil
+}
+
+// 展平后的折线（子路径）
+type polyline struct {
+	points []VectorPoint
+	closed bool
+}
+
+// 将路径段变换并展平为折线；用于填充时所有子路径都视为闭合
+func flattenPath(segments []PathSegment, m Matrix, forFill bool) []polyline {
+	var result []polyline
+	var cur polyline
+	var last VectorPoint
+
+	flush := func() {
+		if len(cur.points) > 1 {
+			if forFill {
+				cur.closed = true
+			}
+			result = append(result, cur)
+		}
+		cur = polyline{}
+	}
+
+	for _, seg := range segments {
+		switch seg.Op {
+		case SegmentMoveTo:
+			flush()
+			last = m.Apply(seg.Points[0])
+			cur.points = append(cur.points, last)
+		case SegmentLineTo:
+			p := m.Apply(seg.Points[0])
+			if len(cur.points) == 0 {
+				cur.points = append(cur.points, last)
+			}
+			cur.points = append(cur.points, p)
+			last = p
+		case SegmentCubicTo:
+			if len(cur.points) == 0 {
+				cur.points = append(cur.points, last)
+			}
+			p1, p2, p3 := m.Apply(seg.Points[0]), m.Apply(seg.Points[1]), m.Apply(seg.Points[2])
+			cur.points = flattenCubic(cur.points, last, p1, p2, p3)
+			last = p3
+		case SegmentClose:
+			if len(cur.points) > 0 {
+				start := cur.points[0]
+				cur.closed = true
+				flush()
+				// 闭合后当前点回到子路径起点
+				last = start
+			}
+		}
+	}
+	flush()
+
+	return result
+}
+
+// 将三次贝塞尔曲线展平并追加到点列
+func flattenCubic(points []VectorPoint, p0, p1, p2, p3 VectorPoint) []VectorPoint {
+	// Wang公式估算所需分段数
+	ddx := math.Max(math.Abs(p0.X-2*p1.X+p2.X), math.Abs(p1.X-2*p2.X+p3.X))
+	ddy := math.Max(math.Abs(p0.Y-2*p1.Y+p2.Y), math.Abs(p1.Y-2*p2.Y+p3.Y))
+	n := int(math.Ceil(math.Sqrt(0.75 * math.Hypot(ddx, ddy) / flattenTolerance)))
+	if n < 1 {
+		n = 1
+	}
+	if n > 500 {
+		n = 500
+	}
+
+	for i := 1; i <= n; i++ {
+		t := float64(i) / float64(n)
+		mt := 1 - t
+		a, b, c, d := mt*mt*mt, 3*mt*mt*t, 3*mt*t*t, t*t*t
+		points = append(points, VectorPoint{
+			X: a*p0.X + b*p1.X + c*p2.X + d*p3.X,
+			Y: a*p0.Y + b*p1.Y + c*p2.Y + d*p3.Y,
+		})
+	}
+	return points
+}
+
+// 覆盖率蒙版，只覆盖rect范围
+type coverageMask struct {
+	rect image.Rectangle
+	a    []float32
+}
+
+func (cm *coverageMask) at(x, y int) float32 {
+	if !(image.Point{x, y}).In(cm.rect) {
+		return 0
+	}
+	return cm.a[(y-cm.rect.Min.Y)*cm.rect.Dx()+(x-cm.rect.Min.X)]
+}
+
+// 多边形边，y0 < y1
+type rasterEdge struct {
+	x0, y0, x1, y1 float64
+	dir            int
+}
+
+// 扫描线交点
+type crossing struct {
+	x   float64
+	dir int
+}
+
+// 以非零或奇偶规则光栅化闭合多边形，结果裁剪到bounds
+func rasterizePolygons(polys []polyline, evenOdd bool, bounds image.Rectangle) *coverageMask {
+	var edges []rasterEdge
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+
+	for _, pl := range polys {
+		n := len(pl.points)
+		for i := 0; i < n; i++ {
+			a := pl.points[i]
+			b := pl.points[(i+1)%n]
+			minX, maxX = math.Min(minX, a.X), math.Max(maxX, a.X)
+			minY, maxY = math.Min(minY, a.Y), math.Max(maxY, a.Y)
+			if a.Y == b.Y {
+				continue
+			}
+			if a.Y < b.Y {
+				edges = append(edges, rasterEdge{a.X, a.Y, b.X, b.Y, 1})
+			} else {
+				edges = append(edges, rasterEdge{b.X, b.Y, a.X, a.Y, -1})
+			}
+		}
+	}
+
+	if len(edges) == 0 {
+		return &coverageMask{}
+	}
+
+	rect := image.Rect(
+		int(math.Floor(minX)), int(math.Floor(minY)),
+		int(math.Ceil(maxX))+1, int(math.Ceil(maxY))+1,
+	).Intersect(bounds)
+	mask := &coverageMask{rect: rect}
+	if rect.Empty() {
+		return mask
+	}
+
+	w := rect.Dx()
+	mask.a = make([]float32, w*rect.Dy())
+
+	sort.Slice(edges, func(i, j int) bool { return edges[i].y0 < edges[j].y0 })
+
+	var active []rasterEdge
+	var crossings []crossing
+	next := 0
+	weight := float32(1.0 / rasterSubsamples)
+
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		row := mask.a[(y-rect.Min.Y)*w : (y-rect.Min.Y+1)*w]
+
+		for sub := 0; sub < rasterSubsamples; sub++ {
+			sy := float64(y) + (float64(sub)+0.5)/rasterSubsamples
+
+			// 更新活动边表
+			for next < len(edges) && edges[next].y0 <= sy {
+				active = append(active, edges[next])
+				next++
+			}
+			kept := active[:0]
+			for _, e := range active {
+				if e.y1 > sy {
+					kept = append(kept, e)
+				}
+			}
+			active = kept
+
+			crossings = crossings[:0]
+			for _, e := range active {
+				if e.y0 > sy {
+					continue
+				}
+				x := e.x0 + (sy-e.y0)*(e.x1-e.x0)/(e.y1-e.y0)
+				crossings = append(crossings, crossing{x, e.dir})
+			}
+			sort.Slice(crossings, func(i, j int) bool { return crossings[i].x < crossings[j].x })
+
+			winding := 0
+			for i := 0; i+1 < len(crossings); i++ {
+				winding += crossings[i].dir
+				inside := winding != 0
+				if evenOdd {
+					inside = winding%2 != 0
+				}
+				if inside {
+					addSpan(row, crossings[i].x-float64(rect.Min.X), crossings[i+1].x-float64(rect.Min.X), weight)
+				}
+			}
+		}
+	}
+
+	return mask
+}
+
+// 将[x0,x1)区间的覆盖率累加到行缓冲，端点按面积比例计算
+func addSpan(row []float32, x0, x1 float64, weight float32) {
+	if x1 <= 0 || x0 >= float64(len(row)) || x1 <= x0 {
+		return
+	}
+	x0 = math.Max(x0, 0)
+	x1 = math.Min(x1, float64(len(row)))
+
+	i0 := int(x0)
+	i1 := int(x1)
+	if i0 == i1 {
+		row[i0] += weight * float32(x1-x0)
+		return
+	}
+	row[i0] += weight * float32(float64(i0+1)-x0)
+	for i := i0 + 1; i < i1 && i < len(row); i++ {
+		row[i] += weight
+	}
+	if i1 < len(row) {
+		row[i1] += weight * float32(x1-float64(i1))
+	}
+}
+
+// 将颜色按蒙版覆盖率合成到目标图像
+func compositeMask(dst *image.RGBA, mask, clip *coverageMask, c color.RGBA, alpha float64) {
+	if mask == nil || mask.rect.Empty() {
+		return
+	}
+	w := mask.rect.Dx()
+	for y := mask.rect.Min.Y; y < mask.rect.Max.Y; y++ {
+		for x := mask.rect.Min.X; x < mask.rect.Max.X; x++ {
+			cov := mask.a[(y-mask.rect.Min.Y)*w+(x-mask.rect.Min.X)]
+			if cov <= 0 {
+				continue
+			}
+			if cov > 1 {
+				cov = 1
+			}
+			if clip != nil {
+				cov *= clip.at(x, y)
+				if cov <= 0 {
+					continue
+				}
+			}
+			a := float64(cov) * alpha
+			i := dst.PixOffset(x, y)
+			px := dst.Pix[i : i+4 : i+4]
+			px[0] = uint8(float64(c.R)*a + float64(px[0])*(1-a) + 0.5)
+			px[1] = uint8(float64(c.G)*a + float64(px[1])*(1-a) + 0.5)
+			px[2] = uint8(float64(c.B)*a + float64(px[2])*(1-a) + 0.5)
+			px[3] = uint8(255*a + float64(px[3])*(1-a) + 0.5)
+		}
+	}
+}
+
+// 裁剪蒙版缓存键：同一裁剪状态下的路径共享同一个裁剪切片
+type clipKey struct {
+	first *ClipPath
+	n     int
+}
+
+// 计算裁剪路径交集的蒙版，无裁剪时返回nil
+func clipMaskFor(clips []ClipPath, base Matrix, bounds image.Rectangle, cache map[clipKey]*coverageMask) *coverageMask {
+	if len(clips) == 0 {
+		return nil
+	}
+	key := clipKey{&clips[0], len(clips)}
+	if mask, ok := cache[key]; ok {
+		return mask
+	}
+
+	var result *coverageMask
+	for _, cp := range clips {
+		polys := flattenPath(cp.Segments, cp.CTM.Multiply(base), true)
+		mask := rasterizePolygons(polys, cp.EvenOdd, bounds)
+		if result == nil {
+			result = mask
+			continue
+		}
+		result = intersectMasks(result, mask)
+		if result.rect.Empty() {
+			break
+		}
+	}
+
+	cache[key] = result
+	return result
+}
+
+// 两个蒙版取交集（覆盖率相乘）
+func intersectMasks(a, b *coverageMask) *coverageMask {
+	rect := a.rect.Intersect(b.rect)
+	result := &coverageMask{rect: rect}
+	if rect.Empty() {
+		return result
+	}
+	result.a = make([]float32, rect.Dx()*rect.Dy())
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			va, vb := a.at(x, y), b.at(x, y)
+			if va > 1 {
+				va = 1
+			}
+			if vb > 1 {
+				vb = 1
+			}
+			result.a[(y-rect.Min.Y)*rect.Dx()+(x-rect.Min.X)] = va * vb
+		}
+	}
+	return result
+}
+
+// 按虚线模式切分折线，dash以用户空间单位给出
+func applyDash(lines []polyline, dash []float64, phase, scale float64) []polyline {
+	total := 0.0
+	pattern := make([]float64, len(dash))
+	for i, d := range dash {
+		pattern[i] = math.Max(d, 0) * scale
+		total += pattern[i]
+	}
+	if total <= 0 {
+		return lines
+	}
+
+	var result []polyline
+	for _, pl := range lines {
+		pts := pl.points
+		if pl.closed && len(pts) > 0 {
+			pts = append(append([]VectorPoint(nil), pts...), pts[0])
+		}
+
+		// 根据相位确定起始位置
+		idx := 0
+		remain := pattern[0]
+		offset := math.Mod(phase*scale, total)
+		for offset > 0 {
+			if offset >= remain {
+				offset -= remain
+				idx = (idx + 1) % len(pattern)
+				remain = pattern[idx]
+			} else {
+				remain -= offset
+				offset = 0
+			}
+		}
+
+		on := idx%2 == 0
+		var cur []VectorPoint
+		if on && len(pts) > 0 {
+			cur = []VectorPoint{pts[0]}
+		}
+		for i := 0; i+1 < len(pts); i++ {
+			a, b := pts[i], pts[i+1]
+			segLen := math.Hypot(b.X-a.X, b.Y-a.Y)
+			pos := 0.0
+			for segLen-pos > remain {
+				pos += remain
+				t := pos / segLen
+				p := VectorPoint{a.X + (b.X-a.X)*t, a.Y + (b.Y-a.Y)*t}
+				if on {
+					cur = append(cur, p)
+					result = append(result, polyline{points: cur})
+					cur = nil
+				} else {
+					cur = []VectorPoint{p}
+				}
+				on = !on
+				idx = (idx + 1) % len(pattern)
+				remain = pattern[idx]
+			}
+			remain -= segLen - pos
+			if on {
+				cur = append(cur, b)
+			}
+		}
+		if on && len(cur) > 1 {
+			result = append(result, polyline{points: cur})
+		}
+	}
+	return result
+}
+
+// 将折线描边转换为多边形集合（统一为正向环绕，用非零规则填充即得并集）
+func strokePolylines(lines []polyline, width float64, lineCap, lineJoin int, miterLimit float64) []polyline {
+	hw := width / 2
+	var polys []polyline
+
+	add := func(pts ...VectorPoint) {
+		polys = append(polys, orientPositive(polyline{points: pts, closed: true}))
+	}
+
+	for _, pl := range lines {
+		pts := dedupPoints(pl.points)
+		if len(pts) == 1 {
+			// 零长度子路径只有圆形/方形线帽可见
+			switch lineCap {
+			case 1:
+				add(circlePolygon(pts[0], hw)...)
+			case 2:
+				p := pts[0]
+				add(VectorPoint{p.X - hw, p.Y - hw}, VectorPoint{p.X + hw, p.Y - hw},
+					VectorPoint{p.X + hw, p.Y + hw}, VectorPoint{p.X - hw, p.Y + hw})
+			}
+			continue
+		}
+		if len(pts) < 2 {
+			continue
+		}
+
+		closed := pl.closed && len(pts) > 2
+		n := len(pts)
+		segCount := n - 1
+		if closed {
+			segCount = n
+		}
+
+		for i := 0; i < segCount; i++ {
+			a, b := pts[i], pts[(i+1)%n]
+			nx, ny := segmentNormal(a, b)
+			ox, oy := nx*hw, ny*hw
+
+			// 方形线帽：在开放路径两端延伸半个线宽
+			if !closed && lineCap == 2 {
+				dx, dy := -ny*hw, nx*hw
+				if i == 0 {
+					a = VectorPoint{a.X - dx, a.Y - dy}
+				}
+				if i == segCount-1 {
+					b = VectorPoint{b.X + dx, b.Y + dy}
+				}
+			}
+			add(VectorPoint{a.X + ox, a.Y + oy}, VectorPoint{b.X + ox, b.Y + oy},
+				VectorPoint{b.X - ox, b.Y - oy}, VectorPoint{a.X - ox, a.Y - oy})
+		}
+
+		// 线段连接
+		for i := 0; i < n; i++ {
+			if !closed && (i == 0 || i == n-1) {
+				continue
+			}
+			prev := pts[(i-1+n)%n]
+			cur := pts[i]
+			next := pts[(i+1)%n]
+			polys = append(polys, joinPolygons(prev, cur, next, hw, lineJoin, miterLimit)...)
+		}
+
+		// 圆形线帽
+		if !closed && lineCap == 1 {
+			add(circlePolygon(pts[0], hw)...)
+			add(circlePolygon(pts[n-1], hw)...)
+		}
+	}
+
+	return polys
+}
+
+// 生成连接处的填充多边形
+func joinPolygons(prev, cur, next VectorPoint, hw float64, lineJoin int, miterLimit float64) []polyline {
+	n1x, n1y := segmentNormal(prev, cur)
+	n2x, n2y := segmentNormal(cur, next)
+
+	if lineJoin == 1 {
+		return []polyline{orientPositive(polyline{points: circlePolygon(cur, hw), closed: true})}
+	}
+
+	// 判断外侧：转向为正时外侧在法线负方向
+	cross := (cur.X-prev.X)*(next.Y-cur.Y) - (cur.Y-prev.Y)*(next.X-cur.X)
+	sign := 1.0
+	if cross > 0 {
+		sign = -1
+	}
+	p1 := VectorPoint{cur.X + sign*n1x*hw, cur.Y + sign*n1y*hw}
+	p2 := VectorPoint{cur.X + sign*n2x*hw, cur.Y + sign*n2y*hw}
+
+	bevel := orientPositive(polyline{points: []VectorPoint{cur, p1, p2}, closed: true})
+	if lineJoin == 2 {
+		return []polyline{bevel}
+	}
+
+	// 斜接：检查斜接比
+	cosTheta := n1x*n2x + n1y*n2y
+	miterRatio := math.Sqrt(2 / math.Max(1+cosTheta, 1e-9))
+	if miterRatio > miterLimit {
+		return []polyline{bevel}
+	}
+	mx, my := n1x+n2x, n1y+n2y
+	ml := math.Hypot(mx, my)
+	if ml < 1e-9 {
+		return []polyline{bevel}
+	}
+	tip := VectorPoint{
+		X: cur.X + sign*mx/ml*hw*miterRatio,
+		Y: cur.Y + sign*my/ml*hw*miterRatio,
+	}
+	return []polyline{orientPositive(polyline{points: []VectorPoint{cur, p1, tip, p2}, closed: true})}
+}
+
+// 线段的单位法线
+func segmentNormal(a, b VectorPoint) (float64, float64) {
+	dx, dy := b.X-a.X, b.Y-a.Y
+	l := math.Hypot(dx, dy)
+	if l == 0 {
+		return 0, 0
+	}
+	return -dy / l, dx / l
+}
+
+// 近似圆的多边形
+func circlePolygon(c VectorPoint, r float64) []VectorPoint {
+	pts := make([]VectorPoint, roundSegments)
+	for i := range pts {
+		a := 2 * math.Pi * float64(i) / roundSegments
+		pts[i] = VectorPoint{c.X + r*math.Cos(a), c.Y + r*math.Sin(a)}
+	}
+	return pts
+}
+
+// 去除相邻重复点
+func dedupPoints(pts []VectorPoint) []VectorPoint {
+	var out []VectorPoint
+	for _, p := range pts {
+		if len(out) > 0 {
+			last := out[len(out)-1]
+			if math.Abs(last.X-p.X) < 1e-9 && math.Abs(last.Y-p.Y) < 1e-9 {
+				continue
+			}
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+// 保证多边形为正向（有向面积为正）
+func orientPositive(pl polyline) polyline {
+	area := 0.0
+	n := len(pl.points)
+	for i := 0; i < n; i++ {
+		a, b := pl.points[i], pl.points[(i+1)%n]
+		area += a.X*b.Y - b.X*a.Y
+	}
+	if area < 0 {
+		for i, j := 0, n-1; i < j; i, j = i+1, j-1 {
+			pl.points[i], pl.points[j] = pl.points[j], pl.points[i]
+		}
+	}
+	return pl
+}
@@ -0,0 +1,97 @@
+package extract
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"testing"
+)
+
+// 渲染测试PDF的第一页（72DPI，一个点对应一个像素）
+func renderTestPDF(t *testing.T, path string) *Page {
+	t.Helper()
+	page, err := NewProcessor(72).ExtractPage(context.Background(), path, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return page
+}
+
+// 图像坐标 (x, y) 的颜色，y向下
+func pixelAt(img image.Image, x, y int) color.RGBA {
+	return color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+}
+
+func TestRenderFillRules(t *testing.T) {
+	// 两个同向的嵌套正方形：非零规则填满，奇偶规则内部留空
+	square := "10 10 m 90 10 l 90 90 l 10 90 l h 30 30 m 70 30 l 70 70 l 30 70 l h"
+	black := color.RGBA{0, 0, 0, 255}
+	white := color.RGBA{255, 255, 255, 255}
+	tests := []struct {
+		op           string
+		inner, outer color.RGBA
+	}{
+		{"f", black, black},
+		{"f*", white, black},
+	}
+	for _, tt := range tests {
+		page := renderTestPDF(t, writeTestPDF(t, 100, 100, square+" "+tt.op, "<< >>"))
+		if b := page.Image.Bounds(); b != image.Rect(0, 0, 100, 100) {
+			t.Fatalf("%s: 图像大小 %v", tt.op, b)
+		}
+		if got := pixelAt(page.Image, 50, 50); got != tt.inner {
+			t.Errorf("%s: 内部 %v, 期望 %v", tt.op, got, tt.inner)
+		}
+		if got := pixelAt(page.Image, 20, 20); got != tt.outer {
+			t.Errorf("%s: 两个正方形之间 %v, 期望 %v", tt.op, got, tt.outer)
+		}
+		if got := pixelAt(page.Image, 5, 5); got != white {
+			t.Errorf("%s: 外部 %v, 期望白色", tt.op, got)
+		}
+	}
+}
+
+func TestRenderStrokeAndClip(t *testing.T) {
+	// 裁剪到左半边后描边一条横线，y轴向上
+	page := renderTestPDF(t, writeTestPDF(t, 100, 100, "0 0 50 100 re W n 1 0 0 RG 10 w 0 50 m 100 50 l S", "<< >>"))
+	if got := pixelAt(page.Image, 25, 50); got != (color.RGBA{255, 0, 0, 255}) {
+		t.Errorf("裁剪区域内的描边 %v, 期望红色", got)
+	}
+	if got := pixelAt(page.Image, 75, 50); got != (color.RGBA{255, 255, 255, 255}) {
+		t.Errorf("裁剪区域外 %v, 期望白色", got)
+	}
+	if got := pixelAt(page.Image, 25, 40); got != (color.RGBA{255, 255, 255, 255}) {
+		t.Errorf("线宽之外 %v, 期望白色", got)
+	}
+}
+
+func TestRenderMixedPage(t *testing.T) {
+	// 图像画在第一个方块之上、第二个方块之下
+	green := color.RGBA{0, 200, 0, 255}
+	path := writeTestPDF(t, 100, 100,
+		"1 0 0 rg 10 10 40 40 re f q 60 0 0 60 20 20 cm /Im1 Do Q 0 0 1 rg 60 60 30 30 re f",
+		"<< /XObject << /Im1 5 0 R >> >>",
+		testImage(4, 4, func(x, y int) color.RGBA { return green }))
+	page := renderTestPDF(t, path)
+	if page.Vector == nil || len(page.Vector.Images) != 1 || page.Vector.SkippedImages() != 0 {
+		t.Fatalf("期望解码1个图像: %+v", page.Vector)
+	}
+	tests := []struct {
+		x, y int // 图像坐标，y向下
+		want color.RGBA
+	}{
+		{15, 85, color.RGBA{255, 0, 0, 255}}, // 只有红色方块
+		{30, 75, green},                      // 图像盖住红色方块
+		{75, 25, color.RGBA{0, 0, 255, 255}}, // 蓝色方块盖住图像
+		{50, 50, green},
+		{95, 95, color.RGBA{255, 255, 255, 255}},
+	}
+	for _, tt := range tests {
+		if got := pixelAt(page.Image, tt.x, tt.y); got != tt.want {
+			t.Errorf("(%d,%d) = %v, 期望 %v", tt.x, tt.y, got, tt.want)
+		}
+	}
+	if box, ok := page.Vector.ContentBox(); !ok || box != (PageBox{10, 10, 90, 90}) {
+		t.Errorf("内容区域 %v %v, 期望包含图像和路径", box, ok)
+	}
+}
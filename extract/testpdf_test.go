@@ -0,0 +1,72 @@
+package extract
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// 写入只有一页的测试PDF：content为页面内容流，resources为页面资源字典，
+// objects为附加的间接对象，从5号开始编号
+func writeTestPDF(t *testing.T, width, height int, content, resources string, objects ...string) string {
+	t.Helper()
+	objs := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources %s /Contents 4 0 R >>", width, height, resources),
+		testStream("", content),
+	}
+	objs = append(objs, objects...)
+
+	var buf bytes.Buffer
+	// pdfcpu从文件末尾之前512字节处查找交叉引用表，用注释行把文件补足长度
+	buf.WriteString("%PDF-1.4\n%" + strings.Repeat("x", 512) + "\n")
+	offsets := make([]int, len(objs))
+	for i, obj := range objs {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objs)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objs)+1, xref)
+
+	path := filepath.Join(t.TempDir(), "test.pdf")
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// 流对象，dict为长度以外的字典项
+func testStream(dict, data string) string {
+	return fmt.Sprintf("<< %s /Length %d >>\nstream\n%s\nendstream", dict, len(data), data)
+}
+
+// 表单XObject对象
+func testForm(bbox, content string) string {
+	return testStream("/Type /XObject /Subtype /Form /BBox ["+bbox+"]", content)
+}
+
+// Flate压缩的RGB图像XObject对象，pixel返回每个像素的颜色
+func testImage(w, h int, pixel func(x, y int) color.RGBA) string {
+	var raw bytes.Buffer
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := pixel(x, y)
+			raw.Write([]byte{c.R, c.G, c.B})
+		}
+	}
+	var data bytes.Buffer
+	zw := zlib.NewWriter(&data)
+	zw.Write(raw.Bytes())
+	zw.Close()
+	return testStream(fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /FlateDecode", w, h), data.String())
+}
@@ -0,0 +1,768 @@
+package extract
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"os"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// 表单XObject的最大嵌套深度，防止循环引用
+const maxFormDepth = 16
+
+// 二维仿射变换矩阵 [a b c d e f]，与PDF的cm操作数顺序一致
+type Matrix [6]float64
+
+// 单位矩阵
+var IdentityMatrix = Matrix{1, 0, 0, 1, 0, 0}
+
+// 矩阵相乘：先应用m，再应用n
+func (m Matrix) Multiply(n Matrix) Matrix {
+	return Matrix{
+		m[0]*n[0] + m[1]*n[2],
+		m[0]*n[1] + m[1]*n[3],
+		m[2]*n[0] + m[3]*n[2],
+		m[2]*n[1] + m[3]*n[3],
+		m[4]*n[0] + m[5]*n[2] + n[4],
+		m[4]*n[1] + m[5]*n[3] + n[5],
+	}
+}
+
+// 变换一个点
+func (m Matrix) Apply(p VectorPoint) VectorPoint {
+	return VectorPoint{
+		X: m[0]*p.X + m[2]*p.Y + m[4],
+		Y: m[1]*p.X + m[3]*p.Y + m[5],
+	}
+}
+
+// 矩阵的平均缩放系数，用于换算线宽
+func (m Matrix) Scale() float64 {
+	return math.Sqrt(math.Abs(m[0]*m[3] - m[1]*m[2]))
+}
+
+// 矢量坐标点
+type VectorPoint struct {
+	X, Y float64
+}
+
+// 路径段类型
+type SegmentOp int
+
+const (
+	SegmentMoveTo SegmentOp = iota
+	SegmentLineTo
+	SegmentCubicTo
+	SegmentClose
+)
+
+// 路径段：MoveTo/LineTo含1个点，CubicTo含2个控制点和终点，Close不含点
+type PathSegment struct {
+	Op     SegmentOp
+	Points []VectorPoint
+}
+
+// 裁剪路径，坐标位于CTM所描述的用户空间
+type ClipPath struct {
+	Segments []PathSegment
+	CTM      Matrix
+	EvenOdd  bool
+}
+
+// 从内容流中提取的一条已绘制路径
+// 路径坐标保持为绘制时的用户空间坐标，CTM将其变换到页面空间
+type VectorPath struct {
+	Segments []PathSegment
+	CTM      Matrix
+
+	Fill        bool
+	Stroke      bool
+	EvenOdd     bool
+	FillColor   color.RGBA
+	StrokeColor color.RGBA
+	FillAlpha   float64
+	StrokeAlpha float64
+
+	LineWidth  float64
+	LineCap    int
+	LineJoin   int
+	MiterLimit float64
+	Dash       []float64
+	DashPhase  float64
+
+	// 绘制时生效的裁剪路径，需取交集
+	Clips []ClipPath
+}
+
+// 页面框（单位：点）
+type PageBox struct {
+	LLX, LLY, URX, URY float64
+}
+
+func (b PageBox) Width() float64 {
+	return b.URX - b.LLX
+}
+
+func (b PageBox) Height() float64 {
+	return b.URY - b.LLY
+}
+
+// 页面的矢量内容
+type VectorPage struct {
+	PageNumber int
+	MediaBox   PageBox
+	Rotate     int
+	Paths      []VectorPath
+	Images     []PageImage // 可以解码的位图，与路径按绘制顺序叠加
+	ImageCount int
+	TextCount  int
+}
+
+// 颜色空间类型
+type colorSpaceKind int
+
+const (
+	colorSpaceGray colorSpaceKind = iota
+	colorSpaceRGB
+	colorSpaceCMYK
+	colorSpaceSeparation
+	colorSpaceLab
+	colorSpacePattern
+	colorSpaceIndexed
+)
+
+// 图形状态
+type graphicsState struct {
+	ctm         Matrix
+	fillColor   color.RGBA
+	strokeColor color.RGBA
+	fillSpace   colorSpaceKind
+	strokeSpace colorSpaceKind
+	fillAlpha   float64
+	strokeAlpha float64
+	lineWidth   float64
+	lineCap     int
+	lineJoin    int
+	miterLimit  float64
+	dash        []float64
+	dashPhase   float64
+	clips       []ClipPath
+}
+
+func newGraphicsState() graphicsState {
+	black := color.RGBA{0, 0, 0, 255}
+	return graphicsState{
+		ctm:         IdentityMatrix,
+		fillColor:   black,
+		strokeColor: black,
+		fillAlpha:   1,
+		strokeAlpha: 1,
+		lineWidth:   1,
+		miterLimit:  10,
+	}
+}
+
+// 内容流路径解释器
+type pathInterpreter struct {
+	pdf   *model.Context
+	xref  *model.XRefTable
+	page  *VectorPage
+	state graphicsState
+	stack []graphicsState
+	floor int // 当前内容流可以恢复到的最低栈深度，表单内多余的Q不能弹出表单外保存的状态
+
+	path      []PathSegment
+	current   VectorPoint
+	start     VectorPoint
+	pendingW  bool
+	pendingEO bool
+	formDepth int
+
+	images map[int]image.Image // 按对象号缓存已解码的位图，解码失败时为nil
+}
+
+// 读取PDF上下文，同一文件只读取一次
+func (proc *Processor) context(pdfPath string) (*model.Context, error) {
+	if proc.ctx != nil && proc.ctxPath == pdfPath {
+		return proc.ctx, nil
+	}
+
+	ctx, err := ReadContext(pdfPath)
+	if err != nil {
+		return nil, err
+	}
+
+	proc.ctx, proc.ctxPath = ctx, pdfPath
+	return ctx, nil
+}
+
+// 读取并解析PDF文件
+func ReadContext(pdfPath string) (*model.Context, error) {
+	f, err := os.Open(pdfPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	conf := model.NewDefaultConfiguration()
+	conf.ValidationMode = model.ValidationRelaxed
+
+	ctx, err := api.ReadContext(f, conf)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.EnsurePageCount(); err != nil {
+		return nil, err
+	}
+	return ctx, nil
+}
+
+// 获取PDF页数
+func (proc *Processor) PageCount(pdfPath string) (int, error) {
+	ctx, err := proc.context(pdfPath)
+	if err != nil {
+		return 0, newError(pdfPath, 0, err)
+	}
+	return ctx.PageCount, nil
+}
+
+// 提取指定页面内容流中的矢量路径（页码从1开始）
+func (proc *Processor) ExtractVectorPaths(pdfPath string, pageNr int) (*VectorPage, error) {
+	ctx, err := proc.context(pdfPath)
+	if err != nil {
+		return nil, fmt.Errorf("读取PDF失败: %v", err)
+	}
+	return proc.extractPageVectors(ctx, pageNr)
+}
+
+// 从已读取的上下文中提取页面矢量内容
+func (proc *Processor) extractPageVectors(ctx *model.Context, pageNr int) (*VectorPage, error) {
+	if pageNr < 1 || pageNr > ctx.PageCount {
+		return nil, fmt.Errorf("页码 %d 超出范围 (共 %d 页)", pageNr, ctx.PageCount)
+	}
+
+	pageDict, _, inhAttrs, err := ctx.PageDict(pageNr, false)
+	if err != nil {
+		return nil, fmt.Errorf("读取第 %d 页失败: %v", pageNr, err)
+	}
+	if pageDict == nil {
+		return nil, fmt.Errorf("找不到第 %d 页", pageNr)
+	}
+
+	page := &VectorPage{
+		PageNumber: pageNr,
+		MediaBox:   PageBox{0, 0, 612, 792},
+		Rotate:     inhAttrs.Rotate,
+	}
+	box := inhAttrs.CropBox
+	if box == nil {
+		box = inhAttrs.MediaBox
+	}
+	if box != nil {
+		page.MediaBox = PageBox{
+			LLX: math.Min(box.LL.X, box.UR.X),
+			LLY: math.Min(box.LL.Y, box.UR.Y),
+			URX: math.Max(box.LL.X, box.UR.X),
+			URY: math.Max(box.LL.Y, box.UR.Y),
+		}
+	}
+
+	content, err := ctx.PageContent(pageDict)
+	if err != nil {
+		return nil, err
+	}
+
+	interp := &pathInterpreter{
+		pdf:   ctx,
+		xref:  ctx.XRefTable,
+		page:  page,
+		state: newGraphicsState(),
+	}
+	if err := interp.run(content, inhAttrs.Resources); err != nil {
+		return nil, fmt.Errorf("解析第 %d 页内容流失败: %v", pageNr, err)
+	}
+
+	return page, nil
+}
+
+// 解释一段内容流
+func (pi *pathInterpreter) run(content []byte, resources types.Dict) error {
+	lexer := newContentLexer(content)
+	var operands []contentToken
+
+	for {
+		tok, ok, err := lexer.next()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return nil
+		}
+		if tok.kind != tokenKeyword {
+			operands = append(operands, tok)
+			continue
+		}
+
+		switch tok.str {
+		case "BI":
+			lexer.skipInlineImage()
+			pi.page.ImageCount++
+		case "true", "false", "null":
+			operands = append(operands, tok)
+			continue
+		default:
+			pi.execute(tok.str, operands, resources)
+		}
+		operands = operands[:0]
+	}
+}
+
+// 取末尾n个数字操作数
+func numberArgs(operands []contentToken, n int) ([]float64, bool) {
+	if len(operands) < n {
+		return nil, false
+	}
+	args := make([]float64, n)
+	for i, op := range operands[len(operands)-n:] {
+		if op.kind != tokenNumber {
+			return nil, false
+		}
+		args[i] = op.num
+	}
+	return args, true
+}
+
+// 执行单个操作符
+func (pi *pathInterpreter) execute(op string, operands []contentToken, resources types.Dict) {
+	s := &pi.state
+
+	switch op {
+	// 图形状态
+	case "q":
+		saved := *s
+		saved.clips = append([]ClipPath(nil), s.clips...)
+		pi.stack = append(pi.stack, saved)
+	case "Q":
+		if len(pi.stack) > pi.floor {
+			pi.state = pi.stack[len(pi.stack)-1]
+			pi.stack = pi.stack[:len(pi.stack)-1]
+		}
+	case "cm":
+		if a, ok := numberArgs(operands, 6); ok {
+			s.ctm = Matrix{a[0], a[1], a[2], a[3], a[4], a[5]}.Multiply(s.ctm)
+		}
+	case "w":
+		if a, ok := numberArgs(operands, 1); ok {
+			s.lineWidth = a[0]
+		}
+	case "J":
+		if a, ok := numberArgs(operands, 1); ok {
+			s.lineCap = int(a[0])
+		}
+	case "j":
+		if a, ok := numberArgs(operands, 1); ok {
+			s.lineJoin = int(a[0])
+		}
+	case "M":
+		if a, ok := numberArgs(operands, 1); ok {
+			s.miterLimit = a[0]
+		}
+	case "d":
+		if len(operands) >= 2 && operands[0].kind == tokenArray {
+			s.dash = nil
+			for _, item := range operands[0].array {
+				if item.kind == tokenNumber {
+					s.dash = append(s.dash, item.num)
+				}
+			}
+			s.dashPhase = operands[1].num
+		}
+	case "gs":
+		if len(operands) >= 1 && operands[0].kind == tokenName {
+			pi.applyExtGState(operands[0].str, resources)
+		}
+
+	// 路径构造
+	case "m":
+		if a, ok := numberArgs(operands, 2); ok {
+			p := VectorPoint{a[0], a[1]}
+			pi.path = append(pi.path, PathSegment{Op: SegmentMoveTo, Points: []VectorPoint{p}})
+			pi.current, pi.start = p, p
+		}
+	case "l":
+		if a, ok := numberArgs(operands, 2); ok {
+			p := VectorPoint{a[0], a[1]}
+			pi.path = append(pi.path, PathSegment{Op: SegmentLineTo, Points: []VectorPoint{p}})
+			pi.current = p
+		}
+	case "c":
+		if a, ok := numberArgs(operands, 6); ok {
+			pi.curveTo(VectorPoint{a[0], a[1]}, VectorPoint{a[2], a[3]}, VectorPoint{a[4], a[5]})
+		}
+	case "v":
+		if a, ok := numberArgs(operands, 4); ok {
+			pi.curveTo(pi.current, VectorPoint{a[0], a[1]}, VectorPoint{a[2], a[3]})
+		}
+	case "y":
+		if a, ok := numberArgs(operands, 4); ok {
+			end := VectorPoint{a[2], a[3]}
+			pi.curveTo(VectorPoint{a[0], a[1]}, end, end)
+		}
+	case "h":
+		pi.closePath()
+	case "re":
+		if a, ok := numberArgs(operands, 4); ok {
+			x, y, w, h := a[0], a[1], a[2], a[3]
+			pi.path = append(pi.path,
+				PathSegment{Op: SegmentMoveTo, Points: []VectorPoint{{x, y}}},
+				PathSegment{Op: SegmentLineTo, Points: []VectorPoint{{x + w, y}}},
+				PathSegment{Op: SegmentLineTo, Points: []VectorPoint{{x + w, y + h}}},
+				PathSegment{Op: SegmentLineTo, Points: []VectorPoint{{x, y + h}}},
+				PathSegment{Op: SegmentClose},
+			)
+			pi.current, pi.start = VectorPoint{x, y}, VectorPoint{x, y}
+		}
+
+	// 路径绘制
+	case "f", "F":
+		pi.paint(true, false, false)
+	case "f*":
+		pi.paint(true, false, true)
+	case "S":
+		pi.paint(false, true, false)
+	case "s":
+		pi.closePath()
+		pi.paint(false, true, false)
+	case "B":
+		pi.paint(true, true, false)
+	case "B*":
+		pi.paint(true, true, true)
+	case "b":
+		pi.closePath()
+		pi.paint(true, true, false)
+	case "b*":
+		pi.closePath()
+		pi.paint(true, true, true)
+	case "n":
+		pi.paint(false, false, false)
+	case "W":
+		pi.pendingW, pi.pendingEO = true, false
+	case "W*":
+		pi.pendingW, pi.pendingEO = true, true
+
+	// 颜色
+	case "g":
+		s.fillSpace = colorSpaceGray
+		pi.setColor(&s.fillColor, s.fillSpace, operands)
+	case "G":
+		s.strokeSpace = colorSpaceGray
+		pi.setColor(&s.strokeColor, s.strokeSpace, operands)
+	case "rg":
+		s.fillSpace = colorSpaceRGB
+		pi.setColor(&s.fillColor, s.fillSpace, operands)
+	case "RG":
+		s.strokeSpace = colorSpaceRGB
+		pi.setColor(&s.strokeColor, s.strokeSpace, operands)
+	case "k":
+		s.fillSpace = colorSpaceCMYK
+		pi.setColor(&s.fillColor, s.fillSpace, operands)
+	case "K":
+		s.strokeSpace = colorSpaceCMYK
+		pi.setColor(&s.strokeColor, s.strokeSpace, operands)
+	case "cs":
+		if len(operands) >= 1 && operands[0].kind == tokenName {
+			s.fillSpace = pi.resolveColorSpace(operands[0].str, resources)
+			s.fillColor = color.RGBA{0, 0, 0, 255}
+		}
+	case "CS":
+		if len(operands) >= 1 && operands[0].kind == tokenName {
+			s.strokeSpace = pi.resolveColorSpace(operands[0].str, resources)
+			s.strokeColor = color.RGBA{0, 0, 0, 255}
+		}
+	case "sc", "scn":
+		pi.setColor(&s.fillColor, s.fillSpace, operands)
+	case "SC", "SCN":
+		pi.setColor(&s.strokeColor, s.strokeSpace, operands)
+
+	// 外部对象
+	case "Do":
+		if len(operands) >= 1 && operands[0].kind == tokenName {
+			pi.drawXObject(operands[0].str, resources)
+		}
+
+	// 文本只计数，不转换为轮廓
+	case "Tj", "TJ", "'", "\"":
+		pi.page.TextCount++
+	}
+}
+
+func (pi *pathInterpreter) curveTo(c1, c2, end VectorPoint) {
+	pi.path = append(pi.path, PathSegment{Op: SegmentCubicTo, Points: []VectorPoint{c1, c2, end}})
+	pi.current = end
+}
+
+func (pi *pathInterpreter) closePath() {
+	if len(pi.path) == 0 {
+		return
+	}
+	pi.path = append(pi.path, PathSegment{Op: SegmentClose})
+	pi.current = pi.start
+}
+
+// 结束当前路径：按需填充/描边，并处理挂起的裁剪
+func (pi *pathInterpreter) paint(fill, stroke, evenOdd bool) {
+	s := &pi.state
+
+	if (fill || stroke) && len(pi.path) > 0 {
+		vp := VectorPath{
+			Segments:    pi.path,
+			CTM:         s.ctm,
+			Fill:        fill,
+			Stroke:      stroke,
+			EvenOdd:     evenOdd,
+			FillColor:   s.fillColor,
+			StrokeColor: s.strokeColor,
+			FillAlpha:   s.fillAlpha,
+			StrokeAlpha: s.strokeAlpha,
+			LineWidth:   s.lineWidth,
+			LineCap:     s.lineCap,
+			LineJoin:    s.lineJoin,
+			MiterLimit:  s.miterLimit,
+			Dash:        s.dash,
+			DashPhase:   s.dashPhase,
+			Clips:       s.clips,
+		}
+		pi.page.Paths = append(pi.page.Paths, vp)
+	}
+
+	// W/W* 在绘制操作之后才生效
+	if pi.pendingW && len(pi.path) > 0 {
+		clips := make([]ClipPath, len(s.clips), len(s.clips)+1)
+		copy(clips, s.clips)
+		s.clips = append(clips, ClipPath{Segments: pi.path, CTM: s.ctm, EvenOdd: pi.pendingEO})
+	}
+
+	pi.pendingW = false
+	pi.path = nil
+}
+
+// 按颜色空间将操作数转换为RGB颜色
+func (pi *pathInterpreter) setColor(dst *color.RGBA, space colorSpaceKind, operands []contentToken) {
+	var comps []float64
+	for _, op := range operands {
+		if op.kind == tokenNumber {
+			comps = append(comps, op.num)
+		}
+	}
+	if len(comps) == 0 {
+		// 图案颜色等无法直接表示，保持当前颜色
+		return
+	}
+
+	clamp := func(v float64) uint8 {
+		return uint8(math.Round(math.Max(0, math.Min(1, v)) * 255))
+	}
+
+	switch {
+	case space == colorSpaceSeparation:
+		// 专色按色调近似为灰度
+		tint := comps[0]
+		for _, c := range comps[1:] {
+			tint = math.Max(tint, c)
+		}
+		v := clamp(1 - tint)
+		*dst = color.RGBA{v, v, v, 255}
+	case space == colorSpaceLab:
+		v := clamp(comps[0] / 100)
+		*dst = color.RGBA{v, v, v, 255}
+	case space == colorSpaceIndexed:
+		*dst = color.RGBA{0, 0, 0, 255}
+	case len(comps) >= 4:
+		c, m, y, k := comps[0], comps[1], comps[2], comps[3]
+		*dst = color.RGBA{clamp((1 - c) * (1 - k)), clamp((1 - m) * (1 - k)), clamp((1 - y) * (1 - k)), 255}
+	case len(comps) == 3:
+		*dst = color.RGBA{clamp(comps[0]), clamp(comps[1]), clamp(comps[2]), 255}
+	default:
+		v := clamp(comps[0])
+		*dst = color.RGBA{v, v, v, 255}
+	}
+}
+
+// 查找资源字典中的子字典条目
+func (pi *pathInterpreter) resource(resources types.Dict, category, name string) types.Object {
+	if resources == nil {
+		return nil
+	}
+	sub, err := pi.xref.DereferenceDict(resources[category])
+	if err != nil || sub == nil {
+		return nil
+	}
+	obj, err := pi.xref.Dereference(sub[name])
+	if err != nil {
+		return nil
+	}
+	return obj
+}
+
+// 解析颜色空间名称
+func (pi *pathInterpreter) resolveColorSpace(name string, resources types.Dict) colorSpaceKind {
+	switch name {
+	case "DeviceGray", "G", "CalGray":
+		return colorSpaceGray
+	case "DeviceRGB", "RGB", "CalRGB":
+		return colorSpaceRGB
+	case "DeviceCMYK", "CMYK":
+		return colorSpaceCMYK
+	case "Pattern":
+		return colorSpacePattern
+	}
+
+	arr, ok := pi.resource(resources, "ColorSpace", name).(types.Array)
+	if !ok || len(arr) == 0 {
+		return colorSpaceGray
+	}
+	family, _ := arr[0].(types.Name)
+	switch family {
+	case "ICCBased":
+		if len(arr) > 1 {
+			if sd, _, err := pi.xref.DereferenceStreamDict(arr[1]); err == nil && sd != nil {
+				if n := sd.IntEntry("N"); n != nil {
+					switch *n {
+					case 1:
+						return colorSpaceGray
+					case 4:
+						return colorSpaceCMYK
+					}
+				}
+			}
+		}
+		return colorSpaceRGB
+	case "CalRGB":
+		return colorSpaceRGB
+	case "CalGray":
+		return colorSpaceGray
+	case "Lab":
+		return colorSpaceLab
+	case "Separation", "DeviceN":
+		return colorSpaceSeparation
+	case "Indexed", "I":
+		return colorSpaceIndexed
+	case "Pattern":
+		return colorSpacePattern
+	}
+	return colorSpaceGray
+}
+
+// 应用扩展图形状态中的线宽、透明度等参数
+func (pi *pathInterpreter) applyExtGState(name string, resources types.Dict) {
+	d, ok := pi.resource(resources, "ExtGState", name).(types.Dict)
+	if !ok {
+		return
+	}
+	s := &pi.state
+	number := func(key string) (float64, bool) {
+		o, found := d.Find(key)
+		if !found {
+			return 0, false
+		}
+		v, err := pi.xref.DereferenceNumber(o)
+		return v, err == nil
+	}
+
+	if v, ok := number("LW"); ok {
+		s.lineWidth = v
+	}
+	if v, ok := number("LC"); ok {
+		s.lineCap = int(v)
+	}
+	if v, ok := number("LJ"); ok {
+		s.lineJoin = int(v)
+	}
+	if v, ok := number("ML"); ok {
+		s.miterLimit = v
+	}
+	if v, ok := number("CA"); ok {
+		s.strokeAlpha = v
+	}
+	if v, ok := number("ca"); ok {
+		s.fillAlpha = v
+	}
+}
+
+// 绘制XObject：表单递归解释，图像按当前CTM和裁剪记录
+func (pi *pathInterpreter) drawXObject(name string, resources types.Dict) {
+	if resources == nil {
+		return
+	}
+	sub, err := pi.xref.DereferenceDict(resources["XObject"])
+	if err != nil || sub == nil {
+		return
+	}
+	sd, _, err := pi.xref.DereferenceStreamDict(sub[name])
+	if err != nil || sd == nil {
+		return
+	}
+
+	switch subtype := sd.Subtype(); {
+	case subtype != nil && *subtype == "Image":
+		pi.page.ImageCount++
+		pi.drawImage(sub[name], sd)
+	case subtype != nil && *subtype == "Form":
+		if pi.formDepth >= maxFormDepth {
+			return
+		}
+		if err := sd.Decode(); err != nil {
+			return
+		}
+
+		formResources := resources
+		if d, err := pi.xref.DereferenceDict(sd.Dict["Resources"]); err == nil && d != nil {
+			formResources = d
+		}
+
+		pi.execute("q", nil, resources)
+		if arr, err := pi.xref.DereferenceArray(sd.Dict["Matrix"]); err == nil && len(arr) == 6 {
+			var m Matrix
+			for i, o := range arr {
+				m[i], _ = pi.xref.DereferenceNumber(o)
+			}
+			pi.state.ctm = m.Multiply(pi.state.ctm)
+		}
+		if arr, err := pi.xref.DereferenceArray(sd.Dict["BBox"]); err == nil && len(arr) == 4 {
+			var b [4]float64
+			for i, o := range arr {
+				b[i], _ = pi.xref.DereferenceNumber(o)
+			}
+			pi.path = nil
+			pi.execute("re", []contentToken{
+				{kind: tokenNumber, num: b[0]}, {kind: tokenNumber, num: b[1]},
+				{kind: tokenNumber, num: b[2] - b[0]}, {kind: tokenNumber, num: b[3] - b[1]},
+			}, formResources)
+			pi.execute("W", nil, formResources)
+			pi.execute("n", nil, formResources)
+		}
+
+		depth, floor := len(pi.stack), pi.floor
+		pi.floor = depth
+		pi.formDepth++
+		savedPath := pi.path
+		pi.path = nil
+		if err := pi.run(sd.Content, formResources); err != nil {
+			// 表单内容损坏时保留已解析部分
+			pi.path = nil
+		}
+		pi.path = savedPath
+		pi.formDepth--
+		pi.floor = floor
+		// 表单内不配对的q会被丢弃
+		if len(pi.stack) > depth {
+			pi.stack = pi.stack[:depth]
+		}
+		pi.execute("Q", nil, resources)
+	}
+}
@@ -0,0 +1,59 @@
+package extract
+
+import (
+	"context"
+	"image/color"
+	"testing"
+)
+
+func TestExtractPaths(t *testing.T) {
+	path := writeTestPDF(t, 200, 100,
+		"1 0 0 rg 10 10 50 30 re f\n0 0 1 RG 2 w 100 10 m 150 60 l 190 10 180 90 120 80 c S\n0.5 G 0 0 1 rg 20 50 m 60 50 l 40 90 l h B*",
+		"<< >>")
+	page, err := NewProcessor(72).ExtractPage(context.Background(), path, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths := page.Vector.Paths
+	if len(paths) != 3 {
+		t.Fatalf("路径数 %d, 期望 3", len(paths))
+	}
+	if !paths[0].Fill || paths[0].Stroke || paths[0].FillColor != (color.RGBA{255, 0, 0, 255}) || len(paths[0].Segments) != 5 {
+		t.Errorf("矩形路径: %+v", paths[0])
+	}
+	if paths[1].Fill || !paths[1].Stroke || paths[1].StrokeColor != (color.RGBA{0, 0, 255, 255}) || paths[1].LineWidth != 2 {
+		t.Errorf("描边路径: %+v", paths[1])
+	}
+	if ops := len(paths[1].Segments); ops != 3 || paths[1].Segments[2].Op != SegmentCubicTo {
+		t.Errorf("描边路径的线段: %+v", paths[1].Segments)
+	}
+	if !paths[2].Fill || !paths[2].Stroke || !paths[2].EvenOdd || paths[2].StrokeColor != (color.RGBA{128, 128, 128, 255}) {
+		t.Errorf("B*路径: %+v", paths[2])
+	}
+}
+
+func TestFormUnbalancedRestore(t *testing.T) {
+	// 表单内多余的Q不能恢复表单外保存的状态，表单结束后外层的q/Q仍然配对
+	path := writeTestPDF(t, 100, 100,
+		"1 0 0 rg q 0 1 0 rg /Fm1 Do 0 0 10 10 re f Q 20 0 10 10 re f",
+		"<< /XObject << /Fm1 5 0 R >> >>",
+		testForm("0 0 100 100", "Q Q 0 0 1 rg q 0.5 0 0 0.5 0 0 cm Q 40 40 10 10 re f"))
+	page, err := NewProcessor(72).ExtractPage(context.Background(), path, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []color.RGBA{{0, 0, 255, 255}, {0, 255, 0, 255}, {255, 0, 0, 255}}
+	paths := page.Vector.Paths
+	if len(paths) != len(want) {
+		t.Fatalf("路径数 %d, 期望 %d", len(paths), len(want))
+	}
+	for i, p := range paths {
+		if p.FillColor != want[i] {
+			t.Errorf("路径 %d 颜色 %v, 期望 %v", i, p.FillColor, want[i])
+		}
+	}
+	// 表单内的路径不受表单外被弹出的状态影响，坐标保持原样
+	if got := paths[0].CTM.Apply(paths[0].Segments[0].Points[0]); got != (VectorPoint{40, 40}) {
+		t.Errorf("表单内路径起点 %v, 期望 (40,40)", got)
+	}
+}
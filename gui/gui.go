@@ -0,0 +1,772 @@
+//go:build !nogui
+
+// Package gui 基于Fyne的图形界面，处理逻辑由pipeline包完成
+package gui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/app"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"pdf-vector-extractor/crop"
+	"pdf-vector-extractor/detect"
+	"pdf-vector-extractor/layout"
+	"pdf-vector-extractor/pipeline"
+)
+
+// 显示失败报告对话框
+func showFailureReport(report *pipeline.ProcessReport, win fyne.Window) {
+	text := report.Summary()
+	if report.ReportPath != "" {
+		text += "\n报告已保存到: " + report.ReportPath
+	}
+
+	reportLabel := widget.NewLabel(text)
+	reportLabel.Wrapping = fyne.TextWrapWord
+	scroll := container.NewVScroll(reportLabel)
+	scroll.SetMinSize(fyne.NewSize(500, 300))
+
+	dialog.ShowCustom("失败报告", "关闭", scroll, win)
+}
+
+// 启动图形界面，窗口关闭后返回
+func Run() {
+	// 初始化中文字体支持，解决乱码问题
+	initChineseFont()
+
+	myApp := app.New()
+	myApp.SetIcon(nil)
+
+	myWindow := myApp.NewWindow("PDF矢量图提取工具 v1.0")
+	myWindow.Resize(fyne.NewSize(650, 900))
+	myWindow.CenterOnScreen()
+
+	// 配置
+	config := pipeline.DefaultConfig()
+
+	// 界面组件
+	inputDirLabel := widget.NewLabel("输入目录:")
+	inputDirEntry := widget.NewEntry()
+	inputDirEntry.SetPlaceHolder("请选择包含PDF文件的目录路径...")
+
+	// 获取桌面路径
+	getDesktopPath := func() string {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		return filepath.Join(homeDir, "Desktop")
+	}
+
+	selectDirBtn := widget.NewButton("选择目录", func() {
+		dialog.ShowFolderOpen(func(list fyne.ListableURI, err error) {
+			if err != nil {
+				dialog.ShowError(err, myWindow)
+				return
+			}
+			if list == nil {
+				return
+			}
+			config.InputDir = list.Path()
+			inputDirEntry.SetText(list.Path())
+		}, myWindow)
+	})
+
+	// 桌面快捷按钮
+	desktopBtn := widget.NewButton("桌面", func() {
+		desktopPath := getDesktopPath()
+		if desktopPath != "" {
+			config.InputDir = desktopPath
+			inputDirEntry.SetText(desktopPath)
+		}
+	})
+
+	outputPathLabel := widget.NewLabel("输出文件夹:")
+	outputPathEntry := widget.NewEntry()
+	outputPathEntry.SetPlaceHolder("请选择输出文件夹，拼接后的图片将保存在此...")
+
+	selectOutputBtn := widget.NewButton("选择输出文件夹", func() {
+		dialog.ShowFolderOpen(func(folder fyne.ListableURI, err error) {
+			if err != nil {
+				dialog.ShowError(err, myWindow)
+				return
+			}
+			if folder == nil {
+				return
+			}
+			config.OutputPath = folder.Path()
+			outputPathEntry.SetText(folder.Path())
+		}, myWindow)
+	})
+
+	// 输出到桌面快捷按钮
+	outputDesktopBtn := widget.NewButton("桌面", func() {
+		desktopPath := getDesktopPath()
+		if desktopPath != "" {
+			config.OutputPath = desktopPath
+			outputPathEntry.SetText(desktopPath)
+		}
+	})
+
+	// 参数配置
+	boxSizeLabel := widget.NewLabel("裁剪框大小 (像素):")
+	boxSizeEntry := widget.NewEntry()
+	boxSizeEntry.SetText("200")
+	boxSizeEntry.OnChanged = func(text string) {
+		if size, err := strconv.Atoi(text); err == nil && size > 0 {
+			config.BoxSize = size
+		}
+	}
+
+	spacingLabel := widget.NewLabel("行间距 (像素):")
+	spacingEntry := widget.NewEntry()
+	spacingEntry.SetText(strconv.Itoa(config.Spacing))
+	spacingEntry.OnChanged = func(text string) {
+		if spacing, err := strconv.Atoi(text); err == nil && spacing >= 0 {
+			config.Spacing = spacing
+		}
+	}
+
+	// 网格排版
+	columnSpacingLabel := widget.NewLabel("列间距 (像素):")
+	columnSpacingEntry := widget.NewEntry()
+	columnSpacingEntry.SetText(strconv.Itoa(config.ColumnSpacing))
+	columnSpacingEntry.OnChanged = func(text string) {
+		if spacing, err := strconv.Atoi(text); err == nil && spacing >= 0 {
+			config.ColumnSpacing = spacing
+		}
+	}
+
+	columnsLabel := widget.NewLabel("列数:")
+	columnsEntry := widget.NewEntry()
+	columnsEntry.SetText(strconv.Itoa(config.Columns))
+	columnsEntry.SetPlaceHolder("留空则由行数决定，都留空时自动排列")
+	columnsEntry.OnChanged = func(text string) {
+		if text == "" {
+			config.Columns = 0
+		} else if n, err := strconv.Atoi(text); err == nil && n >= 0 {
+			config.Columns = n
+		}
+	}
+
+	rowsLabel := widget.NewLabel("行数 (列数留空时生效):")
+	rowsEntry := widget.NewEntry()
+	rowsEntry.SetPlaceHolder("1为横向一行")
+	rowsEntry.OnChanged = func(text string) {
+		if text == "" {
+			config.Rows = 0
+		} else if n, err := strconv.Atoi(text); err == nil && n >= 0 {
+			config.Rows = n
+		}
+	}
+
+	fillLabel := widget.NewLabel("填充顺序:")
+	fillNames := map[string]string{
+		"先行后列": layout.FillRowMajor,
+		"先列后行": layout.FillColumnMajor,
+	}
+	fillSelect := widget.NewSelect([]string{"先行后列", "先列后行"}, func(selected string) {
+		config.FillOrder = fillNames[selected]
+	})
+	fillSelect.SetSelectedIndex(0)
+
+	marginLabel := widget.NewLabel("外边距 (像素):")
+	marginEntry := widget.NewEntry()
+	marginEntry.SetText(strconv.Itoa(config.Margin))
+	marginEntry.OnChanged = func(text string) {
+		if margin, err := strconv.Atoi(text); err == nil && margin >= 0 {
+			config.Margin = margin
+		}
+	}
+
+	// 排版方式和紧密排版
+	layouterLabel := widget.NewLabel("排版方式:")
+	layouterNames := map[string]string{
+		"网格":         pipeline.DefaultLayouter,
+		"紧密排列 (精灵图)": "pack",
+	}
+	layouterSelect := widget.NewSelect([]string{"网格", "紧密排列 (精灵图)"}, func(selected string) {
+		config.Layouter = layouterNames[selected]
+	})
+	layouterSelect.SetSelectedIndex(0)
+
+	packWidthLabel := widget.NewLabel("紧密排列宽度 (像素):")
+	packWidthEntry := widget.NewEntry()
+	packWidthEntry.SetPlaceHolder("留空则自动")
+	packWidthEntry.OnChanged = func(text string) {
+		if text == "" {
+			config.PackWidth = 0
+		} else if n, err := strconv.Atoi(text); err == nil && n >= 0 {
+			config.PackWidth = n
+		}
+	}
+
+	atlasLabel := widget.NewLabel("图集描述:")
+	atlasNames := map[string]string{
+		"不生成":  "",
+		"JSON": pipeline.AtlasJSON,
+		"CSV":  pipeline.AtlasCSV,
+	}
+	atlasSelect := widget.NewSelect([]string{"不生成", "JSON", "CSV"}, func(selected string) {
+		config.Atlas = atlasNames[selected]
+	})
+	atlasSelect.SetSelectedIndex(0)
+
+	// 按纸张分页
+	paperLabel := widget.NewLabel("纸张 (分页输出):")
+	paperSelect := widget.NewSelectEntry([]string{"A4", "A3", "A5", "Letter", "Legal"})
+	paperSelect.SetPlaceHolder("留空不分页，或输入 宽x高（毫米）")
+	paperSelect.OnChanged = func(text string) {
+		config.PaperSize = strings.TrimSpace(text)
+	}
+
+	paperMarginLabel := widget.NewLabel("页边距 (毫米):")
+	paperMarginEntry := widget.NewEntry()
+	paperMarginEntry.SetText(strconv.FormatFloat(config.PaperMargin, 'f', -1, 64))
+	paperMarginEntry.OnChanged = func(text string) {
+		if margin, err := strconv.ParseFloat(text, 64); err == nil && margin >= 0 {
+			config.PaperMargin = margin
+		}
+	}
+
+	landscapeCheck := widget.NewCheck("横向", func(checked bool) {
+		config.Landscape = checked
+	})
+	cropMarksCheck := widget.NewCheck("裁切线", func(checked bool) {
+		config.CropMarks = checked
+	})
+	pageNumbersCheck := widget.NewCheck("页码", func(checked bool) {
+		config.PageNumbers = checked
+	})
+
+	backgroundLabel := widget.NewLabel("背景颜色:")
+	backgroundEntry := widget.NewEntry()
+	backgroundEntry.SetText(config.Background)
+	backgroundEntry.SetPlaceHolder("#ffffff，none为透明")
+	backgroundEntry.OnChanged = func(text string) {
+		config.Background = strings.TrimSpace(text)
+	}
+
+	renderDPILabel := widget.NewLabel("渲染分辨率 (DPI):")
+	renderDPIEntry := widget.NewEntry()
+	renderDPIEntry.SetText(strconv.Itoa(config.RenderDPI))
+	renderDPIEntry.OnChanged = func(text string) {
+		if dpi, err := strconv.Atoi(text); err == nil && dpi > 0 {
+			config.RenderDPI = dpi
+		}
+	}
+
+	outputDPILabel := widget.NewLabel("PDF/AI输出分辨率 (DPI):")
+	outputDPIEntry := widget.NewEntry()
+	outputDPIEntry.SetPlaceHolder("留空则与渲染分辨率一致")
+	outputDPIEntry.OnChanged = func(text string) {
+		if text == "" {
+			config.OutputDPI = 0
+		} else if dpi, err := strconv.Atoi(text); err == nil && dpi > 0 {
+			config.OutputDPI = dpi
+		}
+	}
+
+	concurrencyLabel := widget.NewLabel("并行处理文件数:")
+	concurrencyEntry := widget.NewEntry()
+	concurrencyEntry.SetPlaceHolder("留空则使用全部CPU")
+	concurrencyEntry.OnChanged = func(text string) {
+		if text == "" {
+			config.Concurrency = 0
+		} else if n, err := strconv.Atoi(text); err == nil && n > 0 {
+			config.Concurrency = n
+		}
+	}
+
+	pageSelectionLabel := widget.NewLabel("页面选择:")
+	pageSelectionEntry := widget.NewEntry()
+	pageSelectionEntry.SetPlaceHolder("1、all、1-3,7、odd、even")
+	pageSelectionEntry.SetText("1")
+	pageSelectionEntry.OnChanged = func(text string) {
+		config.PageSelection = strings.TrimSpace(text)
+	}
+
+	// 内容检测和分割：分割时所选检测方法用于标记内容像素，矢量几何不能分割，按只含位图页面的检测方法标记
+	detectMethod, fallbackMethod, segment := pipeline.DefaultDetector, detect.MethodEdge, false
+	applyDetector := func() {
+		config.Detector, config.SegmentMethod, config.VectorFallback = detectMethod, detectMethod, fallbackMethod
+		if detectMethod == detect.MethodVector {
+			config.SegmentMethod = fallbackMethod
+		}
+		if segment {
+			config.Detector = "segment"
+		}
+	}
+
+	pixelMethods := []string{"边缘检测", "自动阈值 (Otsu)", "局部阈值 (扫描件)", "背景色估计 (彩色纸张)"}
+	detectorNames := map[string]string{
+		"矢量几何":         detect.MethodVector,
+		"边缘检测":         detect.MethodEdge,
+		"自动阈值 (Otsu)":  detect.MethodOtsu,
+		"局部阈值 (扫描件)":   detect.MethodAdaptive,
+		"背景色估计 (彩色纸张)": detect.MethodBackground,
+	}
+
+	detectorLabel := widget.NewLabel("检测方法:")
+	detectorSelect := widget.NewSelect(append([]string{"矢量几何"}, pixelMethods...), func(selected string) {
+		detectMethod = detectorNames[selected]
+		applyDetector()
+	})
+	detectorSelect.SetSelectedIndex(0)
+
+	fallbackLabel := widget.NewLabel("只含位图的页面:")
+	fallbackSelect := widget.NewSelect(pixelMethods, func(selected string) {
+		fallbackMethod = detectorNames[selected]
+		applyDetector()
+	})
+	fallbackSelect.SetSelectedIndex(0)
+
+	segmentCheck := widget.NewCheck("每页分割多个设计", func(checked bool) {
+		segment = checked
+		applyDetector()
+	})
+
+	detectThresholdLabel := widget.NewLabel("检测阈值 (0-255):")
+	detectThresholdEntry := widget.NewEntry()
+	detectThresholdEntry.SetPlaceHolder("留空则使用检测方法的默认值")
+	detectThresholdEntry.OnChanged = func(text string) {
+		if text == "" {
+			config.DetectThreshold = 0
+		} else if n, err := strconv.Atoi(text); err == nil && n >= 0 && n <= 255 {
+			config.DetectThreshold = n
+		}
+	}
+
+	detectMarginLabel := widget.NewLabel("内容边距 (像素):")
+	detectMarginEntry := widget.NewEntry()
+	detectMarginEntry.SetText(strconv.Itoa(config.DetectMargin))
+	detectMarginEntry.OnChanged = func(text string) {
+		if n, err := strconv.Atoi(text); err == nil && n >= 0 {
+			config.DetectMargin = n
+		}
+	}
+
+	mergeDistanceLabel := widget.NewLabel("分割合并距离 (像素):")
+	mergeDistanceEntry := widget.NewEntry()
+	mergeDistanceEntry.SetText(strconv.Itoa(config.MergeDistance))
+	mergeDistanceEntry.OnChanged = func(text string) {
+		if n, err := strconv.Atoi(text); err == nil && n >= 0 {
+			config.MergeDistance = n
+		}
+	}
+
+	minAreaLabel := widget.NewLabel("分割最小面积 (平方像素):")
+	minAreaEntry := widget.NewEntry()
+	minAreaEntry.SetText(strconv.Itoa(config.MinArea))
+	minAreaEntry.OnChanged = func(text string) {
+		if n, err := strconv.Atoi(text); err == nil && n >= 0 {
+			config.MinArea = n
+		}
+	}
+
+	// 内容缩放到裁剪框
+	fitLabel := widget.NewLabel("内容缩放:")
+	fitNames := map[string]string{
+		"不缩放":       crop.FitNone,
+		"完整放入裁剪框":   crop.FitContain,
+		"铺满裁剪框":     crop.FitCover,
+		"只缩小大于裁剪框的": crop.FitDownscale,
+	}
+	fitSelect := widget.NewSelect([]string{"不缩放", "完整放入裁剪框", "铺满裁剪框", "只缩小大于裁剪框的"}, func(selected string) {
+		config.FitMode = fitNames[selected]
+	})
+	fitSelect.SetSelectedIndex(0)
+
+	filterLabel := widget.NewLabel("重采样滤镜:")
+	filterNames := map[string]string{
+		"最近邻":         crop.FilterNearest,
+		"双线性":         crop.FilterBilinear,
+		"Catmull-Rom": crop.FilterCatmullRom,
+		"Lanczos":     crop.FilterLanczos,
+	}
+	filterSelect := widget.NewSelect([]string{"最近邻", "双线性", "Catmull-Rom", "Lanczos"}, func(selected string) {
+		config.ResampleFilter = filterNames[selected]
+	})
+	filterSelect.SetSelected("Catmull-Rom")
+
+	// 内容中心点和在裁剪框中的对齐
+	centerLabel := widget.NewLabel("内容中心点:")
+	centerNames := map[string]string{
+		"外接矩形":      layout.CenterBounds,
+		"墨迹重心":      layout.CenterCentroid,
+		"基线对齐 (文字)": layout.CenterBaseline,
+	}
+	centerSelect := widget.NewSelect([]string{"外接矩形", "墨迹重心", "基线对齐 (文字)"}, func(selected string) {
+		config.CenterMode = centerNames[selected]
+	})
+	centerSelect.SetSelectedIndex(0)
+
+	alignXLabel := widget.NewLabel("水平对齐:")
+	alignXNames := map[string]string{"左": layout.AlignLeft, "居中": layout.AlignCenter, "右": layout.AlignRight}
+	alignXSelect := widget.NewSelect([]string{"左", "居中", "右"}, func(selected string) {
+		config.AlignHorizontal = alignXNames[selected]
+	})
+	alignXSelect.SetSelected("居中")
+
+	alignYLabel := widget.NewLabel("垂直对齐:")
+	alignYNames := map[string]string{"上": layout.AlignTop, "居中": layout.AlignMiddle, "下": layout.AlignBottom}
+	alignYSelect := widget.NewSelect([]string{"上", "居中", "下"}, func(selected string) {
+		config.AlignVertical = alignYNames[selected]
+	})
+	alignYSelect.SetSelected("居中")
+
+	// 目录扫描
+	recursiveCheck := widget.NewCheck("包含子目录", func(checked bool) {
+		config.Recursive = checked
+	})
+	perDirCheck := widget.NewCheck("按子目录分别输出", func(checked bool) {
+		config.PerDirectory = checked
+	})
+
+	includeLabel := widget.NewLabel("包含文件:")
+	includeEntry := widget.NewEntry()
+	includeEntry.SetPlaceHolder("逗号分隔，如 *.pdf,图纸/**/*.pdf，留空则全部包含")
+	includeEntry.OnChanged = func(text string) {
+		config.Include = splitPatterns(text)
+	}
+
+	excludeLabel := widget.NewLabel("排除文件或目录:")
+	excludeEntry := widget.NewEntry()
+	excludeEntry.SetPlaceHolder("逗号分隔，如 草稿*,旧版")
+	excludeEntry.OnChanged = func(text string) {
+		config.Exclude = splitPatterns(text)
+	}
+
+	// 文件顺序
+	sortLabel := widget.NewLabel("文件排序:")
+	sortNames := map[string]string{
+		"自然排序 (2 在 10 之前)": pipeline.SortNatural,
+		"文件名字节顺序":          pipeline.SortName,
+		"修改时间":             pipeline.SortModTime,
+		"拼音":               pipeline.SortPinyin,
+	}
+	sortOptions := []string{"自然排序 (2 在 10 之前)", "文件名字节顺序", "修改时间", "拼音"}
+	sortSelect := widget.NewSelect(sortOptions, func(selected string) {
+		config.SortOrder = sortNames[selected]
+	})
+	sortSelect.SetSelectedIndex(0)
+
+	orderFileLabel := widget.NewLabel("排序清单:")
+	orderFileEntry := widget.NewEntry()
+	orderFileEntry.SetPlaceHolder("可选，每行一个文件名或.csv文件，优先于排序方式")
+	orderFileEntry.OnChanged = func(text string) {
+		config.OrderFile = strings.TrimSpace(text)
+	}
+	orderFileBtn := widget.NewButton("选择", func() {
+		dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil {
+				dialog.ShowError(err, myWindow)
+				return
+			}
+			if reader == nil {
+				return
+			}
+			reader.Close()
+			orderFileEntry.SetText(reader.URI().Path())
+		}, myWindow)
+	})
+
+	traceCheck := widget.NewCheck("将位图描摹为矢量路径 (SVG/AI/PDF)", func(checked bool) {
+		config.TraceRaster = checked
+	})
+	traceCheck.SetChecked(true)
+
+	traceThresholdLabel := widget.NewLabel("描摹阈值 (0-255):")
+	traceThresholdEntry := widget.NewEntry()
+	traceThresholdEntry.SetText(strconv.Itoa(config.TraceThreshold))
+	traceThresholdEntry.OnChanged = func(text string) {
+		if threshold, err := strconv.Atoi(text); err == nil && threshold > 0 && threshold <= 255 {
+			config.TraceThreshold = threshold
+		}
+	}
+
+	traceCornerLabel := widget.NewLabel("拐角阈值 (度):")
+	traceCornerEntry := widget.NewEntry()
+	traceCornerEntry.SetText(strconv.FormatFloat(config.TraceCornerAngle, 'f', -1, 64))
+	traceCornerEntry.OnChanged = func(text string) {
+		if angle, err := strconv.ParseFloat(text, 64); err == nil && angle > 0 {
+			config.TraceCornerAngle = angle
+		}
+	}
+
+	traceSpeckleLabel := widget.NewLabel("斑点过滤 (像素):")
+	traceSpeckleEntry := widget.NewEntry()
+	traceSpeckleEntry.SetText(strconv.Itoa(config.TraceSpeckle))
+	traceSpeckleEntry.OnChanged = func(text string) {
+		if speckle, err := strconv.Atoi(text); err == nil && speckle >= 0 {
+			config.TraceSpeckle = speckle
+		}
+	}
+
+	outputTypeLabel := widget.NewLabel("输出格式:")
+	// 列出所有已注册的输出格式
+	var outputTypes []string
+	for _, format := range pipeline.Writers() {
+		outputTypes = append(outputTypes, strings.ToUpper(format))
+	}
+	outputTypeSelect := widget.NewSelect(outputTypes, func(selected string) {
+		config.OutputType = strings.ToLower(selected)
+	})
+	outputTypeSelect.SetSelected(strings.ToUpper(config.OutputType))
+
+	// 状态标签和进度条
+	statusLabel := widget.NewLabel("就绪")
+	progressBar := widget.NewProgressBar()
+	progressBar.Hide()
+
+	// 逐文件状态列表，由处理进度事件更新
+	var statusMu sync.Mutex
+	var fileStatuses []string
+	fileList := widget.NewList(
+		func() int {
+			statusMu.Lock()
+			defer statusMu.Unlock()
+			return len(fileStatuses)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			statusMu.Lock()
+			defer statusMu.Unlock()
+			if id < len(fileStatuses) {
+				item.(*widget.Label).SetText(fileStatuses[id])
+			}
+		},
+	)
+	fileListBox := container.NewGridWrap(fyne.NewSize(610, 160), fileList)
+	fileListBox.Hide()
+
+	// 处理进度事件：更新进度条、状态标签和文件列表
+	var failedPages map[int]int
+	onProgress := func(e pipeline.Event) {
+		name := filepath.Base(e.File)
+		statusMu.Lock()
+		setStatus := func(text string) {
+			if e.Index > 0 && e.Index <= len(fileStatuses) {
+				fileStatuses[e.Index-1] = fmt.Sprintf("%d. %s  %s", e.Index, name, text)
+			}
+		}
+		switch e.Kind {
+		case pipeline.EventStart:
+			fileStatuses = make([]string, e.Total)
+			for i := range fileStatuses {
+				fileStatuses[i] = fmt.Sprintf("%d. 等待处理", i+1)
+			}
+			failedPages = make(map[int]int)
+		case pipeline.EventFileStart:
+			setStatus("处理中")
+		case pipeline.EventStage:
+			if e.Page > 0 {
+				setStatus(fmt.Sprintf("第%d页 %s", e.Page, e.Stage))
+			} else {
+				setStatus(e.Stage)
+			}
+		case pipeline.EventFailure:
+			failedPages[e.Index]++
+		case pipeline.EventFileDone:
+			switch failed := failedPages[e.Index]; {
+			case failed > 0 && e.Tiles == 0:
+				setStatus("失败")
+			case failed > 0:
+				setStatus(fmt.Sprintf("完成 %d 个图块，%d 项失败", e.Tiles, failed))
+			default:
+				setStatus(fmt.Sprintf("完成 %d 个图块", e.Tiles))
+			}
+		}
+		statusMu.Unlock()
+
+		progressBar.SetValue(e.Fraction())
+		switch e.Kind {
+		case pipeline.EventStart:
+			fileListBox.Show()
+			statusLabel.SetText(fmt.Sprintf("找到 %d 个PDF文件", e.Total))
+		case pipeline.EventFileStart:
+			statusLabel.SetText(fmt.Sprintf("正在处理 (%d/%d): %s", e.Index, e.Total, name))
+		case pipeline.EventSave:
+			statusLabel.SetText("正在保存输出...")
+		}
+		fileList.Refresh()
+	}
+
+	// 取消按钮，处理过程中可用
+	var cancelProcessing context.CancelFunc
+	cancelBtn := widget.NewButton("取消", func() {
+		if cancelProcessing != nil {
+			cancelProcessing()
+		}
+	})
+	cancelBtn.Disable()
+
+	// 处理按钮
+	var processBtn *widget.Button
+	processBtn = widget.NewButton("开始处理", func() {
+		if config.InputDir == "" {
+			dialog.ShowError(fmt.Errorf("请选择输入目录"), myWindow)
+			return
+		}
+		if config.OutputPath == "" {
+			dialog.ShowError(fmt.Errorf("请选择输出文件夹"), myWindow)
+			return
+		}
+		if err := config.Validate(); err != nil {
+			dialog.ShowError(err, myWindow)
+			return
+		}
+
+		// 异步处理，避免界面卡顿
+		processBtn.SetText("处理中...")
+		processBtn.Disable()
+		statusLabel.SetText("正在处理PDF文件...")
+		progressBar.SetValue(0)
+		progressBar.Show()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancelProcessing = func() {
+			cancel()
+			cancelBtn.Disable()
+			statusLabel.SetText("正在取消...")
+		}
+		cancelBtn.Enable()
+
+		go func() {
+			defer cancel()
+			extractor := pipeline.NewPDFExtractor(config)
+			extractor.OnProgress(onProgress)
+
+			// 首先检查目录和文件
+			statusLabel.SetText("正在扫描PDF文件...")
+
+			report, err := extractor.ProcessDirectory(ctx)
+
+			// 在主线程更新UI
+			processBtn.SetText("开始处理")
+			processBtn.Enable()
+			cancelBtn.Disable()
+			progressBar.Hide()
+
+			switch {
+			case errors.Is(err, context.Canceled):
+				statusLabel.SetText("已取消，未完成的输出已删除")
+			case err != nil:
+				statusLabel.SetText("处理失败")
+				dialog.ShowError(err, myWindow)
+				if len(report.Failures) > 0 {
+					showFailureReport(report, myWindow)
+				}
+			case len(report.Failures) > 0:
+				statusLabel.SetText(fmt.Sprintf("处理完成，%d 项失败", len(report.Failures)))
+				showFailureReport(report, myWindow)
+			default:
+				statusLabel.SetText("处理完成")
+				dialog.ShowInformation("成功", "拼接完成！文件已保存到输出文件夹。", myWindow)
+			}
+		}()
+	})
+
+	// 布局
+	content := container.NewVBox(
+		inputDirLabel,
+		container.NewBorder(nil, nil, desktopBtn, selectDirBtn, inputDirEntry),
+		widget.NewSeparator(),
+
+		outputPathLabel,
+		container.NewBorder(nil, nil, outputDesktopBtn, selectOutputBtn, outputPathEntry),
+		widget.NewSeparator(),
+
+		container.NewAppTabs(
+			container.NewTabItem("参数", container.NewVBox(
+				container.NewGridWithColumns(2,
+					boxSizeLabel, boxSizeEntry,
+					fitLabel, fitSelect,
+					filterLabel, filterSelect,
+					centerLabel, centerSelect,
+					alignXLabel, alignXSelect,
+					alignYLabel, alignYSelect,
+					renderDPILabel, renderDPIEntry,
+					outputDPILabel, outputDPIEntry,
+					concurrencyLabel, concurrencyEntry,
+					pageSelectionLabel, pageSelectionEntry,
+					outputTypeLabel, outputTypeSelect,
+					traceThresholdLabel, traceThresholdEntry,
+					traceCornerLabel, traceCornerEntry,
+					traceSpeckleLabel, traceSpeckleEntry,
+				),
+				traceCheck,
+			)),
+			container.NewTabItem("检测", container.NewVBox(
+				container.NewGridWithColumns(2,
+					detectorLabel, detectorSelect,
+					fallbackLabel, fallbackSelect,
+					detectThresholdLabel, detectThresholdEntry,
+					detectMarginLabel, detectMarginEntry,
+					mergeDistanceLabel, mergeDistanceEntry,
+					minAreaLabel, minAreaEntry,
+				),
+				segmentCheck,
+			)),
+			container.NewTabItem("排版", container.NewVBox(
+				container.NewGridWithColumns(2,
+					layouterLabel, layouterSelect,
+					columnsLabel, columnsEntry,
+					rowsLabel, rowsEntry,
+					fillLabel, fillSelect,
+					spacingLabel, spacingEntry,
+					columnSpacingLabel, columnSpacingEntry,
+					marginLabel, marginEntry,
+					backgroundLabel, backgroundEntry,
+					packWidthLabel, packWidthEntry,
+					atlasLabel, atlasSelect,
+					paperLabel, paperSelect,
+					paperMarginLabel, paperMarginEntry,
+				),
+				container.NewHBox(landscapeCheck, cropMarksCheck, pageNumbersCheck),
+			)),
+			container.NewTabItem("文件", container.NewVBox(
+				container.NewGridWithColumns(2,
+					includeLabel, includeEntry,
+					excludeLabel, excludeEntry,
+					sortLabel, sortSelect,
+					orderFileLabel, container.NewBorder(nil, nil, nil, orderFileBtn, orderFileEntry),
+				),
+				container.NewHBox(recursiveCheck, perDirCheck),
+			)),
+		),
+		widget.NewSeparator(),
+
+		statusLabel,
+		progressBar,
+		fileListBox,
+		container.NewGridWithColumns(2, processBtn, cancelBtn),
+	)
+
+	myWindow.SetContent(container.NewPadded(content))
+	myWindow.ShowAndRun()
+}
+
+// 拆分逗号分隔的匹配模式
+func splitPatterns(text string) []string {
+	var patterns []string
+	for _, p := range strings.Split(text, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			patterns = append(patterns, p)
+		}
+	}
+	return patterns
+}
@@ -0,0 +1,10 @@
+//go:build !nogui
+
+package main
+
+import "pdf-vector-extractor/gui"
+
+// 启动图形界面
+func runGUI() {
+	gui.Run()
+}
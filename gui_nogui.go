@@ -0,0 +1,15 @@
+//go:build nogui
+
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// 无图形界面的构建（-tags nogui），用于没有显示环境的服务器
+func runGUI() {
+	fmt.Fprintln(os.Stderr, "此版本不包含图形界面，请使用命令行子命令运行")
+	printCLIUsage(os.Stderr)
+	os.Exit(exitUsage)
+}
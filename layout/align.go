@@ -0,0 +1,183 @@
+package layout
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+
+	"pdf-vector-extractor/detect"
+)
+
+// 内容的中心点：对齐到方框中心的参考点
+const (
+	CenterBounds   = "bbox"     // 内容外接矩形的中点
+	CenterCentroid = "centroid" // 按墨量加权的重心，长尾、下伸部分不会使内容看起来偏移
+	CenterBaseline = "baseline" // 所有图块的基线对齐到同一高度，适合文字类设计
+)
+
+// 内容在方框中的水平和垂直对齐位置
+const (
+	AlignLeft   = "left"
+	AlignCenter = "center"
+	AlignRight  = "right"
+
+	AlignTop    = "top"
+	AlignMiddle = "middle"
+	AlignBottom = "bottom"
+)
+
+// 对齐参数
+type AlignOptions struct {
+	Center     string // 中心点: bbox、centroid、baseline，空为bbox
+	Horizontal string // 水平对齐: left、center、right，空为center
+	Vertical   string // 垂直对齐: top、middle、bottom，空为middle；基线对齐时决定基线所在高度
+	Padding    int    // 靠边对齐时内容与方框边缘的距离 (像素)
+}
+
+// 检查中心点和对齐方式
+func (o AlignOptions) Validate() error {
+	switch o.Center {
+	case "", CenterBounds, CenterCentroid, CenterBaseline:
+	default:
+		return fmt.Errorf("未知的中心点: %s（可选 %s、%s、%s）", o.Center, CenterBounds, CenterCentroid, CenterBaseline)
+	}
+	switch o.Horizontal {
+	case "", AlignLeft, AlignCenter, AlignRight:
+	default:
+		return fmt.Errorf("未知的水平对齐方式: %s（可选 %s、%s、%s）", o.Horizontal, AlignLeft, AlignCenter, AlignRight)
+	}
+	switch o.Vertical {
+	case "", AlignTop, AlignMiddle, AlignBottom:
+	default:
+		return fmt.Errorf("未知的垂直对齐方式: %s（可选 %s、%s、%s）", o.Vertical, AlignTop, AlignMiddle, AlignBottom)
+	}
+	if o.Padding < 0 {
+		return fmt.Errorf("对齐边距不能为负数: %d", o.Padding)
+	}
+	return nil
+}
+
+// 外接矩形居中时与裁剪结果相同，不需要测量和移动
+func (o AlignOptions) Identity() bool {
+	return (o.Center == "" || o.Center == CenterBounds) &&
+		(o.Horizontal == "" || o.Horizontal == AlignCenter) &&
+		(o.Vertical == "" || o.Vertical == AlignMiddle)
+}
+
+// 方框box中的内容ink按中心点和对齐方式应移动的距离，内容始终保持在方框内
+// 基线对齐需要所有图块的共同基线，这里先按外接矩形对齐，由Align移动到共同基线
+func (o AlignOptions) Offset(ink detect.Ink, box image.Rectangle) image.Point {
+	ref := image.Pt((ink.Bounds.Min.X+ink.Bounds.Max.X)/2, (ink.Bounds.Min.Y+ink.Bounds.Max.Y)/2)
+	if o.Center == CenterCentroid {
+		ref = ink.Centroid
+	}
+
+	var d image.Point
+	switch o.Horizontal {
+	case AlignLeft:
+		d.X = box.Min.X + o.Padding - ink.Bounds.Min.X
+	case AlignRight:
+		d.X = box.Max.X - o.Padding - ink.Bounds.Max.X
+	default:
+		d.X = (box.Min.X+box.Max.X)/2 - ref.X
+	}
+	switch o.Vertical {
+	case AlignTop:
+		d.Y = box.Min.Y + o.Padding - ink.Bounds.Min.Y
+	case AlignBottom:
+		d.Y = box.Max.Y - o.Padding - ink.Bounds.Max.Y
+	default:
+		d.Y = (box.Min.Y+box.Max.Y)/2 - ref.Y
+	}
+	return ClampShift(d, ink.Bounds, box)
+}
+
+// 限制移动距离d，使ink移动后不超出box；ink比box大时使ink始终盖住box
+func ClampShift(d image.Point, ink, box image.Rectangle) image.Point {
+	return image.Pt(
+		clampBetween(d.X, box.Min.X-ink.Min.X, box.Max.X-ink.Max.X),
+		clampBetween(d.Y, box.Min.Y-ink.Min.Y, box.Max.Y-ink.Max.Y),
+	)
+}
+
+// 把v限制在a和b之间，a和b的大小顺序不限
+func clampBetween(v, a, b int) int {
+	return max(min(v, max(a, b)), min(a, b))
+}
+
+// 基线对齐时把所有图块的基线移动到同一高度，返回移动后的图块；其他中心点已在裁剪时对齐，不做改动
+// 共同基线的高度取能放下最高的基线以上部分和最深的下伸部分；没有测量内容的图块不移动
+func Align(tiles []Tile, opts AlignOptions) []Tile {
+	if opts.Center != CenterBaseline {
+		return tiles
+	}
+	ascent, descent := 0, 0
+	for _, tile := range tiles {
+		if tile.Ink != nil {
+			ascent = max(ascent, tile.Ink.Baseline-tile.Ink.Bounds.Min.Y)
+			descent = max(descent, tile.Ink.Bounds.Max.Y-tile.Ink.Baseline)
+		}
+	}
+
+	aligned := make([]Tile, len(tiles))
+	for i, tile := range tiles {
+		if tile.Ink == nil {
+			aligned[i] = tile
+			continue
+		}
+		b := tile.Image.Bounds()
+		line := b.Min.Y + (b.Dy()-ascent-descent)/2 + ascent
+		switch opts.Vertical {
+		case AlignTop:
+			line = b.Min.Y + opts.Padding + ascent
+		case AlignBottom:
+			line = b.Max.Y - opts.Padding - descent
+		}
+		d := ClampShift(image.Pt(0, line-tile.Ink.Baseline), tile.Ink.Bounds, b)
+		aligned[i] = tile.shift(d)
+	}
+	return aligned
+}
+
+// 把图块的裁剪区域在方框中移动d，移出方框的部分不再可见
+// 移开后露出的部分在裁剪区域以外，设为透明，拼接时与矢量输出一样透出拼接结果的背景
+func (t Tile) shift(d image.Point) Tile {
+	if d == (image.Point{}) {
+		return t
+	}
+	b := t.Image.Bounds()
+	visible := t.VisibleRect().Add(b.Min)
+	img := image.NewRGBA(b)
+	draw.Draw(img, b, t.Image, b.Min, draw.Src)
+	draw.Draw(img, visible, image.Transparent, image.Point{}, draw.Src)
+	draw.Draw(img, visible.Add(d), t.Image, visible.Min, draw.Src)
+
+	ink := *t.Ink
+	ink.Bounds = ink.Bounds.Add(d)
+	ink.Centroid = ink.Centroid.Add(d)
+	ink.Baseline += d.Y
+	t.Ink = &ink
+	t.Image = img
+	t.Offset = t.Offset.Add(d)
+	return t
+}
+
+// 把在渲染图像中测量的内容分布映射到图块方框中
+func (t Tile) MapInk(ink detect.Ink) detect.Ink {
+	size := t.Size
+	if size == (image.Point{}) {
+		size = t.CropRect.Size()
+	}
+	mapX := func(x int) int {
+		return t.Offset.X + int(math.Round(float64(x-t.CropRect.Min.X)*float64(size.X)/float64(t.CropRect.Dx())))
+	}
+	mapY := func(y int) int {
+		return t.Offset.Y + int(math.Round(float64(y-t.CropRect.Min.Y)*float64(size.Y)/float64(t.CropRect.Dy())))
+	}
+	return detect.Ink{
+		Bounds:   image.Rect(mapX(ink.Bounds.Min.X), mapY(ink.Bounds.Min.Y), mapX(ink.Bounds.Max.X), mapY(ink.Bounds.Max.Y)),
+		Centroid: image.Pt(mapX(ink.Centroid.X), mapY(ink.Centroid.Y)),
+		Baseline: mapY(ink.Baseline),
+	}
+}
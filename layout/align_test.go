@@ -0,0 +1,96 @@
+package layout
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"pdf-vector-extractor/detect"
+)
+
+func TestAlignOffset(t *testing.T) {
+	box := image.Rect(0, 0, 100, 100)
+	ink := detect.Ink{Bounds: image.Rect(30, 20, 50, 60), Centroid: image.Pt(35, 30), Baseline: 50}
+	tests := []struct {
+		name string
+		opts AlignOptions
+		want image.Point
+	}{
+		{"外接矩形居中", AlignOptions{}, image.Pt(10, 10)},
+		{"墨迹重心", AlignOptions{Center: CenterCentroid}, image.Pt(15, 20)},
+		{"左上", AlignOptions{Horizontal: AlignLeft, Vertical: AlignTop, Padding: 5}, image.Pt(-25, -15)},
+		{"右下", AlignOptions{Horizontal: AlignRight, Vertical: AlignBottom, Padding: 5}, image.Pt(45, 35)},
+		// 不把内容移出方框
+		{"重心靠边", AlignOptions{Center: CenterCentroid, Horizontal: AlignRight, Padding: -10}, image.Pt(50, 20)},
+	}
+	for _, tt := range tests {
+		if got := tt.opts.Offset(ink, box); got != tt.want {
+			t.Errorf("%s: Offset = %v, 期望 %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestClampShift(t *testing.T) {
+	box := image.Rect(0, 50, 200, 150)
+	tests := []struct {
+		name string
+		d    image.Point
+		ink  image.Rectangle
+		want image.Point
+	}{
+		{"方框内", image.Pt(10, -10), image.Rect(50, 80, 150, 120), image.Pt(10, -10)},
+		{"超出上边", image.Pt(0, -50), image.Rect(50, 80, 150, 120), image.Pt(0, -30)},
+		{"超出右边", image.Pt(80, 0), image.Rect(50, 80, 150, 120), image.Pt(50, 0)},
+		// 内容比方框宽时内容始终盖住方框
+		{"内容较宽", image.Pt(-50, 0), image.Rect(-20, 80, 220, 120), image.Pt(-20, 0)},
+		{"内容较宽居中", image.Pt(5, 0), image.Rect(-20, 80, 220, 120), image.Pt(5, 0)},
+	}
+	for _, tt := range tests {
+		if got := ClampShift(tt.d, tt.ink, box); got != tt.want {
+			t.Errorf("%s: ClampShift = %v, 期望 %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAlignBaselineExposesBackground(t *testing.T) {
+	beige := color.RGBA{230, 220, 190, 255}
+	tile := func(top, baseline int) Tile {
+		img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+		draw.Draw(img, img.Bounds(), &image.Uniform{beige}, image.Point{}, draw.Src)
+		ink := image.Rect(40, top, 60, baseline)
+		draw.Draw(img, ink, &image.Uniform{color.Black}, image.Point{}, draw.Src)
+		return Tile{
+			Image:    img,
+			CropRect: image.Rect(0, 0, 100, 100),
+			Ink:      &detect.Ink{Bounds: ink, Centroid: image.Pt(50, (top+baseline)/2), Baseline: baseline},
+		}
+	}
+	tiles := Align([]Tile{tile(10, 30), tile(50, 80)}, AlignOptions{Center: CenterBaseline})
+	if tiles[0].Ink.Baseline != tiles[1].Ink.Baseline {
+		t.Fatalf("基线 %d 和 %d 不同", tiles[0].Ink.Baseline, tiles[1].Ink.Baseline)
+	}
+
+	// 移动后露出的部分在可见区域以外，拼接结果中与矢量输出一样为背景颜色
+	bg := color.RGBA{0, 0, 255, 255}
+	sheet := Grid(tiles, 100, GridOptions{Columns: 2, Background: bg})
+	for i, tile := range tiles {
+		if tile.VisibleRect() == image.Rect(0, 0, 100, 100) {
+			t.Errorf("图块 %d 没有移动", i)
+		}
+		for y := 0; y < 100; y++ {
+			p := image.Pt(50, y)
+			want := bg
+			switch {
+			case p.In(tile.Ink.Bounds):
+				want = color.RGBA{0, 0, 0, 255}
+			case p.In(tile.VisibleRect()):
+				want = beige
+			}
+			if got := sheet.Image.RGBAAt(sheet.Cells[i].Min.X+p.X, sheet.Cells[i].Min.Y+p.Y); got != want {
+				t.Errorf("图块 %d (50,%d) = %v, 期望 %v", i, y, got, want)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package layout
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// 网格填充顺序
+const (
+	FillRowMajor    = "row"    // 先填满一行再换行
+	FillColumnMajor = "column" // 先填满一列再换列
+)
+
+// 白色背景
+var White = color.RGBA{255, 255, 255, 255}
+
+// 网格排版参数
+type GridOptions struct {
+	Columns    int    // 列数，大于0时固定列数
+	Rows       int    // 行数，列数为0且行数大于0时固定行数，如1为横向一行
+	Fill       string // 填充顺序: row、column，空为row
+	SpacingX   int    // 列间距 (像素)
+	SpacingY   int    // 行间距 (像素)
+	Margin     int    // 外边距 (像素)
+	Background color.RGBA
+}
+
+// 行数和列数，列数和行数都未指定时取接近正方形的网格
+// 按填充顺序去掉用不到的行列：先列后行时每列填满rows个，列数由行数得出，反之亦然
+func (o GridOptions) dimensions(n int) (columns, rows int) {
+	switch {
+	case n == 0:
+		return 0, 0
+	case o.Columns > 0:
+		columns = min(o.Columns, n)
+		rows = (n + columns - 1) / columns
+	case o.Rows > 0:
+		rows = min(o.Rows, n)
+		columns = (n + rows - 1) / rows
+	default:
+		columns = int(math.Ceil(math.Sqrt(float64(n))))
+		rows = (n + columns - 1) / columns
+	}
+	if o.Fill == FillColumnMajor {
+		return (n + rows - 1) / rows, rows
+	}
+	return columns, (n + columns - 1) / columns
+}
+
+// 将图块排列成网格，每个图块占一个boxSize见方的单元格
+func Grid(tiles []Tile, boxSize int, opts GridOptions) *Sheet {
+	columns, rows := opts.dimensions(len(tiles))
+	width := 2*opts.Margin + columns*(boxSize+opts.SpacingX) - opts.SpacingX
+	height := 2*opts.Margin + rows*(boxSize+opts.SpacingY) - opts.SpacingY
+	if len(tiles) == 0 {
+		width, height = 2*opts.Margin, 2*opts.Margin
+	}
+	combinedImg := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	// 填充背景
+	draw.Draw(combinedImg, combinedImg.Bounds(), &image.Uniform{opts.Background}, image.Point{}, draw.Src)
+
+	// 逐个绘制图像
+	sheet := &Sheet{Image: combinedImg, Tiles: tiles, Background: opts.Background}
+	for i, tile := range tiles {
+		col, row := i%columns, i/columns
+		if opts.Fill == FillColumnMajor {
+			col, row = i/rows, i%rows
+		}
+		x := opts.Margin + col*(boxSize+opts.SpacingX)
+		y := opts.Margin + row*(boxSize+opts.SpacingY)
+		dstRect := image.Rect(x, y, x+boxSize, y+boxSize)
+		draw.Draw(combinedImg, dstRect, tile.Image, image.Point{}, draw.Over)
+		sheet.Cells = append(sheet.Cells, dstRect)
+	}
+	return sheet
+}
+
+// 解析背景颜色: #rrggbb、#rrggbbaa、#rgb、white、black，none或transparent为透明
+func ParseColor(s string) (color.RGBA, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch s {
+	case "", "white":
+		return White, nil
+	case "black":
+		return color.RGBA{0, 0, 0, 255}, nil
+	case "none", "transparent":
+		return color.RGBA{}, nil
+	}
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if len(hex) != 8 || err != nil {
+		return color.RGBA{}, fmt.Errorf("无效的颜色: %s（应为 #rrggbb、#rrggbbaa 或 none）", s)
+	}
+	// 使用预乘透明度的RGBA
+	a := uint32(v & 0xff)
+	premul := func(c uint32) uint8 { return uint8(c * a / 255) }
+	return color.RGBA{premul(uint32(v >> 24)), premul(uint32(v >> 16 & 0xff)), premul(uint32(v >> 8 & 0xff)), uint8(a)}, nil
+}
@@ -0,0 +1,121 @@
+package layout
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// n个boxSize见方的纯色图块
+func gridTiles(n, boxSize int, c color.RGBA) []Tile {
+	tiles := make([]Tile, n)
+	for i := range tiles {
+		img := image.NewRGBA(image.Rect(0, 0, boxSize, boxSize))
+		for j := 0; j < len(img.Pix); j += 4 {
+			img.Pix[j], img.Pix[j+1], img.Pix[j+2], img.Pix[j+3] = c.R, c.G, c.B, c.A
+		}
+		tiles[i] = Tile{Image: img, CropRect: img.Bounds()}
+	}
+	return tiles
+}
+
+func TestGridDimensions(t *testing.T) {
+	tests := []struct {
+		n             int
+		opts          GridOptions
+		columns, rows int
+	}{
+		{0, GridOptions{}, 0, 0},
+		{5, GridOptions{}, 3, 2},
+		{10, GridOptions{}, 4, 3},
+		{3, GridOptions{Columns: 1}, 1, 3},
+		{3, GridOptions{Columns: 5}, 3, 1},
+		{5, GridOptions{Columns: 4}, 4, 2},
+		{5, GridOptions{Rows: 1}, 5, 1},
+		{5, GridOptions{Rows: 2}, 3, 2},
+		// 先列后行时每列填满，列数由行数得出，不留空列
+		{5, GridOptions{Columns: 4, Fill: FillColumnMajor}, 3, 2},
+		{7, GridOptions{Columns: 3, Fill: FillColumnMajor}, 3, 3},
+		{10, GridOptions{Columns: 6, Fill: FillColumnMajor}, 5, 2},
+		{5, GridOptions{Rows: 2, Fill: FillColumnMajor}, 3, 2},
+		// 先行后列时每行填满，行数由列数得出，不留空行
+		{5, GridOptions{Rows: 4}, 2, 3},
+	}
+	for _, tt := range tests {
+		columns, rows := tt.opts.dimensions(tt.n)
+		if columns != tt.columns || rows != tt.rows {
+			t.Errorf("%d 个图块 %+v: %d 列 %d 行, 期望 %d 列 %d 行", tt.n, tt.opts, columns, rows, tt.columns, tt.rows)
+		}
+		// 按填充顺序放入所有图块后，每行每列都有图块
+		if tt.n == 0 {
+			continue
+		}
+		sheet := Grid(gridTiles(tt.n, 1, White), 1, tt.opts)
+		used := image.Rectangle{}
+		for _, cell := range sheet.Cells {
+			used = used.Union(cell)
+		}
+		if used != sheet.Image.Bounds() || used != image.Rect(0, 0, columns, rows) {
+			t.Errorf("%d 个图块 %+v: 单元格范围 %v, 拼接结果 %v", tt.n, tt.opts, used, sheet.Image.Bounds())
+		}
+	}
+}
+
+func TestGridCells(t *testing.T) {
+	opts := GridOptions{Columns: 4, Fill: FillColumnMajor, SpacingX: 5, SpacingY: 3, Margin: 2, Background: White}
+	sheet := Grid(gridTiles(5, 10, color.RGBA{0, 0, 0, 255}), 10, opts)
+	// 3列2行：宽 2+3*15-5+2，高 2+2*13-3+2
+	if b := sheet.Image.Bounds(); b != image.Rect(0, 0, 44, 27) {
+		t.Fatalf("拼接结果大小 %v", b)
+	}
+	want := []image.Point{{2, 2}, {2, 15}, {17, 2}, {17, 15}, {32, 2}}
+	for i, p := range want {
+		if sheet.Cells[i].Min != p || sheet.Cells[i].Size() != image.Pt(10, 10) {
+			t.Errorf("第%d个单元格 %v, 期望从 %v 开始", i, sheet.Cells[i], p)
+		}
+	}
+	// 最后一列只有一个图块，其下方为背景
+	if got := sheet.Image.RGBAAt(35, 20); got != White {
+		t.Errorf("空单元格颜色 %v, 期望白色", got)
+	}
+	if got := sheet.Image.RGBAAt(35, 5); got != (color.RGBA{0, 0, 0, 255}) {
+		t.Errorf("图块颜色 %v, 期望黑色", got)
+	}
+}
+
+func TestGridTransparentTiles(t *testing.T) {
+	// 透明图块不遮挡背景
+	bg := color.RGBA{0, 0, 128, 128}
+	sheet := Grid(gridTiles(2, 10, color.RGBA{}), 10, GridOptions{Columns: 2, Background: bg})
+	for _, p := range []image.Point{{5, 5}, {15, 5}} {
+		if got := sheet.Image.RGBAAt(p.X, p.Y); got != bg {
+			t.Errorf("(%d, %d) 颜色 %v, 期望背景 %v", p.X, p.Y, got, bg)
+		}
+	}
+}
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		in   string
+		want color.RGBA
+	}{
+		{"white", White},
+		{"#000", color.RGBA{0, 0, 0, 255}},
+		{"#ff8000", color.RGBA{255, 128, 0, 255}},
+		{"none", color.RGBA{}},
+		{"transparent", color.RGBA{}},
+		// 半透明颜色按预乘保存
+		{"#ff000080", color.RGBA{128, 0, 0, 128}},
+	}
+	for _, tt := range tests {
+		got, err := ParseColor(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseColor(%q) = %v, %v, 期望 %v", tt.in, got, err, tt.want)
+		}
+	}
+	for _, in := range []string{"#12", "red", "#gggggg"} {
+		if _, err := ParseColor(in); err == nil {
+			t.Errorf("ParseColor(%q) 期望错误", in)
+		}
+	}
+}
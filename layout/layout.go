@@ -0,0 +1,7 @@
+// Package layout 将裁剪后的图块排列到拼接结果中
+package layout
+
+// 将图块纵向排列到拼接结果中，每个图块占一个boxSize见方的单元格
+func Arrange(tiles []Tile, boxSize, spacing int) *Sheet {
+	return Grid(tiles, boxSize, GridOptions{Columns: 1, SpacingY: spacing, Background: White})
+}
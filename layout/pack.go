@@ -0,0 +1,150 @@
+package layout
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"sort"
+)
+
+// 紧密排版参数
+type PackOptions struct {
+	Width      int // 拼接结果宽度，0表示按图块总面积自动取接近正方形的宽度
+	SpacingX   int // 图块之间的横向间距
+	SpacingY   int // 图块之间的纵向间距
+	Margin     int
+	Background color.RGBA
+}
+
+// 按图块可见内容的实际大小紧密排列（MaxRects，左下优先），适合宽高比各异的图块
+// 只绘制每个图块的可见区域，单元格按可见区域定位，可能相互重叠
+func Pack(tiles []Tile, opts PackOptions) *Sheet {
+	sizes := make([]image.Point, len(tiles))
+	area, widest := 0, 0
+	for i, tile := range tiles {
+		sizes[i] = tile.VisibleRect().Size()
+		area += (sizes[i].X + opts.SpacingX) * (sizes[i].Y + opts.SpacingY)
+		widest = max(widest, sizes[i].X)
+	}
+
+	// 内部宽度加上一个间距，使最右侧图块之后的间距不占用宽度
+	width := opts.Width - 2*opts.Margin
+	if opts.Width <= 0 {
+		width = int(math.Ceil(math.Sqrt(float64(area))))
+	} else {
+		width += opts.SpacingX
+	}
+	width = max(width, widest+opts.SpacingX)
+
+	// 按高度从大到小依次放入，排序不影响图块在结果中的顺序
+	order := make([]int, len(tiles))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		sa, sb := sizes[order[a]], sizes[order[b]]
+		if sa.Y != sb.Y {
+			return sa.Y > sb.Y
+		}
+		return sa.X > sb.X
+	})
+
+	bin := newMaxRects(width)
+	placed := make([]image.Point, len(tiles))
+	right, height := 0, 0
+	for _, i := range order {
+		if sizes[i].X == 0 || sizes[i].Y == 0 {
+			continue
+		}
+		pos := bin.insert(sizes[i].X+opts.SpacingX, sizes[i].Y+opts.SpacingY)
+		placed[i] = pos
+		right = max(right, pos.X+sizes[i].X)
+		height = max(height, pos.Y+sizes[i].Y)
+	}
+
+	// 自动宽度时去掉右侧未使用的部分
+	sheetW := 2*opts.Margin + right
+	if opts.Width > 0 {
+		sheetW = max(opts.Width, sheetW)
+	}
+	combinedImg := image.NewRGBA(image.Rect(0, 0, sheetW, 2*opts.Margin+height))
+	draw.Draw(combinedImg, combinedImg.Bounds(), &image.Uniform{opts.Background}, image.Point{}, draw.Src)
+
+	sheet := &Sheet{Image: combinedImg, Tiles: tiles, Background: opts.Background}
+	margin := image.Pt(opts.Margin, opts.Margin)
+	for i, tile := range tiles {
+		// 单元格的偏移使可见区域正好落在放置位置
+		at := placed[i].Add(margin)
+		origin := at.Sub(tile.VisibleRect().Min)
+		cell := image.Rectangle{Min: origin, Max: origin.Add(tile.Image.Bounds().Size())}
+		visible := image.Rectangle{Min: at, Max: at.Add(sizes[i])}
+		draw.Draw(combinedImg, visible, tile.Image, tile.Image.Bounds().Min.Add(tile.VisibleRect().Min), draw.Over)
+		sheet.Cells = append(sheet.Cells, cell)
+	}
+	return sheet
+}
+
+// MaxRects装箱：宽度固定、高度不限，记录所有极大空闲矩形
+type maxRects struct {
+	free []image.Rectangle
+}
+
+func newMaxRects(width int) *maxRects {
+	return &maxRects{free: []image.Rectangle{image.Rect(0, 0, width, math.MaxInt32)}}
+}
+
+// 放入w×h的矩形，返回左上角位置；选择底边最高、其次最靠左的位置
+func (m *maxRects) insert(w, h int) image.Point {
+	best, found := image.Point{}, false
+	bestBottom := math.MaxInt
+	for _, r := range m.free {
+		if r.Dx() < w || r.Dy() < h {
+			continue
+		}
+		bottom := r.Min.Y + h
+		if !found || bottom < bestBottom || (bottom == bestBottom && r.Min.X < best.X) {
+			best, bestBottom, found = r.Min, bottom, true
+		}
+	}
+	used := image.Rectangle{Min: best, Max: best.Add(image.Pt(w, h))}
+	m.split(used)
+	return best
+}
+
+// 从空闲矩形中扣除已用区域，并去掉被其他空闲矩形包含的矩形
+func (m *maxRects) split(used image.Rectangle) {
+	var next []image.Rectangle
+	for _, r := range m.free {
+		if !r.Overlaps(used) {
+			next = append(next, r)
+			continue
+		}
+		if used.Min.X > r.Min.X {
+			next = append(next, image.Rect(r.Min.X, r.Min.Y, used.Min.X, r.Max.Y))
+		}
+		if used.Max.X < r.Max.X {
+			next = append(next, image.Rect(used.Max.X, r.Min.Y, r.Max.X, r.Max.Y))
+		}
+		if used.Min.Y > r.Min.Y {
+			next = append(next, image.Rect(r.Min.X, r.Min.Y, r.Max.X, used.Min.Y))
+		}
+		if used.Max.Y < r.Max.Y {
+			next = append(next, image.Rect(r.Min.X, used.Max.Y, r.Max.X, r.Max.Y))
+		}
+	}
+
+	m.free = m.free[:0]
+	for i, r := range next {
+		contained := false
+		for j, other := range next {
+			if i != j && r.In(other) && (r != other || j < i) {
+				contained = true
+				break
+			}
+		}
+		if !contained {
+			m.free = append(m.free, r)
+		}
+	}
+}
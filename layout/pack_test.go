@@ -0,0 +1,114 @@
+package layout
+
+import (
+	"image"
+	"math/rand"
+	"testing"
+)
+
+// 生成可见区域大小各异的图块
+func packTiles(n int, seed int64) []Tile {
+	r := rand.New(rand.NewSource(seed))
+	const box = 200
+	tiles := make([]Tile, n)
+	for i := range tiles {
+		size := image.Pt(1+r.Intn(box), 1+r.Intn(box))
+		offset := image.Pt((box-size.X)/2, (box-size.Y)/2)
+		tiles[i] = Tile{
+			Image:    image.NewRGBA(image.Rect(0, 0, box, box)),
+			CropRect: image.Rectangle{Max: size},
+			Offset:   offset,
+		}
+	}
+	return tiles
+}
+
+// 检查每个图块的可见区域都在结果内、在外边距之内、互不重叠（含间距）
+func checkPacked(t *testing.T, sheet *Sheet, opts PackOptions) {
+	t.Helper()
+	bounds := sheet.Image.Bounds()
+	inner := bounds.Inset(opts.Margin)
+	placed := make([]image.Rectangle, len(sheet.Tiles))
+	for i, tile := range sheet.Tiles {
+		r := tile.VisibleRect().Add(sheet.Cells[i].Min)
+		if r.Size() != tile.VisibleRect().Size() || r.Empty() {
+			t.Fatalf("图块 %d 的可见区域大小改变: %v", i, r)
+		}
+		if !r.In(inner) {
+			t.Errorf("图块 %d 位于 %v，超出 %v", i, r, inner)
+		}
+		placed[i] = image.Rectangle{Min: r.Min, Max: r.Max.Add(image.Pt(opts.SpacingX, opts.SpacingY))}
+	}
+	for i := range placed {
+		for j := i + 1; j < len(placed); j++ {
+			if placed[i].Overlaps(placed[j]) {
+				t.Errorf("图块 %d %v 与 %d %v 重叠", i, placed[i], j, placed[j])
+			}
+		}
+	}
+}
+
+func TestPackInvariants(t *testing.T) {
+	tests := []struct {
+		name  string
+		n     int
+		seed  int64
+		opts  PackOptions
+		width int // 期望的结果宽度，0为不检查
+	}{
+		{"自动宽度", 40, 1, PackOptions{}, 0},
+		{"间距和外边距", 40, 2, PackOptions{SpacingX: 3, SpacingY: 5, Margin: 7}, 0},
+		{"固定宽度", 60, 3, PackOptions{Width: 500, SpacingX: 2, SpacingY: 2, Margin: 4}, 500},
+		{"宽度小于最宽图块", 10, 4, PackOptions{Width: 50}, 0},
+		{"单个图块", 1, 5, PackOptions{Margin: 10}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tiles := packTiles(tt.n, tt.seed)
+			sheet := Pack(tiles, tt.opts)
+			if len(sheet.Cells) != len(tiles) {
+				t.Fatalf("单元格数 %d, 期望 %d", len(sheet.Cells), len(tiles))
+			}
+			checkPacked(t, sheet, tt.opts)
+			if tt.width > 0 && sheet.Image.Bounds().Dx() != tt.width {
+				t.Errorf("结果宽度 %d, 期望 %d", sheet.Image.Bounds().Dx(), tt.width)
+			}
+		})
+	}
+}
+
+func TestPackAutoWidthTrimmed(t *testing.T) {
+	tiles := packTiles(30, 6)
+	opts := PackOptions{Margin: 3}
+	sheet := Pack(tiles, opts)
+	// 自动宽度时右侧不留未使用的部分
+	right := 0
+	for i, tile := range sheet.Tiles {
+		right = max(right, tile.VisibleRect().Add(sheet.Cells[i].Min).Max.X)
+	}
+	if got := sheet.Image.Bounds().Dx(); got != right+opts.Margin {
+		t.Errorf("结果宽度 %d, 期望 %d", got, right+opts.Margin)
+	}
+}
+
+func TestMaxRectsInsert(t *testing.T) {
+	bin := newMaxRects(100)
+	var placed []image.Rectangle
+	for _, size := range []image.Point{{60, 40}, {40, 40}, {100, 10}, {30, 30}, {70, 20}, {100, 100}} {
+		pos := bin.insert(size.X, size.Y)
+		r := image.Rectangle{Min: pos, Max: pos.Add(size)}
+		if r.Min.X < 0 || r.Min.Y < 0 || r.Max.X > 100 {
+			t.Errorf("%v 超出宽度 100", r)
+		}
+		for _, p := range placed {
+			if p.Overlaps(r) {
+				t.Errorf("%v 与 %v 重叠", r, p)
+			}
+		}
+		placed = append(placed, r)
+	}
+	// 前两个矩形并排放在第一行
+	if placed[1].Min != image.Pt(60, 0) {
+		t.Errorf("第二个矩形位于 %v, 期望 (60,0)", placed[1].Min)
+	}
+}
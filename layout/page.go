@@ -0,0 +1,200 @@
+package layout
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// 页面上的标记线，如裁切线；坐标为拼接结果像素
+type Line struct {
+	From, To image.Point
+	Width    float64 // 线宽 (像素)
+}
+
+// 页面上的文字，如页码；At为文字基线的中点，Size为字号 (像素)
+type Label struct {
+	Text string
+	At   image.Point
+	Size float64
+}
+
+// 标记线和文字的颜色
+var MarkColor = color.RGBA{0, 0, 0, 255}
+
+// 分页排版参数，单位均为像素
+type PageOptions struct {
+	Width, Height int // 页面尺寸
+	Margin        int // 页边距，裁切线和页码画在页边距中
+	SpacingX      int
+	SpacingY      int
+	Fill          string // 填充顺序: row、column，空为row
+	Background    color.RGBA
+
+	CropMarks   bool    // 在页边距中对齐每行每列的边缘绘制裁切线
+	MarkLength  int     // 裁切线长度
+	MarkGap     int     // 裁切线与图块之间的距离
+	LineWidth   float64 // 裁切线宽度
+	PageNumbers bool    // 在下边距中居中绘制页码
+	LabelSize   float64 // 页码字号
+}
+
+// 按页面尺寸分页排列图块，每页在可打印区域内排成居中的网格，排满后自动换页
+func Paginate(tiles []Tile, boxSize int, opts PageOptions) ([]*Sheet, error) {
+	areaW := opts.Width - 2*opts.Margin
+	areaH := opts.Height - 2*opts.Margin
+	columns := (areaW + opts.SpacingX) / (boxSize + opts.SpacingX)
+	rows := (areaH + opts.SpacingY) / (boxSize + opts.SpacingY)
+	if columns < 1 || rows < 1 {
+		return nil, fmt.Errorf("裁剪框 %d 像素超出页面可打印区域 %d x %d 像素", boxSize, max(areaW, 0), max(areaH, 0))
+	}
+
+	// 网格在可打印区域内居中
+	gridW := columns*(boxSize+opts.SpacingX) - opts.SpacingX
+	gridH := rows*(boxSize+opts.SpacingY) - opts.SpacingY
+	left := opts.Margin + (areaW-gridW)/2
+	top := opts.Margin + (areaH-gridH)/2
+
+	perPage := columns * rows
+	pageCount := max((len(tiles)+perPage-1)/perPage, 1)
+	sheets := make([]*Sheet, 0, pageCount)
+	for page := 0; page < pageCount; page++ {
+		pageTiles := tiles[page*perPage : min((page+1)*perPage, len(tiles))]
+		img := image.NewRGBA(image.Rect(0, 0, opts.Width, opts.Height))
+		draw.Draw(img, img.Bounds(), &image.Uniform{opts.Background}, image.Point{}, draw.Src)
+
+		sheet := &Sheet{Image: img, Tiles: pageTiles, Background: opts.Background, Page: page + 1, PageCount: pageCount}
+		for i, tile := range pageTiles {
+			col, row := i%columns, i/columns
+			if opts.Fill == FillColumnMajor {
+				col, row = i/rows, i%rows
+			}
+			x := left + col*(boxSize+opts.SpacingX)
+			y := top + row*(boxSize+opts.SpacingY)
+			dstRect := image.Rect(x, y, x+boxSize, y+boxSize)
+			draw.Draw(img, dstRect, tile.Image, image.Point{}, draw.Over)
+			sheet.Cells = append(sheet.Cells, dstRect)
+		}
+
+		if opts.CropMarks {
+			sheet.Lines = cropMarks(left, top, columns, rows, boxSize, opts)
+		}
+		if opts.PageNumbers {
+			sheet.Labels = append(sheet.Labels, Label{
+				Text: fmt.Sprintf("%d / %d", page+1, pageCount),
+				At:   image.Pt(opts.Width/2, opts.Height-opts.Margin/2+int(opts.LabelSize/3)),
+				Size: opts.LabelSize,
+			})
+		}
+		drawMarks(img, sheet)
+		sheets = append(sheets, sheet)
+	}
+	return sheets, nil
+}
+
+// 对齐每列左右边缘和每行上下边缘的裁切线，画在网格外侧的页边距中
+func cropMarks(left, top, columns, rows, boxSize int, opts PageOptions) []Line {
+	var lines []Line
+	gridW := columns*(boxSize+opts.SpacingX) - opts.SpacingX
+	gridH := rows*(boxSize+opts.SpacingY) - opts.SpacingY
+	right, bottom := left+gridW, top+gridH
+
+	var xs, ys []int
+	for c := 0; c < columns; c++ {
+		x := left + c*(boxSize+opts.SpacingX)
+		xs = appendEdge(xs, x)
+		xs = appendEdge(xs, x+boxSize)
+	}
+	for r := 0; r < rows; r++ {
+		y := top + r*(boxSize+opts.SpacingY)
+		ys = appendEdge(ys, y)
+		ys = appendEdge(ys, y+boxSize)
+	}
+
+	// 裁切线不超出页面
+	topLen := min(opts.MarkLength, top-opts.MarkGap)
+	bottomLen := min(opts.MarkLength, opts.Height-bottom-opts.MarkGap)
+	leftLen := min(opts.MarkLength, left-opts.MarkGap)
+	rightLen := min(opts.MarkLength, opts.Width-right-opts.MarkGap)
+	for _, x := range xs {
+		if topLen > 0 {
+			lines = append(lines, Line{image.Pt(x, top-opts.MarkGap-topLen), image.Pt(x, top-opts.MarkGap), opts.LineWidth})
+		}
+		if bottomLen > 0 {
+			lines = append(lines, Line{image.Pt(x, bottom+opts.MarkGap), image.Pt(x, bottom+opts.MarkGap+bottomLen), opts.LineWidth})
+		}
+	}
+	for _, y := range ys {
+		if leftLen > 0 {
+			lines = append(lines, Line{image.Pt(left-opts.MarkGap-leftLen, y), image.Pt(left-opts.MarkGap, y), opts.LineWidth})
+		}
+		if rightLen > 0 {
+			lines = append(lines, Line{image.Pt(right+opts.MarkGap, y), image.Pt(right+opts.MarkGap+rightLen, y), opts.LineWidth})
+		}
+	}
+	return lines
+}
+
+// 间距为0时相邻图块共用边缘，只画一次
+func appendEdge(edges []int, v int) []int {
+	if len(edges) > 0 && edges[len(edges)-1] == v {
+		return edges
+	}
+	return append(edges, v)
+}
+
+// 把标记线和文字绘制到位图中，供PNG等位图格式输出
+func drawMarks(img *image.RGBA, sheet *Sheet) {
+	mark := &image.Uniform{MarkColor}
+	for _, l := range sheet.Lines {
+		// 裁切线均为水平或竖直线
+		w := max(int(math.Round(l.Width)), 1)
+		r := image.Rectangle{Min: l.From, Max: l.To}.Canon()
+		if r.Dx() == 0 {
+			r.Min.X -= w / 2
+			r.Max.X = r.Min.X + w
+		} else {
+			r.Min.Y -= w / 2
+			r.Max.Y = r.Min.Y + w
+		}
+		draw.Draw(img, r, mark, image.Point{}, draw.Src)
+	}
+	for _, label := range sheet.Labels {
+		drawLabel(img, label)
+	}
+}
+
+// 用内置点阵字体绘制文字，按字号整数倍放大
+func drawLabel(img *image.RGBA, label Label) {
+	face := basicfont.Face7x13
+	d := &font.Drawer{Face: face}
+	width := d.MeasureString(label.Text).Ceil()
+	height := face.Height
+	if width == 0 {
+		return
+	}
+	small := image.NewAlpha(image.Rect(0, 0, width, height))
+	d.Dst = small
+	d.Src = image.Opaque
+	d.Dot = fixed.P(0, face.Ascent)
+	d.DrawString(label.Text)
+
+	scale := max(int(math.Round(label.Size/float64(height))), 1)
+	origin := image.Pt(label.At.X-width*scale/2, label.At.Y-face.Ascent*scale)
+	mark := &image.Uniform{MarkColor}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if small.AlphaAt(x, y).A == 0 {
+				continue
+			}
+			px := image.Rect(x*scale, y*scale, (x+1)*scale, (y+1)*scale).Add(origin)
+			draw.Draw(img, px, mark, image.Point{}, draw.Over)
+		}
+	}
+}
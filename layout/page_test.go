@@ -0,0 +1,111 @@
+package layout
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestPaginate(t *testing.T) {
+	// 可打印区域900x700，200的方框加10的间距排成4列3行，每页12个
+	opts := PageOptions{Width: 1000, Height: 800, Margin: 50, SpacingX: 10, SpacingY: 10, Background: White}
+	sheets, err := Paginate(gridTiles(25, 200, color.RGBA{0, 0, 0, 255}), 200, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sheets) != 3 {
+		t.Fatalf("页数 %d, 期望 3", len(sheets))
+	}
+	for i, want := range []int{12, 12, 1} {
+		s := sheets[i]
+		if len(s.Tiles) != want || len(s.Cells) != want || s.Page != i+1 || s.PageCount != 3 {
+			t.Errorf("第%d页: %d 个图块, 页码 %d/%d", i+1, len(s.Tiles), s.Page, s.PageCount)
+		}
+		if b := s.Image.Bounds(); b != image.Rect(0, 0, 1000, 800) {
+			t.Errorf("第%d页大小 %v", i+1, b)
+		}
+	}
+
+	// 830x620的网格在可打印区域内居中
+	cells := sheets[0].Cells
+	if cells[0].Min != image.Pt(85, 90) || cells[5].Min != image.Pt(295, 300) || cells[11].Max != image.Pt(915, 710) {
+		t.Errorf("单元格 %v %v %v", cells[0], cells[5], cells[11])
+	}
+	// 最后一页也从左上角开始
+	if sheets[2].Cells[0].Min != image.Pt(85, 90) {
+		t.Errorf("最后一页的单元格 %v", sheets[2].Cells[0])
+	}
+
+	opts.Fill = FillColumnMajor
+	sheets, err = Paginate(gridTiles(5, 200, White), 200, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := sheets[0].Cells; c[1].Min != image.Pt(85, 300) || c[3].Min != image.Pt(295, 90) {
+		t.Errorf("按列填充的单元格 %v %v", c[1], c[3])
+	}
+
+	// 没有图块时输出一页空白页
+	sheets, err = Paginate(nil, 200, opts)
+	if err != nil || len(sheets) != 1 || len(sheets[0].Cells) != 0 {
+		t.Errorf("没有图块: %d 页, %v", len(sheets), err)
+	}
+}
+
+func TestPaginateBoxTooLarge(t *testing.T) {
+	opts := PageOptions{Width: 300, Height: 300, Margin: 60}
+	if _, err := Paginate(gridTiles(1, 200, White), 200, opts); err == nil {
+		t.Error("方框超出可打印区域应返回错误")
+	}
+}
+
+func TestPaginateMarks(t *testing.T) {
+	opts := PageOptions{
+		Width: 1000, Height: 800, Margin: 50, SpacingX: 10, SpacingY: 10, Background: White,
+		CropMarks: true, MarkLength: 20, MarkGap: 5, LineWidth: 1,
+		PageNumbers: true, LabelSize: 12,
+	}
+	sheets, err := Paginate(gridTiles(13, 200, White), 200, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 4列8条竖直边缘、3行6条水平边缘，两侧各一条裁切线
+	lines := sheets[0].Lines
+	if len(lines) != 2*8+2*6 {
+		t.Fatalf("裁切线 %d 条, 期望 28", len(lines))
+	}
+	// 第一条竖直裁切线对齐第一列左边缘，画在网格上方的页边距中，不接触图块
+	if l := lines[0]; l.From != image.Pt(85, 65) || l.To != image.Pt(85, 85) {
+		t.Errorf("第一条裁切线 %v - %v", l.From, l.To)
+	}
+	for _, l := range lines {
+		r := image.Rectangle{Min: l.From, Max: l.To}.Canon()
+		if r.Overlaps(image.Rect(85, 90, 915, 710)) {
+			t.Errorf("裁切线 %v 与网格重叠", r)
+		}
+		if !r.In(image.Rect(0, 0, 1001, 801)) {
+			t.Errorf("裁切线 %v 超出页面", r)
+		}
+	}
+	if got := sheets[0].Image.RGBAAt(85, 70); got != MarkColor {
+		t.Errorf("裁切线未绘制到位图中: %v", got)
+	}
+
+	if len(sheets) != 2 || len(sheets[1].Labels) != 1 || sheets[1].Labels[0].Text != "2 / 2" {
+		t.Errorf("页码 %v", sheets[len(sheets)-1].Labels)
+	}
+	// 页码在下边距中居中
+	if at := sheets[1].Labels[0].At; at.X != 500 || at.Y <= 710 || at.Y >= 800 {
+		t.Errorf("页码位置 %v", at)
+	}
+
+	// 间距为0时相邻图块共用边缘，裁切线不重复
+	opts.SpacingX, opts.SpacingY = 0, 0
+	sheets, err = Paginate(gridTiles(1, 200, White), 200, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(sheets[0].Lines); n != 2*5+2*4 {
+		t.Errorf("无间距时裁切线 %d 条, 期望 18", n)
+	}
+}
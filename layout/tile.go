@@ -0,0 +1,96 @@
+package layout
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"path/filepath"
+	"strings"
+
+	"pdf-vector-extractor/detect"
+	"pdf-vector-extractor/extract"
+)
+
+// 拼接单元：某个PDF文件中一页的裁剪结果
+type Tile struct {
+	Image      image.Image
+	SourceFile string
+	PageNumber int
+	PageCount  int
+	Part       int // 同一页面分割出的第几个内容（从1开始），未分割时为0
+
+	// 矢量来源的页面内容及其到图块的映射，位图来源时Vector为nil
+	Vector   *extract.VectorPage
+	Scale    float64         // 页面点到渲染像素的缩放系数
+	CropRect image.Rectangle // 渲染图像中的裁剪区域
+	Offset   image.Point     // 裁剪区域在图块方框中的位置
+	Size     image.Point     // 裁剪区域缩放后在图块方框中的大小，为零时不缩放
+
+	// 内容在图块方框中的分布，按中心点或对齐方式裁剪时测量，未测量时为nil
+	Ink *detect.Ink
+}
+
+// 单元名称，多页文件附带页码，分割出的内容附带序号
+func (t Tile) Name() string {
+	base := strings.TrimSuffix(filepath.Base(t.SourceFile), filepath.Ext(t.SourceFile))
+	if t.PageCount > 1 {
+		base = fmt.Sprintf("%s_p%03d", base, t.PageNumber)
+	}
+	if t.Part > 0 {
+		base = fmt.Sprintf("%s_%02d", base, t.Part)
+	}
+	return base
+}
+
+// 页面空间到图块像素空间的变换
+func (t Tile) PageToTile() extract.Matrix {
+	m := t.Vector.DeviceMatrix(t.Scale)
+	if t.Size == (image.Point{}) || t.Size == t.CropRect.Size() {
+		m[4] += float64(t.Offset.X - t.CropRect.Min.X)
+		m[5] += float64(t.Offset.Y - t.CropRect.Min.Y)
+		return m
+	}
+	// 先平移到裁剪区域原点，再缩放到方框中的大小
+	sx := float64(t.Size.X) / float64(t.CropRect.Dx())
+	sy := float64(t.Size.Y) / float64(t.CropRect.Dy())
+	m[0], m[2], m[4] = m[0]*sx, m[2]*sx, (m[4]-float64(t.CropRect.Min.X))*sx+float64(t.Offset.X)
+	m[1], m[3], m[5] = m[1]*sy, m[3]*sy, (m[5]-float64(t.CropRect.Min.Y))*sy+float64(t.Offset.Y)
+	return m
+}
+
+// 裁剪区域在图块方框中的位置，对齐移动后超出方框的部分不可见
+func (t Tile) VisibleRect() image.Rectangle {
+	size := t.Size
+	if size == (image.Point{}) {
+		size = t.CropRect.Size()
+	}
+	r := image.Rectangle{Min: t.Offset, Max: t.Offset.Add(size)}
+	if t.Image != nil {
+		r = r.Intersect(image.Rectangle{Max: t.Image.Bounds().Size()})
+	}
+	return r
+}
+
+// 拼接结果：合成后的位图以及每个图块所在的单元格
+type Sheet struct {
+	Image      *image.RGBA
+	Tiles      []Tile
+	Cells      []image.Rectangle
+	Background color.RGBA // 背景颜色，透明度为0时不绘制背景
+
+	// 分页输出时的页码（从1开始）和总页数，未分页时为0
+	Page      int
+	PageCount int
+
+	// 叠加在图块之上的裁切线和页码，已绘制在Image中，矢量格式需另行输出
+	Lines  []Line
+	Labels []Label
+}
+
+// 第i个图块在拼接结果中的变换（页面空间到拼接结果像素空间）
+func (s *Sheet) TileMatrix(i int) extract.Matrix {
+	m := s.Tiles[i].PageToTile()
+	m[4] += float64(s.Cells[i].Min.X)
+	m[5] += float64(s.Cells[i].Min.Y)
+	return m
+}
@@ -0,0 +1,253 @@
+package output
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"pdf-vector-extractor/extract"
+	"pdf-vector-extractor/layout"
+)
+
+// 保存为AI格式 (Adobe Illustrator可打开的EPS)
+// 图块输出PostScript路径，同一源文件的图块以DSC对象注释分组；未描摹的位图图块以图像嵌入
+// 文件不含Illustrator的图层操作符和procset，在Illustrator中打开后为可编辑的路径，不分图层
+func SaveAI(ctx context.Context, sheet *layout.Sheet, outputPath string, opts Options) error {
+	bounds := sheet.Image.Bounds()
+	width := bounds.Dx()
+	height := bounds.Dy()
+	flip := extract.Matrix{1, 0, 0, -1, 0, float64(height)}
+
+	// 像素按输出分辨率换算为点
+	scale := 72 / float64(opts.dpi())
+	hiResW, hiResH := float64(width)*scale, float64(height)*scale
+
+	var body bytes.Buffer
+	group := ""
+	// PostScript不支持透明度，半透明背景按不透明绘制，白色与纸张相同不绘制
+	if bg := sheet.Background; bg.A > 0 && bg != layout.White {
+		fmt.Fprintf(&body, "%%%%BeginObject: %s\n%s setrgbcolor\n0 0 %d %d rectfill\n%%%%EndObject\n",
+			psComment("背景"), pdfRGB(unpremultiply(bg)), width, height)
+	}
+	for i, tile := range sheet.Tiles {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		// 同一源文件的连续图块放在同一组
+		if i == 0 || tile.SourceFile != sheet.Tiles[i-1].SourceFile {
+			if group != "" {
+				body.WriteString("%%EndObject\n")
+			}
+			group = strings.TrimSuffix(filepath.Base(tile.SourceFile), filepath.Ext(tile.SourceFile))
+			fmt.Fprintf(&body, "%%%%BeginObject: %s\n", psComment(group))
+		}
+
+		// 裁剪到图块的可见区域
+		visible := tile.VisibleRect().Add(sheet.Cells[i].Min)
+		fmt.Fprintf(&body, "%%Tile: %s\ngsave\n%d %d %d %d rectclip\n",
+			psComment(tile.Name()), visible.Min.X, height-visible.Max.Y, visible.Dx(), visible.Dy())
+
+		if paths, m, ok := tileVectors(sheet, i, opts); ok {
+			toPage := m.Multiply(flip)
+			for pi := range paths {
+				writePSPath(&body, &paths[pi], toPage)
+			}
+		} else {
+			cell := sheet.Cells[i].Min
+			writePSImage(&body, tile.Image, cell.X, height-cell.Y-tile.Image.Bounds().Dy())
+		}
+		body.WriteString("grestore\n")
+	}
+	if group != "" {
+		body.WriteString("%%EndObject\n")
+	}
+	// 裁切线和页码单独一组
+	if len(sheet.Lines) > 0 || len(sheet.Labels) > 0 {
+		fmt.Fprintf(&body, "%%%%BeginObject: %s\n", psComment("标记"))
+		writePSMarks(&body, sheet, height)
+		body.WriteString("%%EndObject\n")
+	}
+
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := bufio.NewWriter(file)
+
+	// 写入AI文件头部，路径操作符在序言中定义为简写
+	fmt.Fprintf(w, `%%!PS-Adobe-3.0 EPSF-3.0
+%%%%Creator: %s
+%%%%Title: %s
+%%%%BoundingBox: 0 0 %d %d
+%%%%HiResBoundingBox: 0 0 %s %s
+%%%%DocumentData: Clean7Bit
+%%%%LanguageLevel: 2
+%%%%Pages: 1
+%%%%EndComments
+%%%%BeginProlog
+/m {moveto} bind def
+/l {lineto} bind def
+/c {curveto} bind def
+/h {closepath} bind def
+%%%%EndProlog
+%%%%BeginSetup
+%%%%EndSetup
+%%%%Page: 1 1
+gsave
+%s %s scale
+`, psComment("PDF矢量图提取工具"), psComment(filepath.Base(outputPath)), int(math.Ceil(hiResW)), int(math.Ceil(hiResH)),
+		svgNum(hiResW), svgNum(hiResH), svgNum(scale), svgNum(scale))
+
+	w.Write(body.Bytes())
+
+	// 写入AI文件尾部
+	fmt.Fprintf(w, `grestore
+showpage
+%%%%Trailer
+%%%%EOF
+`)
+
+	return w.Flush()
+}
+
+// 将位图以colorimage嵌入，(x, y)为图像左下角的页面坐标
+func writePSImage(w *bytes.Buffer, img image.Image, x, y int) {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	fmt.Fprintf(w, "gsave\n%d %d translate\n%d %d scale\n", x, y, width, height)
+	fmt.Fprintf(w, "%d %d 8 [%d 0 0 -%d 0 %d] {currentfile 3 %d mul string readhexstring pop} bind false 3 colorimage\n",
+		width, height, width, height, height, width)
+
+	// 透明像素按白色背景合成
+	n := 0
+	for py := bounds.Min.Y; py < bounds.Max.Y; py++ {
+		for px := bounds.Min.X; px < bounds.Max.X; px++ {
+			r, g, b, a := img.At(px, py).RGBA()
+			bg := 0xffff - a
+			fmt.Fprintf(w, "%02x%02x%02x", (r+bg)>>8, (g+bg)>>8, (b+bg)>>8)
+			if n++; n%16 == 0 {
+				w.WriteString("\n")
+			}
+		}
+	}
+	w.WriteString("\ngrestore\n")
+}
+
+// 写入裁切线和页码，height为拼接结果高度，用于翻转y轴
+func writePSMarks(w *bytes.Buffer, sheet *layout.Sheet, height int) {
+	for _, l := range sheet.Lines {
+		fmt.Fprintf(w, "%s setrgbcolor %s setlinewidth\n%d %d m %d %d l stroke\n",
+			pdfRGB(layout.MarkColor), svgNum(l.Width), l.From.X, height-l.From.Y, l.To.X, height-l.To.Y)
+	}
+	for _, label := range sheet.Labels {
+		fmt.Fprintf(w, "%s setrgbcolor /Helvetica findfont %s scalefont setfont\n%d %d moveto %s dup stringwidth pop 2 div neg 0 rmoveto show\n",
+			pdfRGB(layout.MarkColor), svgNum(label.Size), label.At.X, height-label.At.Y, psString(label.Text))
+	}
+}
+
+// PostScript字符串，非ASCII字节使用八进制转义以保持7位纯净
+func psString(s string) string {
+	var sb strings.Builder
+	sb.WriteByte('(')
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '(' || c == ')' || c == '\\':
+			sb.WriteByte('\\')
+			sb.WriteByte(c)
+		case c < 0x20 || c >= 0x7f:
+			fmt.Fprintf(&sb, "\\%03o", c)
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	sb.WriteByte(')')
+	return sb.String()
+}
+
+// DSC注释中的文本，非ASCII字节同样转义
+func psComment(s string) string {
+	str := psString(s)
+	return str[1 : len(str)-1]
+}
+
+// 写入单条路径，toPage为路径所在空间到页面空间的变换
+func writePSPath(w *bytes.Buffer, p *extract.VectorPath, toPage extract.Matrix) {
+	w.WriteString("gsave\n")
+
+	// 裁剪路径直接变换到页面坐标，避免与路径自身的concat互相影响
+	for _, clip := range p.Clips {
+		writePSSegments(w, clip.Segments, clip.CTM.Multiply(toPage))
+		if clip.EvenOdd {
+			w.WriteString("eoclip newpath\n")
+		} else {
+			w.WriteString("clip newpath\n")
+		}
+	}
+
+	ctm := p.CTM.Multiply(toPage)
+	fmt.Fprintf(w, "[%s %s %s %s %s %s] concat\n",
+		svgNum(ctm[0]), svgNum(ctm[1]), svgNum(ctm[2]), svgNum(ctm[3]), svgNum(ctm[4]), svgNum(ctm[5]))
+
+	if p.Stroke {
+		fmt.Fprintf(w, "%s setlinewidth %d setlinecap %d setlinejoin %s setmiterlimit\n",
+			svgNum(p.LineWidth), p.LineCap, p.LineJoin, svgNum(math.Max(p.MiterLimit, 1)))
+		if len(p.Dash) > 0 {
+			parts := make([]string, len(p.Dash))
+			for i, d := range p.Dash {
+				parts[i] = svgNum(d)
+			}
+			fmt.Fprintf(w, "[%s] %s setdash\n", strings.Join(parts, " "), svgNum(p.DashPhase))
+		}
+	}
+
+	writePSSegments(w, p.Segments, extract.IdentityMatrix)
+
+	fill := "fill"
+	if p.EvenOdd {
+		fill = "eofill"
+	}
+	switch {
+	case p.Fill && p.Stroke:
+		// 填充后保留路径继续描边
+		fmt.Fprintf(w, "gsave %s setrgbcolor %s grestore\n%s setrgbcolor stroke\n",
+			pdfRGB(p.FillColor), fill, pdfRGB(p.StrokeColor))
+	case p.Fill:
+		fmt.Fprintf(w, "%s setrgbcolor %s\n", pdfRGB(p.FillColor), fill)
+	case p.Stroke:
+		fmt.Fprintf(w, "%s setrgbcolor stroke\n", pdfRGB(p.StrokeColor))
+	default:
+		w.WriteString("newpath\n")
+	}
+	w.WriteString("grestore\n")
+}
+
+// 写入路径构造操作符（使用序言中定义的简写）
+func writePSSegments(w *bytes.Buffer, segments []extract.PathSegment, m extract.Matrix) {
+	for _, seg := range segments {
+		pts := make([]string, 0, 6)
+		for _, pt := range seg.Points {
+			pt = m.Apply(pt)
+			pts = append(pts, svgNum(pt.X), svgNum(pt.Y))
+		}
+		switch seg.Op {
+		case extract.SegmentMoveTo:
+			fmt.Fprintf(w, "%s m\n", strings.Join(pts, " "))
+		case extract.SegmentLineTo:
+			fmt.Fprintf(w, "%s l\n", strings.Join(pts, " "))
+		case extract.SegmentCubicTo:
+			fmt.Fprintf(w, "%s c\n", strings.Join(pts, " "))
+		case extract.SegmentClose:
+			w.WriteString("h\n")
+		}
+	}
+}
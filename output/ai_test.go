@@ -0,0 +1,76 @@
+package output
+
+import (
+	"context"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSaveAIStructure(t *testing.T) {
+	sheet := testSheet(t, color.RGBA{255, 240, 200, 255})
+	path := filepath.Join(t.TempDir(), "out.ai")
+	// 144DPI下300x100像素对应150x50点
+	if err := SaveAI(context.Background(), sheet, path, Options{OutputDPI: 144}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ps := string(data)
+
+	if !strings.HasPrefix(ps, "%!PS-Adobe-3.0 EPSF-3.0\n") {
+		t.Errorf("缺少EPSF文件头: %q", ps[:min(len(ps), 40)])
+	}
+	for _, want := range []string{
+		"%%BoundingBox: 0 0 150 50\n",
+		"%%HiResBoundingBox: 0 0 150 50\n",
+		"%%EndComments\n",
+		"0.5 0.5 scale\n",
+	} {
+		if !strings.Contains(ps, want) {
+			t.Errorf("缺少 %q", strings.TrimSpace(want))
+		}
+	}
+	if !strings.HasSuffix(ps, "%%EOF\n") {
+		t.Error("缺少EOF结尾")
+	}
+
+	// 背景、矢量文件和位图文件各一组，同一文件的两个图块在同一组
+	if n := strings.Count(ps, "%%BeginObject:"); n != 3 {
+		t.Errorf("对象组数量 %d, 期望 3", n)
+	}
+	if begin, end := strings.Count(ps, "%%BeginObject:"), strings.Count(ps, "%%EndObject\n"); begin != end {
+		t.Errorf("对象组不配对: %d 个开始, %d 个结束", begin, end)
+	}
+	if begin, end := strings.Count(ps, "gsave"), strings.Count(ps, "grestore"); begin != end {
+		t.Errorf("gsave/grestore不配对: %d, %d", begin, end)
+	}
+	if n := strings.Count(ps, "%Tile: "); n != 3 {
+		t.Errorf("图块数量 %d, 期望 3", n)
+	}
+
+	// 矢量图块输出路径，位图图块嵌入图像
+	if n := strings.Count(ps, " c\n"); n != 2 {
+		t.Errorf("曲线段数量 %d, 期望 2", n)
+	}
+	if n := strings.Count(ps, "colorimage"); n != 1 {
+		t.Errorf("嵌入图像数量 %d, 期望 1", n)
+	}
+	// 不使用序言未定义的Illustrator图层操作符
+	for _, op := range []string{" Lb\n", " LB\n", " Ln\n", "%AI5_BeginLayer"} {
+		if strings.Contains(ps, op) {
+			t.Errorf("不应包含 %q", strings.TrimSpace(op))
+		}
+	}
+	for _, line := range strings.Split(ps, "\n") {
+		for _, c := range []byte(line) {
+			if c >= 0x80 {
+				t.Fatalf("文件应为7位纯净: %q", line)
+			}
+		}
+	}
+}
@@ -0,0 +1,185 @@
+// Package output 将拼接结果保存为PNG、SVG、AI或PDF文件
+package output
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"sync"
+
+	"pdf-vector-extractor/extract"
+	"pdf-vector-extractor/layout"
+)
+
+// 输出参数
+type Options struct {
+	Format      string       // 已注册的输出格式，如 "png", "svg", "ai", "pdf"
+	OutputDPI   int          // PDF/AI中一个像素对应的分辨率，0表示使用默认渲染分辨率
+	TraceRaster bool         // 输出SVG、AI、PDF时将位图图块描摹为矢量路径
+	Trace       TraceOptions // 描摹参数
+}
+
+// 输出分辨率，未设置时使用默认渲染分辨率
+func (opts Options) dpi() int {
+	if opts.OutputDPI > 0 {
+		return opts.OutputDPI
+	}
+	return extract.DefaultRenderDPI
+}
+
+// 输出格式的写入器，path已包含扩展名；ctx取消时应停止写入并返回ctx的错误
+type Writer interface {
+	Write(ctx context.Context, sheet *layout.Sheet, path string, opts Options) error
+}
+
+// 以函数实现Writer
+type WriterFunc func(ctx context.Context, sheet *layout.Sheet, path string, opts Options) error
+
+func (f WriterFunc) Write(ctx context.Context, sheet *layout.Sheet, path string, opts Options) error {
+	return f(ctx, sheet, path, opts)
+}
+
+// 可将多页拼接结果写入同一文件的写入器，如PDF
+// 未实现该接口的格式分页输出时每页一个文件
+type PagesWriter interface {
+	Writer
+	WritePages(ctx context.Context, sheets []*layout.Sheet, path string, opts Options) error
+}
+
+// 已注册的输出格式，按注册顺序排列
+var (
+	writersMu sync.RWMutex
+	formats   []string
+	writers   = make(map[string]Writer)
+)
+
+func init() {
+	Register("png", WriterFunc(func(ctx context.Context, sheet *layout.Sheet, path string, _ Options) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return SavePNG(sheet.Image, path)
+	}))
+	Register("svg", WriterFunc(SaveSVG))
+	Register("ai", WriterFunc(SaveAI))
+	Register("pdf", pdfOutput{})
+}
+
+// 注册输出格式，格式名同时用作文件扩展名；同名格式会被替换
+func Register(format string, w Writer) {
+	if format == "" || w == nil {
+		panic("output: 注册输出格式时名称和写入器不能为空")
+	}
+	writersMu.Lock()
+	defer writersMu.Unlock()
+	if _, ok := writers[format]; !ok {
+		formats = append(formats, format)
+	}
+	writers[format] = w
+}
+
+// 查找输出格式的写入器
+func Lookup(format string) (Writer, bool) {
+	writersMu.RLock()
+	defer writersMu.RUnlock()
+	w, ok := writers[format]
+	return w, ok
+}
+
+// 所有已注册的输出格式
+func Formats() []string {
+	writersMu.RLock()
+	defer writersMu.RUnlock()
+	return append([]string(nil), formats...)
+}
+
+// 判断输出格式是否已注册
+func IsFormat(format string) bool {
+	_, ok := Lookup(format)
+	return ok
+}
+
+// 按格式保存拼接结果，basePath不含扩展名，返回写入的文件路径
+// 写入失败或被取消时删除未写完的文件，取消时返回ctx的错误
+func Save(ctx context.Context, sheet *layout.Sheet, basePath string, opts Options) (string, error) {
+	w, ok := Lookup(opts.Format)
+	if !ok {
+		return "", fmt.Errorf("不支持的输出格式: %s", opts.Format)
+	}
+	outputPath := basePath + "." + opts.Format
+	if err := writeFile(ctx, outputPath, func() error {
+		return w.Write(ctx, sheet, outputPath, opts)
+	}); err != nil {
+		return "", err
+	}
+	return outputPath, nil
+}
+
+// 调用write写入文件，失败或被取消时删除未写完的文件，取消时返回ctx的错误
+func writeFile(ctx context.Context, outputPath string, write func() error) error {
+	_, statErr := os.Stat(outputPath)
+	if err := write(); err != nil {
+		// 只删除本次新建的文件，不影响已存在的同名文件
+		if os.IsNotExist(statErr) {
+			os.Remove(outputPath)
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		return fmt.Errorf("保存 %s 失败: %v", outputPath, err)
+	}
+	return nil
+}
+
+// 保存分页的拼接结果，返回写入的文件路径
+// 支持多页的格式写入一个文件，其他格式每页一个文件，文件名附带页码，如 _p001
+// 写入失败或被取消时删除本次写入的所有文件
+func SavePages(ctx context.Context, sheets []*layout.Sheet, basePath string, opts Options) ([]string, error) {
+	if len(sheets) == 1 {
+		path, err := Save(ctx, sheets[0], basePath, opts)
+		if err != nil {
+			return nil, err
+		}
+		return []string{path}, nil
+	}
+
+	w, ok := Lookup(opts.Format)
+	if !ok {
+		return nil, fmt.Errorf("不支持的输出格式: %s", opts.Format)
+	}
+	if pw, ok := w.(PagesWriter); ok {
+		outputPath := basePath + "." + opts.Format
+		if err := writeFile(ctx, outputPath, func() error {
+			return pw.WritePages(ctx, sheets, outputPath, opts)
+		}); err != nil {
+			return nil, err
+		}
+		return []string{outputPath}, nil
+	}
+
+	var paths []string
+	for i, sheet := range sheets {
+		path, err := Save(ctx, sheet, fmt.Sprintf("%s_p%03d", basePath, i+1), opts)
+		if err != nil {
+			for _, p := range paths {
+				os.Remove(p)
+			}
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// 保存为PNG
+func SavePNG(img image.Image, outputPath string) error {
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return png.Encode(file, img)
+}
@@ -0,0 +1,115 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"pdf-vector-extractor/extract"
+	"pdf-vector-extractor/layout"
+)
+
+// 写入只有一页的测试PDF，content为页面内容流
+func writeTestPDF(t *testing.T, name string, width, height int, content string) string {
+	t.Helper()
+	objs := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << >> /Contents 4 0 R >>", width, height),
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+	}
+
+	var buf bytes.Buffer
+	// pdfcpu从文件末尾之前512字节处查找交叉引用表，用注释行把文件补足长度
+	buf.WriteString("%PDF-1.4\n%" + strings.Repeat("x", 512) + "\n")
+	offsets := make([]int, len(objs))
+	for i, obj := range objs {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objs)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objs)+1, xref)
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// 测试用拼接结果：两个来自同一PDF的矢量图块和一个位图图块，每个单元格100像素见方
+func testSheet(t *testing.T, bg color.RGBA) *layout.Sheet {
+	t.Helper()
+	path := writeTestPDF(t, "vector.pdf", 100, 100,
+		"1 0 0 rg 10 10 80 80 re f 0 0 1 RG 4 w 20 50 m 20 90 80 90 80 50 c S")
+	page, err := extract.NewProcessor(72).ExtractPage(context.Background(), path, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Vector == nil {
+		t.Fatal("测试页面应为矢量页面")
+	}
+	vector := layout.Tile{
+		Image:      page.Image,
+		SourceFile: path,
+		PageNumber: 1,
+		PageCount:  1,
+		Vector:     page.Vector,
+		Scale:      page.Scale,
+		CropRect:   page.Image.Bounds(),
+	}
+
+	raster := image.NewRGBA(image.Rect(0, 0, 100, 100))
+	draw.Draw(raster, raster.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(raster, image.Rect(30, 30, 70, 70), image.Black, image.Point{}, draw.Src)
+	rasterTile := layout.Tile{Image: raster, SourceFile: "scan.png", CropRect: raster.Bounds()}
+
+	second := vector
+	second.Part = 2
+	return layout.Grid([]layout.Tile{vector, second, rasterTile}, 100, layout.GridOptions{Columns: 3, Background: bg})
+}
+
+func TestSavePagesSplitsFiles(t *testing.T) {
+	sheets := []*layout.Sheet{testSheet(t, layout.White), testSheet(t, layout.White)}
+	base := filepath.Join(t.TempDir(), "out")
+
+	// PNG不支持多页，每页一个文件并附带页码
+	paths, err := SavePages(context.Background(), sheets, base, Options{Format: "png"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{base + "_p001.png", base + "_p002.png"}
+	if len(paths) != len(want) || paths[0] != want[0] || paths[1] != want[1] {
+		t.Fatalf("PNG分页输出 %v, 期望 %v", paths, want)
+	}
+
+	// PDF写入同一文件
+	paths, err = SavePages(context.Background(), sheets, base, Options{Format: "pdf"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 1 || paths[0] != base+".pdf" {
+		t.Fatalf("PDF分页输出 %v, 期望一个文件", paths)
+	}
+}
+
+func TestSaveUnknownFormat(t *testing.T) {
+	base := filepath.Join(t.TempDir(), "out")
+	if _, err := Save(context.Background(), testSheet(t, layout.White), base, Options{Format: "bmp"}); err == nil {
+		t.Fatal("未注册的格式应返回错误")
+	}
+	if _, err := os.Stat(base + ".bmp"); !os.IsNotExist(err) {
+		t.Errorf("未注册的格式不应创建文件: %v", err)
+	}
+}
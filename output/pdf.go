@@ -0,0 +1,490 @@
+package output
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+
+	"pdf-vector-extractor/extract"
+	"pdf-vector-extractor/layout"
+)
+
+// PDF输出模块：矢量来源的图块以Form XObject导入原页面，保留字体、曲线和专色
+// 位图图块输出描摹路径或嵌入图像；页面尺寸按输出分辨率换算为点，写出前用pdfcpu校验
+func SavePDF(ctx context.Context, sheet *layout.Sheet, outputPath string, opts Options) error {
+	return SavePDFPages(ctx, []*layout.Sheet{sheet}, outputPath, opts)
+}
+
+// 将多个拼接结果保存为多页PDF，每个拼接结果一页
+func SavePDFPages(ctx context.Context, sheets []*layout.Sheet, outputPath string, opts Options) error {
+	pages := make([]pdfPage, 0, len(sheets))
+	for _, sheet := range sheets {
+		content, err := buildPDFContent(ctx, sheet, opts)
+		if err != nil {
+			return err
+		}
+		bounds := sheet.Image.Bounds()
+		pages = append(pages, pdfPage{content: content, width: bounds.Dx(), height: bounds.Dy()})
+	}
+	data, err := pdfDocument(pages, opts.dpi(), extract.ReadContext)
+	if err != nil {
+		return fmt.Errorf("生成PDF失败: %v", err)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// 按严格模式校验，确保输出可被印刷流程接受
+	conf := model.NewDefaultConfiguration()
+	conf.ValidationMode = model.ValidationStrict
+	if err := api.Validate(bytes.NewReader(data), conf); err != nil {
+		return fmt.Errorf("生成的PDF未通过校验: %v", err)
+	}
+
+	return os.WriteFile(outputPath, data, 0644)
+}
+
+// PDF格式的写入器，多页拼接结果写入同一文件
+type pdfOutput struct{}
+
+func (pdfOutput) Write(ctx context.Context, sheet *layout.Sheet, path string, opts Options) error {
+	return SavePDF(ctx, sheet, path, opts)
+}
+
+func (pdfOutput) WritePages(ctx context.Context, sheets []*layout.Sheet, path string, opts Options) error {
+	return SavePDFPages(ctx, sheets, path, opts)
+}
+
+// PDF页面内容流及其引用的资源
+type pdfContent struct {
+	buf    bytes.Buffer
+	alphas [][2]float64 // 每个ExtGState的描边、填充透明度
+	gsName map[[2]float64]string
+	images []image.Image // 按顺序命名为Im1、Im2...
+	forms  []pdfForm     // 按顺序命名为Fm1、Fm2...
+	font   bool          // 使用了页码字体F1
+}
+
+// PDF文档中的一页及其尺寸（像素）
+type pdfPage struct {
+	content       *pdfContent
+	width, height int
+}
+
+// 以Form XObject导入的源页面
+type pdfForm struct {
+	File string
+	Page int
+	BBox extract.PageBox
+}
+
+// 源页面对应的Form名称，同一页面只导入一次
+func (c *pdfContent) formName(f pdfForm) string {
+	for i, existing := range c.forms {
+		if existing.File == f.File && existing.Page == f.Page {
+			return fmt.Sprintf("Fm%d", i+1)
+		}
+	}
+	c.forms = append(c.forms, f)
+	return fmt.Sprintf("Fm%d", len(c.forms))
+}
+
+// 为拼接结果生成页面内容流，坐标单位为拼接结果像素
+func buildPDFContent(ctx context.Context, sheet *layout.Sheet, opts Options) (*pdfContent, error) {
+	height := float64(sheet.Image.Bounds().Dy())
+	// 拼接结果像素空间（y向下）到PDF页面空间（y向上）
+	flip := extract.Matrix{1, 0, 0, -1, 0, height}
+
+	c := &pdfContent{gsName: make(map[[2]float64]string)}
+	// 白色与纸张相同，只绘制其他背景颜色
+	if bg := sheet.Background; bg.A > 0 && bg != layout.White {
+		bounds := sheet.Image.Bounds()
+		c.buf.WriteString("q\n")
+		if bg.A < 255 {
+			fmt.Fprintf(&c.buf, "/%s gs\n", c.alphaState(1, float64(bg.A)/255))
+		}
+		fmt.Fprintf(&c.buf, "%s rg\n0 0 %d %d re f\nQ\n", pdfRGB(unpremultiply(bg)), bounds.Dx(), bounds.Dy())
+	}
+	for i, tile := range sheet.Tiles {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		// 裁剪到图块的可见区域
+		visible := tile.VisibleRect().Add(sheet.Cells[i].Min)
+		fmt.Fprintf(&c.buf, "q\n%d %s %d %d re W n\n",
+			visible.Min.X, svgNum(height-float64(visible.Max.Y)), visible.Dx(), visible.Dy())
+
+		if tile.Vector != nil {
+			// 矢量来源：导入原页面，变换到单元格中的位置
+			name := c.formName(pdfForm{File: tile.SourceFile, Page: tile.PageNumber, BBox: tile.Vector.MediaBox})
+			fmt.Fprintf(&c.buf, "%s cm\n/%s Do\n", pdfMatrix(sheet.TileMatrix(i).Multiply(flip)), name)
+		} else if paths, m, ok := tileVectors(sheet, i, opts); ok {
+			toPage := m.Multiply(flip)
+			for pi := range paths {
+				c.writePath(&paths[pi], toPage)
+			}
+		} else {
+			// 位图图块：图像单位正方形映射到单元格
+			tb := tile.Image.Bounds()
+			c.images = append(c.images, tile.Image)
+			fmt.Fprintf(&c.buf, "%d 0 0 %d %d %s cm\n/Im%d Do\n", tb.Dx(), tb.Dy(),
+				sheet.Cells[i].Min.X, svgNum(height-float64(sheet.Cells[i].Min.Y+tb.Dy())), len(c.images))
+		}
+		c.buf.WriteString("Q\n")
+	}
+	c.writeMarks(sheet, height)
+	return c, nil
+}
+
+// 写入裁切线和页码
+func (c *pdfContent) writeMarks(sheet *layout.Sheet, height float64) {
+	for _, l := range sheet.Lines {
+		fmt.Fprintf(&c.buf, "q\n%s RG\n%s w\n%d %s m\n%d %s l\nS\nQ\n",
+			pdfRGB(layout.MarkColor), svgNum(l.Width),
+			l.From.X, svgNum(height-float64(l.From.Y)), l.To.X, svgNum(height-float64(l.To.Y)))
+	}
+	for _, label := range sheet.Labels {
+		c.font = true
+		x := float64(label.At.X) - helveticaWidth(label.Text)*label.Size/2
+		fmt.Fprintf(&c.buf, "BT\n%s rg\n/F1 %s Tf\n%s %s Td\n%s Tj\nET\n",
+			pdfRGB(layout.MarkColor), svgNum(label.Size), svgNum(x), svgNum(height-float64(label.At.Y)), psString(label.Text))
+	}
+}
+
+// 写入页码使用的Helvetica字体及其字体描述，返回字体对象号
+// 严格校验要求标准字体也带有字符宽度和字体描述
+func (w *pdfWriter) writeHelvetica() int {
+	font, descriptor := w.newObject(), w.newObject()
+	w.writeObject(font, fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding "+
+		"/FirstChar %d /LastChar %d /Widths [%s] /FontDescriptor %d 0 R >>",
+		helveticaFirstChar, helveticaFirstChar+len(helveticaWidths)-1, strings.Trim(fmt.Sprint(helveticaWidths), "[]"), descriptor))
+	w.writeObject(descriptor, "<< /Type /FontDescriptor /FontName /Helvetica /Flags 32 /FontBBox [-166 -225 1000 931] "+
+		"/ItalicAngle 0 /Ascent 718 /Descent -207 /CapHeight 718 /StemV 88 >>")
+	return font
+}
+
+// 页码用到的Helvetica字符宽度（千分之一字号），从空格到数字9
+const helveticaFirstChar = ' '
+
+var helveticaWidths = []int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278, // 空格 到 /
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, // 0-9
+}
+
+// Helvetica中文字的宽度（以字号为单位），用于居中页码
+func helveticaWidth(s string) float64 {
+	width := 0
+	for _, ch := range s {
+		if i := int(ch - helveticaFirstChar); i >= 0 && i < len(helveticaWidths) {
+			width += helveticaWidths[i]
+		} else {
+			width += 556
+		}
+	}
+	return float64(width) / 1000
+}
+
+// 写入单条路径，toPage为路径所在空间到页面空间的变换
+func (c *pdfContent) writePath(p *extract.VectorPath, toPage extract.Matrix) {
+	w := &c.buf
+	w.WriteString("q\n")
+
+	// 裁剪路径直接变换到页面坐标，避免与路径自身的cm互相影响
+	for _, clip := range p.Clips {
+		writePDFSegments(w, clip.Segments, clip.CTM.Multiply(toPage))
+		if clip.EvenOdd {
+			w.WriteString("W* n\n")
+		} else {
+			w.WriteString("W n\n")
+		}
+	}
+
+	fmt.Fprintf(w, "%s cm\n", pdfMatrix(p.CTM.Multiply(toPage)))
+
+	if (p.Fill && p.FillAlpha < 1) || (p.Stroke && p.StrokeAlpha < 1) {
+		fmt.Fprintf(w, "/%s gs\n", c.alphaState(p.StrokeAlpha, p.FillAlpha))
+	}
+	if p.Fill {
+		fmt.Fprintf(w, "%s rg\n", pdfRGB(p.FillColor))
+	}
+	if p.Stroke {
+		fmt.Fprintf(w, "%s RG\n%s w %d J %d j %s M\n", pdfRGB(p.StrokeColor),
+			svgNum(p.LineWidth), p.LineCap, p.LineJoin, svgNum(math.Max(p.MiterLimit, 1)))
+		if len(p.Dash) > 0 {
+			parts := make([]string, len(p.Dash))
+			for i, d := range p.Dash {
+				parts[i] = svgNum(d)
+			}
+			fmt.Fprintf(w, "[%s] %s d\n", strings.Join(parts, " "), svgNum(p.DashPhase))
+		}
+	}
+
+	writePDFSegments(w, p.Segments, extract.IdentityMatrix)
+
+	op := "n"
+	switch {
+	case p.Fill && p.Stroke && p.EvenOdd:
+		op = "B*"
+	case p.Fill && p.Stroke:
+		op = "B"
+	case p.Fill && p.EvenOdd:
+		op = "f*"
+	case p.Fill:
+		op = "f"
+	case p.Stroke:
+		op = "S"
+	}
+	w.WriteString(op + "\nQ\n")
+}
+
+// 透明度对应的ExtGState名称，相同取值共用一个
+func (c *pdfContent) alphaState(strokeAlpha, fillAlpha float64) string {
+	key := [2]float64{strokeAlpha, fillAlpha}
+	if name, ok := c.gsName[key]; ok {
+		return name
+	}
+	name := fmt.Sprintf("GS%d", len(c.alphas)+1)
+	c.gsName[key] = name
+	c.alphas = append(c.alphas, key)
+	return name
+}
+
+// 写入路径构造操作符
+func writePDFSegments(w *bytes.Buffer, segments []extract.PathSegment, m extract.Matrix) {
+	for _, seg := range segments {
+		pts := make([]string, 0, 6)
+		for _, pt := range seg.Points {
+			pt = m.Apply(pt)
+			pts = append(pts, svgNum(pt.X), svgNum(pt.Y))
+		}
+		switch seg.Op {
+		case extract.SegmentMoveTo:
+			fmt.Fprintf(w, "%s m\n", strings.Join(pts, " "))
+		case extract.SegmentLineTo:
+			fmt.Fprintf(w, "%s l\n", strings.Join(pts, " "))
+		case extract.SegmentCubicTo:
+			fmt.Fprintf(w, "%s c\n", strings.Join(pts, " "))
+		case extract.SegmentClose:
+			w.WriteString("h\n")
+		}
+	}
+}
+
+func pdfMatrix(m extract.Matrix) string {
+	return fmt.Sprintf("%s %s %s %s %s %s",
+		svgNum(m[0]), svgNum(m[1]), svgNum(m[2]), svgNum(m[3]), svgNum(m[4]), svgNum(m[5]))
+}
+
+func pdfRGB(c color.RGBA) string {
+	return fmt.Sprintf("%s %s %s", svgNum(float64(c.R)/255), svgNum(float64(c.G)/255), svgNum(float64(c.B)/255))
+}
+
+// 生成PDF文档，像素按dpi换算为点；readContext用于读取要导入页面的源文件
+// 同一源页面在多页中只导入一次
+func pdfDocument(pages []pdfPage, dpi int, readContext func(string) (*model.Context, error)) ([]byte, error) {
+	scale := 72 / float64(dpi)
+
+	w := newPDFWriter()
+	catalog, pagesObj := w.newObject(), w.newObject()
+	pageObjs := make([]int, len(pages))
+	contentObjs := make([]int, len(pages))
+	kids := make([]string, len(pages))
+	for i := range pages {
+		pageObjs[i], contentObjs[i] = w.newObject(), w.newObject()
+		kids[i] = fmt.Sprintf("%d 0 R", pageObjs[i])
+	}
+
+	importers := make(map[string]*pdfImporter)
+	imported := make(map[pdfForm]int)
+	fontObj := 0 // 页码字体，多页共用
+	pageDicts := make([]string, len(pages))
+	contentData := make([][]byte, len(pages))
+	for pi, page := range pages {
+		c := page.content
+		var stream bytes.Buffer
+		fmt.Fprintf(&stream, "q\n%s 0 0 %s 0 0 cm\n", svgNum(scale), svgNum(scale))
+		stream.Write(c.buf.Bytes())
+		stream.WriteString("Q\n")
+		data, err := flateEncode(stream.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		contentData[pi] = data
+
+		var xobjects strings.Builder
+		for i, f := range c.forms {
+			key := pdfForm{File: f.File, Page: f.Page}
+			ref, ok := imported[key]
+			if !ok {
+				im, ok := importers[f.File]
+				if !ok {
+					ctx, err := readContext(f.File)
+					if err != nil {
+						return nil, fmt.Errorf("读取 %s 失败: %v", filepath.Base(f.File), err)
+					}
+					im = newPDFImporter(w, ctx)
+					importers[f.File] = im
+				}
+				ref, err = im.importPage(f.Page, f.BBox)
+				if err != nil {
+					return nil, fmt.Errorf("导入 %s 第%d页失败: %v", filepath.Base(f.File), f.Page, err)
+				}
+				imported[key] = ref
+			}
+			fmt.Fprintf(&xobjects, " /Fm%d %d 0 R", i+1, ref)
+		}
+		for i, img := range c.images {
+			ref, err := w.writeImage(img)
+			if err != nil {
+				return nil, fmt.Errorf("编码图像失败: %v", err)
+			}
+			fmt.Fprintf(&xobjects, " /Im%d %d 0 R", i+1, ref)
+		}
+
+		var extGState strings.Builder
+		for i, a := range c.alphas {
+			fmt.Fprintf(&extGState, " /GS%d << /Type /ExtGState /CA %s /ca %s >>", i+1, svgNum(a[0]), svgNum(a[1]))
+		}
+		fonts := ""
+		if c.font {
+			if fontObj == 0 {
+				fontObj = w.writeHelvetica()
+			}
+			fonts = fmt.Sprintf(" /Font << /F1 %d 0 R >>", fontObj)
+		}
+
+		pageDicts[pi] = fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] "+
+			"/Resources << /ProcSet [/PDF /Text /ImageB /ImageC /ImageI] /XObject <<%s >> /ExtGState <<%s >>%s >> /Contents %d 0 R >>",
+			pagesObj, svgNum(float64(page.width)*scale), svgNum(float64(page.height)*scale),
+			xobjects.String(), extGState.String(), fonts, contentObjs[pi])
+	}
+
+	w.writeObject(catalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesObj))
+	w.writeObject(pagesObj, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	for i := range pages {
+		w.writeObject(pageObjs[i], pageDicts[i])
+		w.writeStream(contentObjs[i], "/Filter /FlateDecode", contentData[i])
+	}
+
+	return w.finish(catalog), nil
+}
+
+// PDF对象写入器，记录每个对象的偏移量以生成交叉引用表
+type pdfWriter struct {
+	buf     bytes.Buffer
+	offsets []int // 下标为对象号减一
+}
+
+func newPDFWriter() *pdfWriter {
+	w := &pdfWriter{}
+	// 文件头后的注释行含二进制字符，提示传输工具按二进制处理
+	// 导入的源页面可能使用较新的特性，按1.7版本输出
+	w.buf.WriteString("%PDF-1.7\n%\xe2\xe3\xcf\xd3\n")
+	return w
+}
+
+// 分配一个对象号
+func (w *pdfWriter) newObject() int {
+	w.offsets = append(w.offsets, 0)
+	return len(w.offsets)
+}
+
+// 写入对象
+func (w *pdfWriter) writeObject(num int, body string) {
+	w.offsets[num-1] = w.buf.Len()
+	fmt.Fprintf(&w.buf, "%d 0 obj\n%s\nendobj\n", num, body)
+}
+
+// 写入流对象，dict为除Length以外的字典项
+func (w *pdfWriter) writeStream(num int, dict string, data []byte) {
+	w.offsets[num-1] = w.buf.Len()
+	fmt.Fprintf(&w.buf, "%d 0 obj\n<< /Length %d %s >>\nstream\n", num, len(data), dict)
+	w.buf.Write(data)
+	w.buf.WriteString("\nendstream\nendobj\n")
+}
+
+// 写入字典形式的流对象，自动设置Length
+func (w *pdfWriter) writeStreamDict(num int, d types.Dict, data []byte) {
+	d["Length"] = types.Integer(len(data))
+	w.offsets[num-1] = w.buf.Len()
+	fmt.Fprintf(&w.buf, "%d 0 obj\n%s\nstream\n", num, d.PDFString())
+	w.buf.Write(data)
+	w.buf.WriteString("\nendstream\nendobj\n")
+}
+
+// 写入图像对象：RGB数据Flate压缩，存在透明像素时附带SMask
+func (w *pdfWriter) writeImage(img image.Image) (int, error) {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	rgb := make([]byte, 0, width*height*3)
+	alpha := make([]byte, 0, width*height)
+	opaque := true
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			rgb = append(rgb, c.R, c.G, c.B)
+			alpha = append(alpha, c.A)
+			if c.A != 255 {
+				opaque = false
+			}
+		}
+	}
+
+	rgbData, err := flateEncode(rgb)
+	if err != nil {
+		return 0, err
+	}
+
+	smask := ""
+	if !opaque {
+		alphaData, err := flateEncode(alpha)
+		if err != nil {
+			return 0, err
+		}
+		ref := w.newObject()
+		w.writeStream(ref, fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d "+
+			"/ColorSpace /DeviceGray /BitsPerComponent 8 /Filter /FlateDecode", width, height), alphaData)
+		smask = fmt.Sprintf(" /SMask %d 0 R", ref)
+	}
+
+	num := w.newObject()
+	w.writeStream(num, fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d "+
+		"/ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /FlateDecode%s", width, height, smask), rgbData)
+	return num, nil
+}
+
+// 写入交叉引用表和文件尾，返回完整文档
+func (w *pdfWriter) finish(root int) []byte {
+	xref := w.buf.Len()
+	fmt.Fprintf(&w.buf, "xref\n0 %d\n0000000000 65535 f\r\n", len(w.offsets)+1)
+	for _, off := range w.offsets {
+		fmt.Fprintf(&w.buf, "%010d 00000 n\r\n", off)
+	}
+	fmt.Fprintf(&w.buf, "trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(w.offsets)+1, root, xref)
+	return w.buf.Bytes()
+}
+
+func flateEncode(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,159 @@
+package output
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+
+	"pdf-vector-extractor/extract"
+)
+
+// 源PDF对象导入器：把页面及其引用的字体、图像、色彩空间等对象复制到输出文档
+// 同一源对象只复制一次，对象号重新分配
+type pdfImporter struct {
+	w      *pdfWriter
+	ctx    *model.Context
+	copied map[int]int // 源对象号 -> 输出对象号
+}
+
+func newPDFImporter(w *pdfWriter, ctx *model.Context) *pdfImporter {
+	return &pdfImporter{w: w, ctx: ctx, copied: make(map[int]int)}
+}
+
+// 将源PDF的一页导入为Form XObject，bbox为页面可见区域，返回对象号
+func (im *pdfImporter) importPage(pageNr int, bbox extract.PageBox) (int, error) {
+	pageDict, _, inhAttrs, err := im.ctx.PageDict(pageNr, false)
+	if err != nil {
+		return 0, err
+	}
+	if pageDict == nil {
+		return 0, fmt.Errorf("找不到第 %d 页", pageNr)
+	}
+
+	content, err := im.ctx.PageContent(pageDict)
+	if err != nil && !errors.Is(err, model.ErrNoContent) {
+		return 0, err
+	}
+	data, err := flateEncode(content)
+	if err != nil {
+		return 0, err
+	}
+
+	form := types.Dict{
+		"Type":      types.Name("XObject"),
+		"Subtype":   types.Name("Form"),
+		"FormType":  types.Integer(1),
+		"BBox":      types.Array{types.Float(bbox.LLX), types.Float(bbox.LLY), types.Float(bbox.URX), types.Float(bbox.URY)},
+		"Resources": types.Dict{},
+		"Filter":    types.Name("FlateDecode"),
+	}
+	if inhAttrs.Resources != nil {
+		res, err := im.copyObject(inhAttrs.Resources)
+		if err != nil {
+			return 0, fmt.Errorf("复制页面资源失败: %v", err)
+		}
+		form["Resources"] = res
+	}
+	// 保留页面的透明度组，使混合效果与原页面一致
+	if group, found := pageDict.Find("Group"); found {
+		g, err := im.copyObject(group)
+		if err != nil {
+			return 0, fmt.Errorf("复制透明度组失败: %v", err)
+		}
+		form["Group"] = g
+	}
+
+	num := im.w.newObject()
+	im.w.writeStreamDict(num, form, data)
+	return num, nil
+}
+
+// 深度复制对象，间接引用指向的对象一并写入输出文档
+func (im *pdfImporter) copyObject(o types.Object) (types.Object, error) {
+	switch o := o.(type) {
+	case types.IndirectRef:
+		return im.copyRef(o)
+	case *types.IndirectRef:
+		return im.copyRef(*o)
+	case types.Dict:
+		// 按键名顺序复制，使输出对象编号稳定
+		keys := make([]string, 0, len(o))
+		for k := range o {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		d := types.Dict{}
+		for _, k := range keys {
+			v := o[k]
+			// 不复制指向页面树的引用
+			if v == nil || k == "Parent" {
+				continue
+			}
+			c, err := im.copyObject(v)
+			if err != nil {
+				return nil, err
+			}
+			d[k] = c
+		}
+		return d, nil
+	case types.Array:
+		a := make(types.Array, len(o))
+		for i, v := range o {
+			if v == nil {
+				continue
+			}
+			c, err := im.copyObject(v)
+			if err != nil {
+				return nil, err
+			}
+			a[i] = c
+		}
+		return a, nil
+	}
+	return o, nil
+}
+
+// 复制间接对象，返回指向输出文档中新对象的引用
+func (im *pdfImporter) copyRef(ref types.IndirectRef) (types.Object, error) {
+	srcNr := ref.ObjectNumber.Value()
+	if num, ok := im.copied[srcNr]; ok {
+		return *types.NewIndirectRef(num, 0), nil
+	}
+
+	num := im.w.newObject()
+	im.copied[srcNr] = num
+
+	obj, err := im.ctx.Dereference(ref)
+	if err != nil {
+		return nil, fmt.Errorf("读取对象 %d 失败: %v", srcNr, err)
+	}
+
+	switch obj := obj.(type) {
+	case nil:
+		im.w.writeObject(num, "null")
+	case types.StreamDict:
+		// 保持原有编码，只重写长度
+		d := types.Dict{}
+		for k, v := range obj.Dict {
+			if k != "Length" {
+				d[k] = v
+			}
+		}
+		c, err := im.copyObject(d)
+		if err != nil {
+			return nil, err
+		}
+		im.w.writeStreamDict(num, c.(types.Dict), obj.Raw)
+	default:
+		c, err := im.copyObject(obj)
+		if err != nil {
+			return nil, err
+		}
+		im.w.writeObject(num, c.PDFString())
+	}
+	return *types.NewIndirectRef(num, 0), nil
+}
@@ -0,0 +1,108 @@
+package output
+
+import (
+	"context"
+	"image/color"
+	"path/filepath"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+
+	"pdf-vector-extractor/extract"
+	"pdf-vector-extractor/layout"
+)
+
+// 读取输出PDF第pageNr页引用的XObject，按子类型返回对象号
+func pdfXObjects(t *testing.T, ctx *model.Context, pageNr int) map[string][]int {
+	t.Helper()
+	page, _, _, err := ctx.PageDict(pageNr, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := ctx.DereferenceDict(page["Resources"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	xobjects, err := ctx.DereferenceDict(res["XObject"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	objs := make(map[string][]int)
+	for _, o := range xobjects {
+		ref, ok := o.(types.IndirectRef)
+		if !ok {
+			t.Fatalf("XObject应为间接引用: %v", o)
+		}
+		sd, _, err := ctx.DereferenceStreamDict(ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+		objs[*sd.Subtype()] = append(objs[*sd.Subtype()], ref.ObjectNumber.Value())
+	}
+	return objs
+}
+
+func TestSavePDF(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.pdf")
+	sheet := testSheet(t, color.RGBA{255, 240, 200, 128})
+	if err := SavePDF(context.Background(), sheet, path, Options{OutputDPI: 144}); err != nil {
+		t.Fatal(err)
+	}
+
+	conf := model.NewDefaultConfiguration()
+	conf.ValidationMode = model.ValidationStrict
+	if err := api.ValidateFile(path, conf); err != nil {
+		t.Fatalf("输出未通过严格校验: %v", err)
+	}
+
+	ctx, err := extract.ReadContext(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 144DPI下300x100像素对应150x50点
+	dims, err := ctx.PageDims()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dims) != 1 || dims[0].Width != 150 || dims[0].Height != 50 {
+		t.Errorf("页面尺寸 %v, 期望 150x50", dims)
+	}
+
+	// 同一源页面的两个图块共用一个Form XObject，位图图块嵌入图像
+	objs := pdfXObjects(t, ctx, 1)
+	if len(objs["Form"]) != 1 || len(objs["Image"]) != 1 {
+		t.Errorf("XObject %v, 期望1个Form和1个Image", objs)
+	}
+}
+
+func TestSavePDFPagesSharesForms(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.pdf")
+	sheet := testSheet(t, layout.White)
+	sheets := []*layout.Sheet{sheet, sheet}
+	if err := SavePDFPages(context.Background(), sheets, path, Options{}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, err := extract.ReadContext(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ctx.PageCount != 2 {
+		t.Fatalf("页数 %d, 期望 2", ctx.PageCount)
+	}
+	// 默认分辨率下像素按渲染分辨率换算为点
+	dims, err := ctx.PageDims()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 300 * 72 / float64(extract.DefaultRenderDPI); dims[0].Width != want {
+		t.Errorf("页面宽度 %v, 期望 %v", dims[0].Width, want)
+	}
+	// 同一源页面在多页中只导入一次
+	first, second := pdfXObjects(t, ctx, 1)["Form"], pdfXObjects(t, ctx, 2)["Form"]
+	if len(first) != 1 || len(second) != 1 || first[0] != second[0] {
+		t.Errorf("两页的Form XObject %v, %v, 期望共用同一对象", first, second)
+	}
+}
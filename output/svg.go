@@ -0,0 +1,277 @@
+package output
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"pdf-vector-extractor/extract"
+	"pdf-vector-extractor/layout"
+)
+
+// SVG输出模块：矢量来源的图块输出原始路径，位图来源的图块输出描摹路径（未启用描摹时嵌入PNG）
+func SaveSVG(ctx context.Context, sheet *layout.Sheet, outputPath string, opts Options) error {
+	bounds := sheet.Image.Bounds()
+	width := bounds.Dx()
+	height := bounds.Dy()
+
+	// 创建SVG文件
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := bufio.NewWriter(file)
+
+	// 写入SVG头部
+	fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?>
+<svg width="%d" height="%d" viewBox="0 0 %d %d" xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink">
+`, width, height, width, height)
+	writeSVGBackground(w, sheet.Background, width, height)
+
+	for i, tile := range sheet.Tiles {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := writeSVGTile(w, sheet, i, opts); err != nil {
+			return fmt.Errorf("写入图块 %s 失败: %v", tile.Name(), err)
+		}
+	}
+
+	writeSVGMarks(w, sheet)
+
+	// 写入SVG尾部
+	fmt.Fprintf(w, "</svg>\n")
+
+	return w.Flush()
+}
+
+// 写入背景矩形，透明背景不写入
+func writeSVGBackground(w *bufio.Writer, bg color.RGBA, width, height int) {
+	if bg.A == 0 {
+		return
+	}
+	fmt.Fprintf(w, `  <rect width="%d" height="%d" fill="%s"`, width, height, svgColor(unpremultiply(bg)))
+	if bg.A < 255 {
+		fmt.Fprintf(w, ` fill-opacity="%s"`, svgNum(float64(bg.A)/255))
+	}
+	w.WriteString("/>\n")
+}
+
+// 写入裁切线和页码
+func writeSVGMarks(w *bufio.Writer, sheet *layout.Sheet) {
+	markColor := svgColor(layout.MarkColor)
+	for _, l := range sheet.Lines {
+		fmt.Fprintf(w, "  <line x1=\"%d\" y1=\"%d\" x2=\"%d\" y2=\"%d\" stroke=\"%s\" stroke-width=\"%s\"/>\n",
+			l.From.X, l.From.Y, l.To.X, l.To.Y, markColor, svgNum(l.Width))
+	}
+	for _, label := range sheet.Labels {
+		fmt.Fprintf(w, "  <text x=\"%d\" y=\"%d\" font-family=\"Helvetica, Arial, sans-serif\" font-size=\"%s\" text-anchor=\"middle\" fill=\"%s\">%s</text>\n",
+			label.At.X, label.At.Y, svgNum(label.Size), markColor, xmlEscape(label.Text))
+	}
+}
+
+// 预乘透明度的颜色还原为原始颜色
+func unpremultiply(c color.RGBA) color.RGBA {
+	if c.A == 0 || c.A == 255 {
+		return c
+	}
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return color.RGBA{n.R, n.G, n.B, n.A}
+}
+
+// 写入单个图块，裁剪到其在单元格中的可见区域
+func writeSVGTile(w *bufio.Writer, sheet *layout.Sheet, i int, opts Options) error {
+	tile := sheet.Tiles[i]
+	cell := sheet.Cells[i]
+	visible := tile.VisibleRect().Add(cell.Min)
+	tileID := fmt.Sprintf("tile%d", i+1)
+
+	fmt.Fprintf(w, `  <g id="%s" data-name="%s" data-source="%s" data-page="%d">
+    <clipPath id="%s-clip"><rect x="%d" y="%d" width="%d" height="%d"/></clipPath>
+`, tileID, xmlEscape(tile.Name()), xmlEscape(filepath.Base(tile.SourceFile)), tile.PageNumber,
+		tileID, visible.Min.X, visible.Min.Y, visible.Dx(), visible.Dy())
+
+	paths, m, ok := tileVectors(sheet, i, opts)
+	if !ok {
+		// 位图来源且未启用描摹：嵌入裁剪后的图块
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, tile.Image); err != nil {
+			return err
+		}
+		tb := tile.Image.Bounds()
+		fmt.Fprintf(w, `    <image x="%d" y="%d" width="%d" height="%d" clip-path="url(#%s-clip)" xlink:href="data:image/png;base64,%s"/>
+`, cell.Min.X, cell.Min.Y, tb.Dx(), tb.Dy(), tileID, base64.StdEncoding.EncodeToString(buf.Bytes()))
+		fmt.Fprintf(w, "  </g>\n")
+		return nil
+	}
+
+	fmt.Fprintf(w, `    <g clip-path="url(#%s-clip)">
+      <g transform="%s">
+`, tileID, svgMatrix(m))
+
+	// 裁剪路径定义，同一裁剪状态只定义一次
+	clipIDs := make(map[*extract.ClipPath]string)
+	for pi := range paths {
+		for ci := range paths[pi].Clips {
+			cp := &paths[pi].Clips[ci]
+			if _, ok := clipIDs[cp]; ok {
+				continue
+			}
+			id := fmt.Sprintf("%s-c%d", tileID, len(clipIDs)+1)
+			clipIDs[cp] = id
+			rule := "nonzero"
+			if cp.EvenOdd {
+				rule = "evenodd"
+			}
+			fmt.Fprintf(w, `        <clipPath id="%s" clipPathUnits="userSpaceOnUse"><path transform="%s" d="%s" clip-rule="%s"/></clipPath>
+`, id, svgMatrix(cp.CTM), svgPathData(cp.Segments), rule)
+		}
+	}
+
+	for pi := range paths {
+		p := &paths[pi]
+
+		// 多个裁剪路径通过嵌套分组取交集
+		for ci := range p.Clips {
+			fmt.Fprintf(w, `        <g clip-path="url(#%s)">`, clipIDs[&p.Clips[ci]])
+		}
+		fmt.Fprintf(w, `        <path transform="%s" d="%s"%s/>`, svgMatrix(p.CTM), svgPathData(p.Segments), svgPaintAttrs(p))
+		for range p.Clips {
+			fmt.Fprintf(w, "</g>")
+		}
+		fmt.Fprintf(w, "\n")
+	}
+
+	fmt.Fprintf(w, "      </g>\n    </g>\n  </g>\n")
+	return nil
+}
+
+// 路径的填充与描边属性
+func svgPaintAttrs(p *extract.VectorPath) string {
+	var sb strings.Builder
+	if p.Fill {
+		fmt.Fprintf(&sb, ` fill="%s"`, svgColor(p.FillColor))
+		if p.EvenOdd {
+			sb.WriteString(` fill-rule="evenodd"`)
+		}
+		if p.FillAlpha < 1 {
+			fmt.Fprintf(&sb, ` fill-opacity="%s"`, svgNum(p.FillAlpha))
+		}
+	} else {
+		sb.WriteString(` fill="none"`)
+	}
+
+	if p.Stroke {
+		fmt.Fprintf(&sb, ` stroke="%s" stroke-width="%s"`, svgColor(p.StrokeColor), svgNum(p.LineWidth))
+		if caps := []string{"butt", "round", "square"}; p.LineCap > 0 && p.LineCap < len(caps) {
+			fmt.Fprintf(&sb, ` stroke-linecap="%s"`, caps[p.LineCap])
+		}
+		if joins := []string{"miter", "round", "bevel"}; p.LineJoin > 0 && p.LineJoin < len(joins) {
+			fmt.Fprintf(&sb, ` stroke-linejoin="%s"`, joins[p.LineJoin])
+		}
+		if p.LineJoin == 0 && p.MiterLimit != 4 {
+			fmt.Fprintf(&sb, ` stroke-miterlimit="%s"`, svgNum(math.Max(p.MiterLimit, 1)))
+		}
+		if len(p.Dash) > 0 {
+			parts := make([]string, len(p.Dash))
+			for i, d := range p.Dash {
+				parts[i] = svgNum(d)
+			}
+			fmt.Fprintf(&sb, ` stroke-dasharray="%s"`, strings.Join(parts, " "))
+			if p.DashPhase != 0 {
+				fmt.Fprintf(&sb, ` stroke-dashoffset="%s"`, svgNum(p.DashPhase))
+			}
+		}
+		if p.StrokeAlpha < 1 {
+			fmt.Fprintf(&sb, ` stroke-opacity="%s"`, svgNum(p.StrokeAlpha))
+		}
+	}
+	return sb.String()
+}
+
+// 路径段转换为SVG路径数据
+func svgPathData(segments []extract.PathSegment) string {
+	var sb strings.Builder
+	for _, seg := range segments {
+		switch seg.Op {
+		case extract.SegmentMoveTo:
+			fmt.Fprintf(&sb, "M%s %s", svgNum(seg.Points[0].X), svgNum(seg.Points[0].Y))
+		case extract.SegmentLineTo:
+			fmt.Fprintf(&sb, "L%s %s", svgNum(seg.Points[0].X), svgNum(seg.Points[0].Y))
+		case extract.SegmentCubicTo:
+			fmt.Fprintf(&sb, "C%s %s %s %s %s %s",
+				svgNum(seg.Points[0].X), svgNum(seg.Points[0].Y),
+				svgNum(seg.Points[1].X), svgNum(seg.Points[1].Y),
+				svgNum(seg.Points[2].X), svgNum(seg.Points[2].Y))
+		case extract.SegmentClose:
+			sb.WriteString("Z")
+		}
+	}
+	return sb.String()
+}
+
+func svgMatrix(m extract.Matrix) string {
+	return fmt.Sprintf("matrix(%s %s %s %s %s %s)",
+		svgNum(m[0]), svgNum(m[1]), svgNum(m[2]), svgNum(m[3]), svgNum(m[4]), svgNum(m[5]))
+}
+
+func svgColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// 数字格式化，最多保留4位小数
+func svgNum(v float64) string {
+	s := strconv.FormatFloat(v, 'f', 4, 64)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "-0" || s == "" {
+		return "0"
+	}
+	return s
+}
+
+func xmlEscape(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+// 将整幅位图描摹后输出为SVG
+func SaveTracedSVG(img image.Image, outputPath string, trace TraceOptions) error {
+	bounds := img.Bounds()
+	width := bounds.Dx()
+	height := bounds.Dy()
+
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := bufio.NewWriter(file)
+
+	// 写入SVG头部
+	fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?>
+<svg width="%d" height="%d" viewBox="0 0 %d %d" xmlns="http://www.w3.org/2000/svg">
+  <rect width="%d" height="%d" fill="#ffffff"/>
+`, width, height, width, height, width, height)
+
+	for _, p := range TraceImage(img, trace) {
+		fmt.Fprintf(w, "  <path d=\"%s\"%s/>\n", svgPathData(p.Segments), svgPaintAttrs(&p))
+	}
+
+	fmt.Fprintf(w, "</svg>\n")
+	return w.Flush()
+}
@@ -0,0 +1,82 @@
+package output
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"pdf-vector-extractor/layout"
+)
+
+// 解析SVG文件，返回每种元素的数量和根元素属性
+func parseSVG(t *testing.T, path string) (map[string]int, map[string]string) {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	counts := make(map[string]int)
+	root := make(map[string]string)
+	dec := xml.NewDecoder(file)
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("SVG不是有效的XML: %v", err)
+		}
+		if se, ok := tok.(xml.StartElement); ok {
+			if len(counts) == 0 {
+				for _, attr := range se.Attr {
+					root[attr.Name.Local] = attr.Value
+				}
+			}
+			counts[se.Name.Local]++
+		}
+	}
+	return counts, root
+}
+
+func TestSaveSVG(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.svg")
+	if err := SaveSVG(context.Background(), testSheet(t, layout.White), path, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	counts, root := parseSVG(t, path)
+	if counts["svg"] != 1 || root["width"] != "300" || root["height"] != "100" || root["viewBox"] != "0 0 300 100" {
+		t.Errorf("根元素 %v", root)
+	}
+	// 两个矢量图块各有填充和描边两条路径，位图图块嵌入图像
+	if counts["path"] != 4 {
+		t.Errorf("路径数量 %d, 期望 4", counts["path"])
+	}
+	if counts["image"] != 1 {
+		t.Errorf("图像数量 %d, 期望 1", counts["image"])
+	}
+	if counts["clipPath"] != 3 {
+		t.Errorf("图块裁剪区域数量 %d, 期望 3", counts["clipPath"])
+	}
+}
+
+func TestSaveSVGTraced(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.svg")
+	opts := Options{TraceRaster: true, Trace: DefaultTraceOptions()}
+	if err := SaveSVG(context.Background(), testSheet(t, layout.White), path, opts); err != nil {
+		t.Fatal(err)
+	}
+	counts, _ := parseSVG(t, path)
+	// 启用描摹后位图图块输出为路径
+	if counts["image"] != 0 {
+		t.Errorf("描摹后仍嵌入了 %d 个图像", counts["image"])
+	}
+	if counts["path"] <= 4 {
+		t.Errorf("路径数量 %d, 期望包含描摹路径", counts["path"])
+	}
+}
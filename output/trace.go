@@ -0,0 +1,311 @@
+package output
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"pdf-vector-extractor/extract"
+	"pdf-vector-extractor/layout"
+)
+
+const (
+	// 默认描摹参数
+	DefaultTraceThreshold   = 128
+	DefaultTraceCornerAngle = 60.0
+	DefaultTraceSpeckle     = 4
+
+	// 折线简化容差（像素）
+	traceSimplifyTolerance = 0.9
+)
+
+// 位图描摹参数
+type TraceOptions struct {
+	Threshold   int     // 亮度低于此值的像素视为前景（0-255）
+	CornerAngle float64 // 转角大于此角度（度）的顶点保持为尖角，其余平滑为曲线
+	Speckle     int     // 面积小于此值（像素）的轮廓视为噪点丢弃
+}
+
+// 默认描摹参数
+func DefaultTraceOptions() TraceOptions {
+	return TraceOptions{
+		Threshold:   DefaultTraceThreshold,
+		CornerAngle: DefaultTraceCornerAngle,
+		Speckle:     DefaultTraceSpeckle,
+	}
+}
+
+// 将无效的参数替换为默认值
+func (opts TraceOptions) normalize() TraceOptions {
+	if opts.Threshold <= 0 || opts.Threshold > 255 {
+		opts.Threshold = DefaultTraceThreshold
+	}
+	if opts.CornerAngle <= 0 {
+		opts.CornerAngle = DefaultTraceCornerAngle
+	}
+	if opts.Speckle < 0 {
+		opts.Speckle = 0
+	}
+	return opts
+}
+
+// 将位图描摹为填充路径，坐标为图像像素空间
+func TraceImage(img image.Image, opts TraceOptions) []extract.VectorPath {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w == 0 || h == 0 {
+		return nil
+	}
+	opts = opts.normalize()
+
+	// 二值化，并统计前景平均颜色
+	bitmap := make([]bool, w*h)
+	var sumR, sumG, sumB, count int
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			r, g, b, a := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			// 按白色背景合成透明像素
+			bg := 0xffff - a
+			r, g, b = r+bg, g+bg, b+bg
+			luma := (299*r + 587*g + 114*b) / 1000 >> 8
+			if int(luma) < opts.Threshold {
+				bitmap[y*w+x] = true
+				sumR += int(r >> 8)
+				sumG += int(g >> 8)
+				sumB += int(b >> 8)
+				count++
+			}
+		}
+	}
+	if count == 0 {
+		return nil
+	}
+
+	var segments []extract.PathSegment
+	for _, loop := range traceContours(bitmap, w, h) {
+		if math.Abs(polygonArea(loop)) < float64(opts.Speckle) {
+			continue
+		}
+		poly := simplifyClosedPolygon(loop, traceSimplifyTolerance)
+		if len(poly) < 3 {
+			continue
+		}
+		segments = append(segments, smoothPolygon(poly, opts.CornerAngle)...)
+	}
+	if len(segments) == 0 {
+		return nil
+	}
+
+	fill := color.RGBA{uint8(sumR / count), uint8(sumG / count), uint8(sumB / count), 255}
+	return []extract.VectorPath{{
+		Segments:  segments,
+		CTM:       extract.IdentityMatrix,
+		Fill:      true,
+		FillColor: fill,
+		FillAlpha: 1,
+	}}
+}
+
+// 沿像素边界追踪前景轮廓，返回格点坐标的闭合多边形
+// 外轮廓与孔洞方向相反，可直接用非零规则填充
+func traceContours(bitmap []bool, w, h int) [][]extract.VectorPoint {
+	fg := func(x, y int) bool {
+		return x >= 0 && y >= 0 && x < w && y < h && bitmap[y*w+x]
+	}
+
+	// 方向：东、南、西、北（屏幕坐标，顺时针）
+	dx := [4]int{1, 0, -1, 0}
+	dy := [4]int{0, 1, 0, -1}
+
+	// 每个格点的出边掩码，前景始终位于行进方向右侧
+	stride := w + 1
+	out := make([]uint8, stride*(h+1))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if !bitmap[y*w+x] {
+				continue
+			}
+			if !fg(x, y-1) {
+				out[y*stride+x] |= 1 << 0 // 上边向东
+			}
+			if !fg(x+1, y) {
+				out[y*stride+x+1] |= 1 << 1 // 右边向南
+			}
+			if !fg(x, y+1) {
+				out[(y+1)*stride+x+1] |= 1 << 2 // 下边向西
+			}
+			if !fg(x-1, y) {
+				out[(y+1)*stride+x] |= 1 << 3 // 左边向北
+			}
+		}
+	}
+
+	var loops [][]extract.VectorPoint
+	for start := range out {
+		for out[start] != 0 {
+			sx, sy := start%stride, start/stride
+			x, y := sx, sy
+
+			// 起始方向取最低位
+			dir := 0
+			for out[start]&(1<<dir) == 0 {
+				dir++
+			}
+
+			var loop []extract.VectorPoint
+			prevDir := -1
+			for {
+				v := y*stride + x
+				out[v] &^= 1 << dir
+				if dir != prevDir {
+					loop = append(loop, extract.VectorPoint{X: float64(x), Y: float64(y)})
+				}
+				prevDir = dir
+				x, y = x+dx[dir], y+dy[dir]
+
+				next := y*stride + x
+				if out[next] == 0 {
+					break
+				}
+				// 优先左转，使对角相接的像素连成一体
+				for _, turn := range [3]int{3, 0, 1} {
+					d := (dir + turn) % 4
+					if out[next]&(1<<d) != 0 {
+						dir = d
+						break
+					}
+				}
+			}
+
+			// 若回到起点时方向未变，去掉重复的起点
+			if len(loop) > 1 && x == sx && y == sy && dir == prevDir {
+				loop = loop[1:]
+			}
+			if len(loop) >= 3 {
+				loops = append(loops, loop)
+			}
+		}
+	}
+	return loops
+}
+
+// 多边形有向面积
+func polygonArea(pts []extract.VectorPoint) float64 {
+	area := 0.0
+	for i := range pts {
+		a, b := pts[i], pts[(i+1)%len(pts)]
+		area += a.X*b.Y - b.X*a.Y
+	}
+	return area / 2
+}
+
+// 用Douglas-Peucker算法简化闭合多边形
+func simplifyClosedPolygon(pts []extract.VectorPoint, tolerance float64) []extract.VectorPoint {
+	if len(pts) <= 4 {
+		return pts
+	}
+
+	// 以距离起点最远的顶点把环拆成两段
+	far, farDist := 0, -1.0
+	for i, p := range pts {
+		d := math.Hypot(p.X-pts[0].X, p.Y-pts[0].Y)
+		if d > farDist {
+			far, farDist = i, d
+		}
+	}
+
+	first := append([]extract.VectorPoint(nil), pts[:far+1]...)
+	second := append(append([]extract.VectorPoint(nil), pts[far:]...), pts[0])
+
+	a := douglasPeucker(first, tolerance)
+	b := douglasPeucker(second, tolerance)
+
+	// 拼接时去掉重复的端点
+	result := append(a[:len(a)-1], b[:len(b)-1]...)
+	return result
+}
+
+func douglasPeucker(pts []extract.VectorPoint, tolerance float64) []extract.VectorPoint {
+	if len(pts) <= 2 {
+		return pts
+	}
+	a, b := pts[0], pts[len(pts)-1]
+	idx, maxDist := 0, 0.0
+	for i := 1; i < len(pts)-1; i++ {
+		d := pointSegmentDistance(pts[i], a, b)
+		if d > maxDist {
+			idx, maxDist = i, d
+		}
+	}
+	if maxDist <= tolerance {
+		return []extract.VectorPoint{a, b}
+	}
+	left := douglasPeucker(pts[:idx+1], tolerance)
+	right := douglasPeucker(pts[idx:], tolerance)
+	return append(left[:len(left)-1], right...)
+}
+
+// 点到线段的距离
+func pointSegmentDistance(p, a, b extract.VectorPoint) float64 {
+	vx, vy := b.X-a.X, b.Y-a.Y
+	l2 := vx*vx + vy*vy
+	if l2 == 0 {
+		return math.Hypot(p.X-a.X, p.Y-a.Y)
+	}
+	t := ((p.X-a.X)*vx + (p.Y-a.Y)*vy) / l2
+	t = math.Max(0, math.Min(1, t))
+	return math.Hypot(p.X-(a.X+t*vx), p.Y-(a.Y+t*vy))
+}
+
+// 将多边形平滑为三次贝塞尔曲线：曲线经过各边中点，转角大于阈值的顶点保持尖角
+func smoothPolygon(poly []extract.VectorPoint, cornerAngle float64) []extract.PathSegment {
+	n := len(poly)
+	mid := func(i int) extract.VectorPoint {
+		a, b := poly[i%n], poly[(i+1)%n]
+		return extract.VectorPoint{X: (a.X + b.X) / 2, Y: (a.Y + b.Y) / 2}
+	}
+
+	segments := []extract.PathSegment{{Op: extract.SegmentMoveTo, Points: []extract.VectorPoint{mid(n - 1)}}}
+	for i := 0; i < n; i++ {
+		prev, v, next := poly[(i-1+n)%n], poly[i], poly[(i+1)%n]
+		m0, m1 := mid(i-1+n), mid(i)
+
+		ax, ay := v.X-prev.X, v.Y-prev.Y
+		bx, by := next.X-v.X, next.Y-v.Y
+		cos := (ax*bx + ay*by) / (math.Hypot(ax, ay)*math.Hypot(bx, by) + 1e-12)
+		turn := math.Acos(math.Max(-1, math.Min(1, cos))) * 180 / math.Pi
+
+		if turn > cornerAngle {
+			segments = append(segments,
+				extract.PathSegment{Op: extract.SegmentLineTo, Points: []extract.VectorPoint{v}},
+				extract.PathSegment{Op: extract.SegmentLineTo, Points: []extract.VectorPoint{m1}},
+			)
+			continue
+		}
+
+		// 以顶点为控制点的二次曲线升阶为三次曲线
+		c1 := extract.VectorPoint{X: m0.X + 2.0/3*(v.X-m0.X), Y: m0.Y + 2.0/3*(v.Y-m0.Y)}
+		c2 := extract.VectorPoint{X: m1.X + 2.0/3*(v.X-m1.X), Y: m1.Y + 2.0/3*(v.Y-m1.Y)}
+		segments = append(segments, extract.PathSegment{Op: extract.SegmentCubicTo, Points: []extract.VectorPoint{c1, c2, m1}})
+	}
+	segments = append(segments, extract.PathSegment{Op: extract.SegmentClose})
+	return segments
+}
+
+// 图块的矢量内容：矢量来源直接使用原始路径，位图来源在启用描摹时描摹
+// 返回路径及其到拼接结果像素空间的变换；无法矢量化时ok为false
+func tileVectors(sheet *layout.Sheet, i int, opts Options) (paths []extract.VectorPath, m extract.Matrix, ok bool) {
+	tile := sheet.Tiles[i]
+	if tile.Vector != nil && len(tile.Vector.Images) == 0 {
+		return tile.Vector.Paths, sheet.TileMatrix(i), true
+	}
+	if tile.Vector != nil {
+		// 路径与位图混合的页面无法只用路径表示，嵌入渲染结果
+		return nil, extract.Matrix{}, false
+	}
+	if !opts.TraceRaster {
+		return nil, extract.Matrix{}, false
+	}
+	cell := sheet.Cells[i].Min
+	return TraceImage(tile.Image, opts.Trace), extract.Matrix{1, 0, 0, 1, float64(cell.X), float64(cell.Y)}, true
+}
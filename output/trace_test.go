@@ -0,0 +1,106 @@
+package output
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"testing"
+
+	"pdf-vector-extractor/extract"
+)
+
+// 按路径段统计子路径数量、曲线段数量和所有点的范围
+func traceStats(segments []extract.PathSegment) (subpaths, curves int, bounds [4]float64) {
+	bounds = [4]float64{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}
+	for _, seg := range segments {
+		switch seg.Op {
+		case extract.SegmentMoveTo:
+			subpaths++
+		case extract.SegmentCubicTo:
+			curves++
+		}
+		for _, p := range seg.Points {
+			bounds = [4]float64{math.Min(bounds[0], p.X), math.Min(bounds[1], p.Y), math.Max(bounds[2], p.X), math.Max(bounds[3], p.Y)}
+		}
+	}
+	return subpaths, curves, bounds
+}
+
+func TestTraceImage(t *testing.T) {
+	newImage := func() *image.RGBA {
+		img := image.NewRGBA(image.Rect(0, 0, 60, 60))
+		draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+		return img
+	}
+	red := color.RGBA{200, 0, 0, 255}
+
+	t.Run("直角保持尖角", func(t *testing.T) {
+		img := newImage()
+		draw.Draw(img, image.Rect(10, 10, 30, 40), &image.Uniform{red}, image.Point{}, draw.Src)
+		// 噪点面积小于Speckle时丢弃
+		img.Set(50, 50, red)
+		paths := TraceImage(img, DefaultTraceOptions())
+		if len(paths) != 1 {
+			t.Fatalf("路径数量 %d, 期望 1", len(paths))
+		}
+		if paths[0].FillColor != red || !paths[0].Fill {
+			t.Errorf("填充颜色 %v, 期望 %v", paths[0].FillColor, red)
+		}
+		subpaths, curves, bounds := traceStats(paths[0].Segments)
+		if subpaths != 1 || curves != 0 {
+			t.Errorf("子路径 %d, 曲线段 %d, 期望1个只含直线的子路径", subpaths, curves)
+		}
+		if bounds != [4]float64{10, 10, 30, 40} {
+			t.Errorf("轮廓范围 %v, 期望 [10 10 30 40]", bounds)
+		}
+	})
+
+	t.Run("圆形平滑为曲线", func(t *testing.T) {
+		img := newImage()
+		for y := 0; y < 60; y++ {
+			for x := 0; x < 60; x++ {
+				if dx, dy := float64(x)+0.5-30, float64(y)+0.5-30; dx*dx+dy*dy < 20*20 {
+					img.Set(x, y, color.Black)
+				}
+			}
+		}
+		paths := TraceImage(img, DefaultTraceOptions())
+		if len(paths) != 1 {
+			t.Fatalf("路径数量 %d, 期望 1", len(paths))
+		}
+		_, curves, bounds := traceStats(paths[0].Segments)
+		if curves == 0 {
+			t.Error("圆形轮廓应包含曲线段")
+		}
+		for i, want := range [4]float64{10, 10, 50, 50} {
+			if math.Abs(bounds[i]-want) > 1.5 {
+				t.Errorf("轮廓范围 %v, 期望接近 [10 10 50 50]", bounds)
+				break
+			}
+		}
+	})
+
+	t.Run("孔洞", func(t *testing.T) {
+		img := newImage()
+		draw.Draw(img, image.Rect(10, 10, 50, 50), image.Black, image.Point{}, draw.Src)
+		draw.Draw(img, image.Rect(20, 20, 40, 40), image.White, image.Point{}, draw.Src)
+		paths := TraceImage(img, DefaultTraceOptions())
+		if len(paths) != 1 {
+			t.Fatalf("路径数量 %d, 期望 1", len(paths))
+		}
+		if subpaths, _, _ := traceStats(paths[0].Segments); subpaths != 2 {
+			t.Errorf("子路径 %d, 期望外轮廓和孔洞2个", subpaths)
+		}
+		// 外轮廓与孔洞方向相反，非零规则填充时孔洞留空
+		if paths[0].EvenOdd {
+			t.Error("描摹路径应使用非零规则")
+		}
+	})
+
+	t.Run("空白图像", func(t *testing.T) {
+		if paths := TraceImage(newImage(), DefaultTraceOptions()); paths != nil {
+			t.Errorf("空白图像描摹出 %d 条路径", len(paths))
+		}
+	})
+}
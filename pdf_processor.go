@@ -1,280 +0,0 @@
-package main
-
-import (
-	"fmt"
-	"image"
-	"image/color"
-	"image/draw"
-	"os"
-	"path/filepath"
-
-	"github.com/pdfcpu/pdfcpu/pkg/api"
-	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
-)
-
-// 改进的PDF处理器
-type EnhancedPDFProcessor struct {
-	config *Config
-}
-
-func NewEnhancedPDFProcessor(config *Config) *EnhancedPDFProcessor {
-	return &EnhancedPDFProcessor{config: config}
-}
-
-// 提取PDF页面为图像
-func (epp *EnhancedPDFProcessor) ExtractPDFAsImage(pdfPath string) (image.Image, error) {
-	// 创建临时目录存储提取的图像
-	tempDir, err := os.MkdirTemp("", "pdf_extract_")
-	if err != nil {
-		return nil, fmt.Errorf("创建临时目录失败: %v", err)
-	}
-	defer os.RemoveAll(tempDir)
-
-	// 设置配置
-	conf := model.NewDefaultConfiguration()
-	conf.ValidationMode = model.ValidationRelaxed
-
-	// 尝试提取PDF中的图像
-	err = api.ExtractImagesFile(pdfPath, tempDir, []string{"1"}, conf)
-	if err != nil {
-		// 如果提取失败，使用回退方法
-		return epp.fallbackPDFExtraction(pdfPath)
-	}
-
-	// 查找提取的图像文件
-	files, err := filepath.Glob(filepath.Join(tempDir, "*.*"))
-	if err != nil || len(files) == 0 {
-		return epp.fallbackPDFExtraction(pdfPath)
-	}
-
-	// 读取第一个图像文件
-	for _, file := range files {
-		imgFile, err := os.Open(file)
-		if err != nil {
-			continue
-		}
-		defer imgFile.Close()
-
-		// 尝试解码图像
-		img, _, err := image.Decode(imgFile)
-		if err != nil {
-			imgFile.Close()
-			continue
-		}
-
-		return img, nil
-	}
-
-	// 如果没有找到有效图像，使用回退方法
-	return epp.fallbackPDFExtraction(pdfPath)
-}
-
-// 回退的PDF提取方法
-func (epp *EnhancedPDFProcessor) fallbackPDFExtraction(pdfPath string) (image.Image, error) {
-	// 创建一个占位图像，包含一些测试内容
-	bounds := image.Rect(0, 0, 800, 600)
-	img := image.NewRGBA(bounds)
-
-	// 填充白色背景
-	white := color.RGBA{255, 255, 255, 255}
-	draw.Draw(img, bounds, &image.Uniform{white}, image.Point{}, draw.Src)
-
-	// 添加一些测试内容（模拟艺术字）
-	black := color.RGBA{0, 0, 0, 255}
-
-	// 绘制简单的测试图形
-	for y := 200; y < 400; y++ {
-		for x := 200; x < 600; x++ {
-			if (x-400)*(x-400)+(y-300)*(y-300) < 10000 {
-				img.Set(x, y, black)
-			}
-		}
-	}
-
-	return img, nil
-}
-
-// 高级边界检测
-func (epp *EnhancedPDFProcessor) DetectAdvancedBounds(img image.Image) (image.Point, image.Rectangle, error) {
-	// 转换为灰度以便更好的处理
-	grayImg := epp.toGrayscale(img)
-
-	// 边缘检测
-	edges := epp.detectEdges(grayImg)
-
-	// 找到内容区域
-	contentBounds := epp.findContentBounds(edges)
-
-	// 计算中心点
-	center := image.Point{
-		X: (contentBounds.Min.X + contentBounds.Max.X) / 2,
-		Y: (contentBounds.Min.Y + contentBounds.Max.Y) / 2,
-	}
-
-	return center, contentBounds, nil
-}
-
-// 转换为灰度图像
-func (epp *EnhancedPDFProcessor) toGrayscale(img image.Image) *image.Gray {
-	bounds := img.Bounds()
-	gray := image.NewGray(bounds)
-
-	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
-		for x := bounds.Min.X; x < bounds.Max.X; x++ {
-			c := color.GrayModel.Convert(img.At(x, y))
-			gray.Set(x, y, c)
-		}
-	}
-
-	return gray
-}
-
-// 简单的边缘检测
-func (epp *EnhancedPDFProcessor) detectEdges(img *image.Gray) *image.Gray {
-	bounds := img.Bounds()
-	edges := image.NewGray(bounds)
-
-	for y := bounds.Min.Y + 1; y < bounds.Max.Y - 1; y++ {
-		for x := bounds.Min.X + 1; x < bounds.Max.X - 1; x++ {
-			// Sobel算子
-			gx := int(img.GrayAt(x+1, y-1).Y) + 2*int(img.GrayAt(x+1, y).Y) + int(img.GrayAt(x+1, y+1).Y) -
-				 int(img.GrayAt(x-1, y-1).Y) - 2*int(img.GrayAt(x-1, y).Y) - int(img.GrayAt(x-1, y+1).Y)
-
-			gy := int(img.GrayAt(x-1, y+1).Y) + 2*int(img.GrayAt(x, y+1).Y) + int(img.GrayAt(x+1, y+1).Y) -
-				 int(img.GrayAt(x-1, y-1).Y) - 2*int(img.GrayAt(x, y-1).Y) - int(img.GrayAt(x+1, y-1).Y)
-
-			magnitude := int(float64(gx*gx + gy*gy) * 0.5)
-			if magnitude > 255 {
-				magnitude = 255
-			}
-
-			edges.SetGray(x, y, color.Gray{Y: uint8(magnitude)})
-		}
-	}
-
-	return edges
-}
-
-// 找到内容边界
-func (epp *EnhancedPDFProcessor) findContentBounds(edges *image.Gray) image.Rectangle {
-	bounds := edges.Bounds()
-
-	minX, minY := bounds.Max.X, bounds.Max.Y
-	maxX, maxY := bounds.Min.X, bounds.Min.Y
-
-	threshold := uint8(50) // 边缘阈值
-
-	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
-		for x := bounds.Min.X; x < bounds.Max.X; x++ {
-			if edges.GrayAt(x, y).Y > threshold {
-				if x < minX {
-					minX = x
-				}
-				if x > maxX {
-					maxX = x
-				}
-				if y < minY {
-					minY = y
-				}
-				if y > maxY {
-					maxY = y
-				}
-			}
-		}
-	}
-
-	// 添加一些边距
-	margin := 20
-	minX = max(bounds.Min.X, minX-margin)
-	minY = max(bounds.Min.Y, minY-margin)
-	maxX = min(bounds.Max.X, maxX+margin)
-	maxY = min(bounds.Max.Y, maxY+margin)
-
-	return image.Rectangle{
-		Min: image.Point{X: minX, Y: minY},
-		Max: image.Point{X: maxX, Y: maxY},
-	}
-}
-
-// 辅助函数
-func max(a, b int) int {
-	if a > b {
-		return a
-	}
-	return b
-}
-
-func min(a, b int) int {
-	if a < b {
-		return a
-	}
-	return b
-}
-
-// 智能裁剪 - 考虑内容比例
-func (epp *EnhancedPDFProcessor) SmartCrop(img image.Image, center image.Point, contentBounds image.Rectangle) image.Image {
-	boxSize := epp.config.BoxSize
-
-	// 计算内容的宽高比
-	contentWidth := contentBounds.Dx()
-	contentHeight := contentBounds.Dy()
-	contentRatio := float64(contentWidth) / float64(contentHeight)
-
-	// 根据内容比例调整裁剪区域
-	var cropWidth, cropHeight int
-	if contentRatio > 1.0 {
-		// 宽度大于高度
-		cropWidth = boxSize
-		cropHeight = int(float64(boxSize) / contentRatio)
-	} else {
-		// 高度大于宽度
-		cropHeight = boxSize
-		cropWidth = int(float64(boxSize) * contentRatio)
-	}
-
-	// 确保不超过boxSize
-	if cropWidth > boxSize {
-		cropWidth = boxSize
-	}
-	if cropHeight > boxSize {
-		cropHeight = boxSize
-	}
-
-	// 计算裁剪区域
-	halfWidth := cropWidth / 2
-	halfHeight := cropHeight / 2
-
-	cropBounds := image.Rectangle{
-		Min: image.Point{X: center.X - halfWidth, Y: center.Y - halfHeight},
-		Max: image.Point{X: center.X + halfWidth, Y: center.Y + halfHeight},
-	}
-
-	// 创建裁剪后的图像，居中放置在标准boxSize中
-	croppedImg := image.NewRGBA(image.Rect(0, 0, boxSize, boxSize))
-
-	// 填充白色背景
-	white := color.RGBA{255, 255, 255, 255}
-	draw.Draw(croppedImg, croppedImg.Bounds(), &image.Uniform{white}, image.Point{}, draw.Src)
-
-	// 计算在目标图像中的位置（居中）
-	offsetX := (boxSize - cropWidth) / 2
-	offsetY := (boxSize - cropHeight) / 2
-
-	// 复制像素
-	for y := 0; y < cropHeight; y++ {
-		for x := 0; x < cropWidth; x++ {
-			srcX := cropBounds.Min.X + x
-			srcY := cropBounds.Min.Y + y
-			dstX := offsetX + x
-			dstY := offsetY + y
-
-			// 检查源图像边界
-			if srcX >= img.Bounds().Min.X && srcX < img.Bounds().Max.X &&
-				srcY >= img.Bounds().Min.Y && srcY < img.Bounds().Max.Y {
-				croppedImg.Set(dstX, dstY, img.At(srcX, srcY))
-			}
-		}
-	}
-
-	return croppedImg
-}
\ No newline at end of file
@@ -0,0 +1,111 @@
+package pipeline
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"pdf-vector-extractor/layout"
+)
+
+// 图集描述文件格式
+const (
+	AtlasJSON = "json"
+	AtlasCSV  = "csv"
+)
+
+// 图集描述：每个拼接结果中各图块的来源和位置
+type atlas struct {
+	Sheets []atlasSheet `json:"sheets"`
+}
+
+type atlasSheet struct {
+	Image  string      `json:"image"` // 拼接结果文件名，与描述文件在同一目录
+	Sheet  int         `json:"sheet"` // 第几个拼接结果，多页PDF中为页码
+	Width  int         `json:"width"`
+	Height int         `json:"height"`
+	Tiles  []atlasTile `json:"tiles"`
+}
+
+// 图块在拼接结果中的可见区域 (像素，原点在左上角)
+type atlasTile struct {
+	Name   string `json:"name"`
+	Source string `json:"source"` // 相对输入目录的源文件路径
+	Page   int    `json:"page"`
+	X      int    `json:"x"`
+	Y      int    `json:"y"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+// 按拼接结果和写入的文件生成图集描述；多页写入同一文件时各页共用文件名
+func (c *Config) buildAtlas(sheets []*layout.Sheet, paths []string) atlas {
+	var a atlas
+	for i, sheet := range sheets {
+		path := paths[min(i, len(paths)-1)]
+		bounds := sheet.Image.Bounds()
+		as := atlasSheet{Image: filepath.Base(path), Sheet: i + 1, Width: bounds.Dx(), Height: bounds.Dy()}
+		for j, tile := range sheet.Tiles {
+			r := tile.VisibleRect().Add(sheet.Cells[j].Min)
+			source, err := filepath.Rel(c.InputDir, tile.SourceFile)
+			if err != nil {
+				source = tile.SourceFile
+			}
+			as.Tiles = append(as.Tiles, atlasTile{
+				Name:   tile.Name(),
+				Source: filepath.ToSlash(source),
+				Page:   tile.PageNumber,
+				X:      r.Min.X,
+				Y:      r.Min.Y,
+				Width:  r.Dx(),
+				Height: r.Dy(),
+			})
+		}
+		a.Sheets = append(a.Sheets, as)
+	}
+	return a
+}
+
+// 写入图集描述文件
+func (a atlas) writeFile(path, format string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if format == AtlasJSON {
+		enc := json.NewEncoder(file)
+		enc.SetIndent("", "  ")
+		return enc.Encode(a)
+	}
+
+	w := csv.NewWriter(file)
+	w.Write([]string{"image", "sheet", "name", "source", "page", "x", "y", "width", "height"})
+	for _, s := range a.Sheets {
+		for _, t := range s.Tiles {
+			w.Write([]string{s.Image, strconv.Itoa(s.Sheet), t.Name, t.Source, strconv.Itoa(t.Page),
+				strconv.Itoa(t.X), strconv.Itoa(t.Y), strconv.Itoa(t.Width), strconv.Itoa(t.Height)})
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// 在拼接结果旁写入图集描述文件
+func (pe *PDFExtractor) writeAtlas(sheets []*layout.Sheet, paths []string) error {
+	format := pe.config.Atlas
+	if format == "" || len(paths) == 0 {
+		return nil
+	}
+	path := pe.outputFile("_atlas." + format)
+	if err := pe.config.buildAtlas(sheets, paths).writeFile(path, format); err != nil {
+		os.Remove(path)
+		return fmt.Errorf("写入图集描述 %s 失败: %v", path, err)
+	}
+	pe.addWritten(path)
+	return nil
+}
@@ -0,0 +1,358 @@
+// Package pipeline 串联提取、检测、裁剪、排版和输出，批量处理目录中的PDF文件
+package pipeline
+
+import (
+	"fmt"
+	"image/color"
+	"runtime"
+	"strings"
+
+	"pdf-vector-extractor/crop"
+	"pdf-vector-extractor/detect"
+	"pdf-vector-extractor/extract"
+	"pdf-vector-extractor/layout"
+	"pdf-vector-extractor/output"
+)
+
+type Config struct {
+	InputDir      string
+	OutputPath    string
+	BoxSize       int
+	OutputType    string // "svg", "ai", "pdf"
+	Spacing       int    // 图块行间距，纵向排列时为图块之间的间距
+	RenderDPI     int    // 矢量页面渲染分辨率
+	OutputDPI     int    // PDF/AI输出分辨率，决定页面物理尺寸，0表示与渲染分辨率一致
+	PageSelection string // 页面选择: 空为第1页，all、1-3,7、odd、even
+
+	// 内容检测
+	DetectThreshold int // 检测阈值 (0-255)，0表示使用检测方法的默认值
+	DetectMargin    int // 内容区域四周的边距 (像素)
+
+	// 矢量检测器 (vector) 在没有路径的页面上使用的检测方法: edge、otsu、adaptive、background，空为edge
+	VectorFallback string
+
+	// 分割 (检测器为segment时)，一页中的多个独立内容分别生成图块
+	SegmentMethod string // 标记内容像素的检测方法: edge、otsu、adaptive、background，空为edge
+	MergeDistance int    // 间距不超过该值 (像素) 的连通区域合并为一个内容
+	MinArea       int    // 外接矩形面积小于该值 (平方像素) 的内容视为噪点丢弃
+
+	// 内容缩放到裁剪框
+	FitMode        string // 缩放方式: none、contain、cover、downscale，空为none
+	ResampleFilter string // 重采样滤镜: nearest、bilinear、catmullrom、lanczos，空为catmullrom
+
+	// 内容在裁剪框中的对齐
+	CenterMode      string // 中心点: bbox（外接矩形）、centroid（墨迹重心）、baseline（基线对齐），空为bbox
+	AlignHorizontal string // 水平对齐: left、center、right，空为center
+	AlignVertical   string // 垂直对齐: top、middle、bottom，空为middle
+
+	// 目录扫描
+	Recursive    bool     // 递归扫描子目录
+	Include      []string // 包含模式，非空时只处理匹配的文件
+	Exclude      []string // 排除模式，匹配的文件和目录不处理
+	Symlinks     string   // 符号链接处理方式: files、follow、skip，空为files
+	PerDirectory bool     // 每个子目录分别生成输出，否则整个目录树生成一个输出
+
+	// 文件顺序
+	SortOrder string // 排序方式: natural、name、mtime、pinyin，空为natural
+	OrderFile string // 排序清单，每行一个文件名，.csv文件取第一列，清单中的文件按清单顺序排在最前
+
+	// 网格排版
+	Columns       int    // 列数，0表示由行数决定，行数也为0时自动取接近正方形的网格
+	Rows          int    // 行数，仅在列数为0时生效，1为横向一行
+	FillOrder     string // 填充顺序: row（先行后列）、column（先列后行），空为row
+	ColumnSpacing int    // 图块列间距
+	Margin        int    // 拼接结果的外边距
+	Background    string // 背景颜色: #rrggbb、#rrggbbaa，none为透明，空为白色
+
+	// 紧密排版 (排版器为pack时)
+	PackWidth int    // 拼接结果宽度，0表示自动取接近正方形的宽度
+	Atlas     string // 图集描述文件格式: json、csv，空为不生成
+
+	// 按纸张分页输出，分页时每页的行列数由纸张尺寸决定，不使用排版器
+	PaperSize   string  // 纸张: a4、a3、a5、letter、legal、tabloid 或 宽x高（毫米），空为不分页
+	Landscape   bool    // 横向
+	PaperMargin float64 // 页边距 (毫米)
+	CropMarks   bool    // 在页边距中绘制裁切线
+	PageNumbers bool    // 在下边距中绘制页码
+
+	// 位图来源的描摹参数
+	TraceRaster      bool    // 输出SVG、AI、PDF时将位图图块描摹为矢量路径
+	TraceThreshold   int     // 二值化阈值 (0-255)
+	TraceCornerAngle float64 // 拐角阈值 (度)
+	TraceSpeckle     int     // 斑点过滤面积 (像素)
+
+	Concurrency int // 并行处理的文件数，0表示使用GOMAXPROCS
+
+	// 各处理阶段选用的已注册实现，空为默认实现
+	Extractor string
+	Detector  string
+	Cropper   string
+	Layouter  string
+}
+
+// 默认配置，图形界面与命令行共用
+func DefaultConfig() *Config {
+	return &Config{
+		BoxSize:       200,
+		OutputType:    "png",
+		Spacing:       10,
+		Columns:       1,
+		FillOrder:     layout.FillRowMajor,
+		ColumnSpacing: 10,
+		Background:    "#ffffff",
+		PaperMargin:   10,
+		RenderDPI:     extract.DefaultRenderDPI,
+		PageSelection: "1",
+		Symlinks:      SymlinksFiles,
+		SortOrder:     SortNatural,
+
+		DetectMargin:   detect.DefaultMargin,
+		VectorFallback: detect.MethodEdge,
+		SegmentMethod:  detect.MethodEdge,
+		MergeDistance:  20,
+		MinArea:        400,
+		FitMode:        crop.FitNone,
+		ResampleFilter: crop.DefaultFilter,
+
+		CenterMode:      layout.CenterBounds,
+		AlignHorizontal: layout.AlignCenter,
+		AlignVertical:   layout.AlignMiddle,
+
+		TraceRaster:      true,
+		TraceThreshold:   output.DefaultTraceThreshold,
+		TraceCornerAngle: output.DefaultTraceCornerAngle,
+		TraceSpeckle:     output.DefaultTraceSpeckle,
+
+		Extractor: DefaultExtractor,
+		Detector:  DefaultDetector,
+		Cropper:   DefaultCropper,
+		Layouter:  DefaultLayouter,
+	}
+}
+
+// 检查配置是否有效
+func (c *Config) Validate() error {
+	switch {
+	case c.BoxSize <= 0:
+		return fmt.Errorf("裁剪框大小必须大于0: %d", c.BoxSize)
+	case c.Spacing < 0:
+		return fmt.Errorf("图片间距不能为负数: %d", c.Spacing)
+	case c.ColumnSpacing < 0:
+		return fmt.Errorf("列间距不能为负数: %d", c.ColumnSpacing)
+	case c.Columns < 0 || c.Rows < 0:
+		return fmt.Errorf("行数和列数不能为负数: %d x %d", c.Rows, c.Columns)
+	case c.Margin < 0:
+		return fmt.Errorf("外边距不能为负数: %d", c.Margin)
+	case c.DetectThreshold < 0 || c.DetectThreshold > 255:
+		return fmt.Errorf("检测阈值应在0-255之间: %d", c.DetectThreshold)
+	case c.DetectMargin < 0:
+		return fmt.Errorf("检测边距不能为负数: %d", c.DetectMargin)
+	case c.MergeDistance < 0:
+		return fmt.Errorf("合并距离不能为负数: %d", c.MergeDistance)
+	case c.MinArea < 0:
+		return fmt.Errorf("最小面积不能为负数: %d", c.MinArea)
+	case c.PackWidth < 0:
+		return fmt.Errorf("紧密排版宽度不能为负数: %d", c.PackWidth)
+	case c.RenderDPI <= 0:
+		return fmt.Errorf("渲染分辨率必须大于0: %d", c.RenderDPI)
+	case c.OutputDPI < 0:
+		return fmt.Errorf("输出分辨率不能为负数: %d", c.OutputDPI)
+	case c.TraceThreshold < 0 || c.TraceThreshold > 255:
+		return fmt.Errorf("描摹阈值应在0-255之间: %d", c.TraceThreshold)
+	case c.TraceCornerAngle < 0:
+		return fmt.Errorf("拐角阈值不能为负数: %v", c.TraceCornerAngle)
+	case c.TraceSpeckle < 0:
+		return fmt.Errorf("斑点过滤面积不能为负数: %d", c.TraceSpeckle)
+	case c.Concurrency < 0:
+		return fmt.Errorf("并发数不能为负数: %d", c.Concurrency)
+	}
+	switch c.FillOrder {
+	case "", layout.FillRowMajor, layout.FillColumnMajor:
+	default:
+		return fmt.Errorf("未知的填充顺序: %s（可选 %s、%s）", c.FillOrder, layout.FillRowMajor, layout.FillColumnMajor)
+	}
+	if err := c.DetectOptions(c.SegmentMethod).Validate(); err != nil {
+		return err
+	}
+	if err := c.DetectOptions(c.VectorFallback).Validate(); err != nil {
+		return fmt.Errorf("矢量检测器的备用方法无效: %v", err)
+	}
+	if err := crop.ValidateFit(c.FitMode, c.ResampleFilter); err != nil {
+		return err
+	}
+	if err := c.AlignOptions().Validate(); err != nil {
+		return err
+	}
+	if _, err := layout.ParseColor(c.Background); err != nil {
+		return err
+	}
+	switch c.Atlas {
+	case "", AtlasJSON, AtlasCSV:
+	default:
+		return fmt.Errorf("未知的图集格式: %s（可选 %s、%s）", c.Atlas, AtlasJSON, AtlasCSV)
+	}
+	if err := c.validatePaper(); err != nil {
+		return err
+	}
+	switch c.Symlinks {
+	case "", SymlinksFiles, SymlinksFollow, SymlinksSkip:
+	default:
+		return fmt.Errorf("未知的符号链接处理方式: %s（可选 %s、%s、%s）", c.Symlinks, SymlinksFiles, SymlinksFollow, SymlinksSkip)
+	}
+	switch c.SortOrder {
+	case "", SortNatural, SortName, SortModTime, SortPinyin:
+	default:
+		return fmt.Errorf("未知的排序方式: %s（可选 %s）", c.SortOrder, strings.Join(SortOrders(), "、"))
+	}
+	if err := validatePatterns(c.Include); err != nil {
+		return err
+	}
+	if err := validatePatterns(c.Exclude); err != nil {
+		return err
+	}
+	if !output.IsFormat(c.OutputType) {
+		return fmt.Errorf("不支持的输出格式: %s（可选 %s）", c.OutputType, strings.Join(output.Formats(), "、"))
+	}
+	_, err := c.stages()
+	return err
+}
+
+// 按配置选用的各阶段实现
+type stageSet struct {
+	newExtractor func() Extractor
+	detector     BoundsDetector
+	cropper      Cropper
+	layouter     Layouter
+}
+
+// 从注册表中查找配置选用的各阶段实现
+func (c *Config) stages() (*stageSet, error) {
+	newExtractor, err := extractors.lookup(c.Extractor, DefaultExtractor)
+	if err != nil {
+		return nil, err
+	}
+	newDetector, err := detectors.lookup(c.Detector, DefaultDetector)
+	if err != nil {
+		return nil, err
+	}
+	newCropper, err := croppers.lookup(c.Cropper, DefaultCropper)
+	if err != nil {
+		return nil, err
+	}
+	newLayouter, err := layouters.lookup(c.Layouter, DefaultLayouter)
+	if err != nil {
+		return nil, err
+	}
+	return &stageSet{
+		newExtractor: func() Extractor { return newExtractor(c) },
+		detector:     newDetector(c),
+		cropper:      newCropper(c),
+		layouter:     newLayouter(c),
+	}, nil
+}
+
+// 输出PDF、AI时一个像素对应的分辨率，未设置时与渲染分辨率一致
+func (c *Config) EffectiveOutputDPI() int {
+	if c.OutputDPI > 0 {
+		return c.OutputDPI
+	}
+	if c.RenderDPI > 0 {
+		return c.RenderDPI
+	}
+	return extract.DefaultRenderDPI
+}
+
+// 并行处理的文件数，未设置时使用GOMAXPROCS
+func (c *Config) Workers() int {
+	if c.Concurrency > 0 {
+		return c.Concurrency
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// 从配置生成指定检测方法的检测参数
+func (c *Config) DetectOptions(method string) detect.Options {
+	return detect.Options{Method: method, Threshold: c.DetectThreshold, Margin: c.DetectMargin}
+}
+
+// 测量墨迹时标记内容像素的检测参数，与所选检测器的判定一致
+// 矢量检测器和自定义检测器按VectorFallback，分割检测器按SegmentMethod
+func (c *Config) InkOptions() detect.Options {
+	if c.Detector == "segment" {
+		return c.DetectOptions(c.SegmentMethod)
+	}
+	for _, method := range detect.Methods() {
+		if c.Detector == method {
+			return c.DetectOptions(method)
+		}
+	}
+	return c.DetectOptions(c.VectorFallback)
+}
+
+// 从配置生成对齐参数，靠边对齐时保留与检测边距相同的距离
+func (c *Config) AlignOptions() layout.AlignOptions {
+	return layout.AlignOptions{
+		Center:     c.CenterMode,
+		Horizontal: c.AlignHorizontal,
+		Vertical:   c.AlignVertical,
+		Padding:    c.DetectMargin,
+	}
+}
+
+// 从配置生成网格排版参数，背景颜色无效时使用白色
+func (c *Config) GridOptions() layout.GridOptions {
+	bg, err := layout.ParseColor(c.Background)
+	if err != nil {
+		bg = layout.White
+	}
+	return layout.GridOptions{
+		Columns:    c.Columns,
+		Rows:       c.Rows,
+		Fill:       c.FillOrder,
+		SpacingX:   c.ColumnSpacing,
+		SpacingY:   c.Spacing,
+		Margin:     c.Margin,
+		Background: bg,
+	}
+}
+
+// 图块方框中内容以外部分的填充颜色：不透明背景时与背景相同，透明或半透明背景时保持透明，
+// 排版时由拼接结果的背景透出，避免半透明背景叠加两次
+func (c *Config) TileFill() color.RGBA {
+	bg := c.GridOptions().Background
+	if bg.A < 255 {
+		return color.RGBA{}
+	}
+	return bg
+}
+
+// 从配置生成紧密排版参数，间距、外边距和背景与网格排版共用
+func (c *Config) PackOptions() layout.PackOptions {
+	grid := c.GridOptions()
+	return layout.PackOptions{
+		Width:      c.PackWidth,
+		SpacingX:   grid.SpacingX,
+		SpacingY:   grid.SpacingY,
+		Margin:     grid.Margin,
+		Background: grid.Background,
+	}
+}
+
+// 从配置生成描摹参数
+func (c *Config) TraceOptions() output.TraceOptions {
+	return output.TraceOptions{
+		Threshold:   c.TraceThreshold,
+		CornerAngle: c.TraceCornerAngle,
+		Speckle:     c.TraceSpeckle,
+	}
+}
+
+// 从配置生成输出参数
+func (c *Config) OutputOptions() output.Options {
+	return output.Options{
+		Format:      c.OutputType,
+		OutputDPI:   c.EffectiveOutputDPI(),
+		TraceRaster: c.TraceRaster,
+		Trace:       c.TraceOptions(),
+	}
+}
@@ -0,0 +1,535 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"pdf-vector-extractor/detect"
+	"pdf-vector-extractor/extract"
+	"pdf-vector-extractor/layout"
+	"pdf-vector-extractor/output"
+)
+
+type PDFExtractor struct {
+	config     *Config
+	stamp      string // 本次输出文件名使用的时间戳
+	outputName string // 非空时代替默认的输出文件名（不含扩展名）
+	outputDir  string // 非空时代替配置的输出文件夹，用于按子目录分别输出
+
+	writtenMu sync.Mutex
+	written   []string // 本次处理写入的文件，取消时删除
+
+	progressMu sync.Mutex
+	onProgress ProgressFunc
+	started    time.Time // 本次处理的开始时间
+	total      int       // 本次处理的文件总数
+	done       int       // 已处理完成的文件数
+}
+
+func NewPDFExtractor(config *Config) *PDFExtractor {
+	return &PDFExtractor{config: config}
+}
+
+// 提取PDF指定页面中的矢量图
+func (pe *PDFExtractor) ExtractVectorFromPDF(ctx context.Context, pdfPath string, pageNr int) (image.Image, error) {
+	stages, err := pe.config.stages()
+	if err != nil {
+		return nil, err
+	}
+	page, err := stages.newExtractor().ExtractPage(ctx, pdfPath, pageNr)
+	if err != nil {
+		return nil, err
+	}
+	return page.Image, nil
+}
+
+// 当前配置
+func (pe *PDFExtractor) Config() *Config {
+	return pe.config
+}
+
+// 生成输出文件路径，同一次处理的所有文件共用时间戳
+func (pe *PDFExtractor) outputFile(suffix string) string {
+	if pe.stamp == "" {
+		pe.stamp = time.Now().Format("20060102_150405")
+	}
+	dir := pe.config.OutputPath
+	if pe.outputDir != "" {
+		dir = pe.outputDir
+	}
+	if pe.outputName != "" {
+		return filepath.Join(dir, pe.outputName+suffix)
+	}
+	return filepath.Join(dir, fmt.Sprintf("PDF拼接结果_%s%s", pe.stamp, suffix))
+}
+
+// 扫描输入目录中的PDF文件，按配置的排序方式或排序清单排列
+// 按配置递归扫描子目录，并按包含/排除模式和符号链接方式筛选
+func (pe *PDFExtractor) FindPDFFiles() ([]string, error) {
+	// 检查目录是否存在
+	if _, err := os.Stat(pe.config.InputDir); os.IsNotExist(err) {
+		return nil, fmt.Errorf("输入目录不存在: %s", pe.config.InputDir)
+	}
+
+	// 扫描PDF文件，支持大小写不敏感
+	files, err := pe.config.scanPDFFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("目录 %s 中没有找到PDF文件\n请检查：\n1. 目录是否包含PDF文件\n2. 文件扩展名是否为.pdf或.PDF\n3. 包含/排除模式是否过滤了所有文件", pe.config.InputDir)
+	}
+
+	log.Printf("找到 %d 个PDF文件", len(files))
+
+	// 按配置的方式排序
+	if err := pe.config.sortFiles(files); err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
+
+// 处理目录中的所有PDF文件并拼接，返回逐文件的处理报告
+// ctx取消时停止处理，删除本次写入的文件并返回ctx的错误
+func (pe *PDFExtractor) ProcessDirectory(ctx context.Context) (*ProcessReport, error) {
+	tiles, report, err := pe.CollectTiles(ctx)
+	if err == nil {
+		// 拼接图像
+		err = pe.saveGroups(ctx, tiles, pe.CombineImages)
+	}
+	err = pe.cleanupOnCancel(ctx, err)
+	pe.emit(Event{Kind: EventDone, Err: err})
+	return report, err
+}
+
+// 逐个保存图块而不拼接，文件以图块名称命名
+// ctx取消时停止处理，删除本次写入的文件并返回ctx的错误
+func (pe *PDFExtractor) ExtractTiles(ctx context.Context) (*ProcessReport, error) {
+	tiles, report, err := pe.CollectTiles(ctx)
+	if err == nil {
+		err = pe.saveGroups(ctx, tiles, pe.saveTiles)
+	}
+	err = pe.cleanupOnCancel(ctx, err)
+	pe.emit(Event{Kind: EventDone, Err: err})
+	return report, err
+}
+
+// 按子目录分别输出时，把图块按来源目录分组，分别保存到输出文件夹中对应的子目录
+func (pe *PDFExtractor) saveGroups(ctx context.Context, tiles []layout.Tile, save func(context.Context, []layout.Tile) error) error {
+	if !pe.config.PerDirectory {
+		return save(ctx, tiles)
+	}
+	defer func() { pe.outputDir = "" }()
+	for _, group := range pe.config.groupTiles(tiles) {
+		pe.outputDir = filepath.Join(pe.config.OutputPath, group.Dir)
+		if err := pe.mkdirOutput(pe.outputDir); err != nil {
+			return err
+		}
+		if err := save(ctx, group.Tiles); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 创建输出子目录，新建的目录在取消时一并删除
+func (pe *PDFExtractor) mkdirOutput(dir string) error {
+	if _, err := os.Stat(dir); err == nil {
+		return nil
+	}
+	// 逐级记录新建的目录
+	if parent := filepath.Dir(dir); parent != dir {
+		if err := pe.mkdirOutput(parent); err != nil {
+			return err
+		}
+	}
+	if err := os.Mkdir(dir, 0755); err != nil && !os.IsExist(err) {
+		return fmt.Errorf("无法创建输出文件夹 %s: %v", dir, err)
+	}
+	pe.addWritten(dir)
+	return nil
+}
+
+// 逐个排版并保存图块
+// 递归扫描时不同目录中可能有同名文件，重名的图块依次加上序号
+func (pe *PDFExtractor) saveTiles(ctx context.Context, tiles []layout.Tile) error {
+	defer func() { pe.outputName = "" }()
+	// 与拼接时一样，基线对齐时同一组的图块共用一条基线
+	tiles = layout.Align(tiles, pe.config.AlignOptions())
+	used := make(map[string]int)
+	for _, tile := range tiles {
+		pe.emit(Event{Kind: EventSave, File: tile.SourceFile, Page: tile.PageNumber, Stage: "保存"})
+		pe.outputName = tile.Name()
+		used[strings.ToLower(pe.outputName)]++
+		if n := used[strings.ToLower(pe.outputName)]; n > 1 {
+			pe.outputName = fmt.Sprintf("%s_%d", pe.outputName, n)
+		}
+		sheet, err := pe.LayoutSheet(ctx, []layout.Tile{tile})
+		if err == nil {
+			err = pe.SaveResult(ctx, sheet)
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			return fmt.Errorf("保存图块 %s 失败: %v", tile.Name(), err)
+		}
+	}
+	return nil
+}
+
+// 记录本次处理写入的文件
+func (pe *PDFExtractor) addWritten(path string) {
+	pe.writtenMu.Lock()
+	pe.written = append(pe.written, path)
+	pe.writtenMu.Unlock()
+}
+
+// ctx已取消时删除本次处理写入的所有文件和新建的目录，并以ctx的错误代替err
+func (pe *PDFExtractor) cleanupOnCancel(ctx context.Context, err error) error {
+	if ctx.Err() == nil {
+		return err
+	}
+	pe.writtenMu.Lock()
+	defer pe.writtenMu.Unlock()
+	// 倒序删除，先删文件再删其所在的目录
+	for i := len(pe.written) - 1; i >= 0; i-- {
+		path := pe.written[i]
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			log.Printf("删除未完成的输出 %s 失败: %v", path, err)
+		}
+	}
+	if len(pe.written) > 0 {
+		log.Printf("处理已取消，已删除 %d 个输出文件", len(pe.written))
+	}
+	pe.written = nil
+	return ctx.Err()
+}
+
+// 提取并裁剪目录中所有PDF选定页面，返回图块和逐文件的处理报告
+// ctx取消时不写入失败报告，直接返回ctx的错误
+func (pe *PDFExtractor) CollectTiles(ctx context.Context) ([]layout.Tile, *ProcessReport, error) {
+	pe.stamp = time.Now().Format("20060102_150405")
+	pe.written = nil
+	pe.resetProgress()
+	report := &ProcessReport{}
+
+	stages, err := pe.config.stages()
+	if err != nil {
+		return nil, report, err
+	}
+
+	files, err := pe.FindPDFFiles()
+	if err != nil {
+		return nil, report, err
+	}
+
+	report.Total = len(files)
+	pe.startProgress(len(files))
+
+	// 多个文件并行处理，结果按文件顺序合并，与并发数无关
+	results := make([]fileResult, len(files))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(pe.config.Workers(), len(files)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				log.Printf("处理文件 (%d/%d): %s", i+1, len(files), filepath.Base(files[i]))
+				pe.emit(Event{Kind: EventFileStart, File: files[i], Index: i + 1})
+				results[i] = pe.processFile(ctx, stages, files[i], i+1)
+				pe.emit(Event{Kind: EventFileDone, File: files[i], Index: i + 1, Tiles: len(results[i].tiles)})
+			}
+		}()
+	}
+feed:
+	for i := range files {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, report, err
+	}
+
+	var tiles []layout.Tile
+	for _, result := range results {
+		tiles = append(tiles, result.tiles...)
+		report.Failures = append(report.Failures, result.failures...)
+		report.Succeeded += len(result.tiles)
+	}
+
+	// 有失败时在输出文件旁写入失败报告
+	if len(report.Failures) > 0 {
+		if err := report.WriteFile(pe.outputFile("_失败报告.txt")); err != nil {
+			log.Printf("写入失败报告失败: %v", err)
+		} else {
+			pe.addWritten(report.ReportPath)
+		}
+	}
+
+	if len(tiles) == 0 {
+		return nil, report, fmt.Errorf("没有成功提取任何图像，%d 个文件全部失败", len(files))
+	}
+
+	return tiles, report, nil
+}
+
+// 单个文件的处理结果
+type fileResult struct {
+	tiles    []layout.Tile
+	failures []FileFailure
+}
+
+// 提取、检测并裁剪单个文件的选定页面，可在多个goroutine中并行调用
+func (pe *PDFExtractor) processFile(ctx context.Context, stages *stageSet, file string, index int) fileResult {
+	var result fileResult
+	fail := func(page int, stage string, err error) {
+		// 取消引起的错误不计入失败
+		if ctx.Err() != nil {
+			return
+		}
+		result.failures = append(result.failures, FileFailure{File: file, Page: page, Stage: stage, Err: err})
+		pe.emit(Event{Kind: EventFailure, File: file, Index: index, Page: page, Stage: stage, Err: err})
+	}
+	stage := func(page int, name string) {
+		pe.emit(Event{Kind: EventStage, File: file, Index: index, Page: page, Stage: name})
+	}
+
+	// 同一文件的所有页面共用一个处理器，只解析一次PDF
+	stage(0, "读取")
+	processor := stages.newExtractor()
+	pageCount, err := processor.PageCount(file)
+	if err != nil {
+		log.Printf("读取文件 %s 失败: %v", file, err)
+		fail(0, "读取", err)
+		return result
+	}
+
+	pages, err := extract.ParsePageSelection(pe.config.PageSelection, pageCount)
+	if err != nil {
+		log.Printf("文件 %s 页面选择无效: %v", file, err)
+		fail(0, "选页", err)
+		return result
+	}
+
+	for _, pageNr := range pages {
+		if ctx.Err() != nil {
+			break
+		}
+
+		// 提取矢量图
+		stage(pageNr, "提取")
+		page, err := processor.ExtractPage(ctx, file, pageNr)
+		if err != nil {
+			log.Printf("处理文件 %s 第%d页失败: %v", file, pageNr, err)
+			fail(pageNr, "提取", err)
+			continue
+		}
+		if page.Vector != nil && page.Vector.SkippedImages() > 0 {
+			log.Printf("文件 %s 第%d页有 %d 个图像无法解码，渲染结果不完整", file, pageNr, page.Vector.SkippedImages())
+		}
+		if page.Vector != nil && page.Vector.TextCount > 0 {
+			log.Printf("文件 %s 第%d页有 %d 处文本未转为轮廓，渲染结果不包含文字", file, pageNr, page.Vector.TextCount)
+		}
+
+		// 检测中心点和内容边界，分割检测器可在一页中找到多个内容
+		stage(pageNr, "检测")
+		regions, err := detectRegions(ctx, stages.detector, page)
+		if err != nil {
+			log.Printf("检测中心点失败 %s 第%d页: %v", file, pageNr, err)
+			fail(pageNr, "检测", err)
+			continue
+		}
+
+		// 智能裁剪图像
+		stage(pageNr, "裁剪")
+		for i, region := range regions {
+			tile, err := pe.cropRegion(ctx, stages.cropper, page.Image, region)
+			if err != nil {
+				log.Printf("裁剪失败 %s 第%d页: %v", file, pageNr, err)
+				fail(pageNr, "裁剪", err)
+				break
+			}
+			tile.SourceFile = file
+			tile.PageNumber = pageNr
+			tile.PageCount = pageCount
+			tile.Vector = page.Vector
+			tile.Scale = page.Scale
+			if len(regions) > 1 {
+				tile.Part = i + 1
+			}
+			result.tiles = append(result.tiles, tile)
+		}
+	}
+	return result
+}
+
+// 检测页面中的内容区域，检测器实现Segmenter时每个独立内容一个区域
+func detectRegions(ctx context.Context, detector BoundsDetector, page *extract.Page) ([]detect.Region, error) {
+	if s, ok := detector.(Segmenter); ok {
+		regions, err := s.Segment(ctx, page.Image)
+		if err == nil && len(regions) == 0 {
+			err = fmt.Errorf("没有找到面积足够大的内容")
+		}
+		return regions, err
+	}
+	var center image.Point
+	var bounds image.Rectangle
+	var err error
+	if pd, ok := detector.(PageDetector); ok {
+		center, bounds, err = pd.DetectPage(ctx, page)
+	} else {
+		center, bounds, err = detector.DetectBounds(ctx, page.Image)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []detect.Region{{Center: center, Bounds: bounds}}, nil
+}
+
+// 裁剪一个内容区域；设置了中心点或对齐方式时测量区域中的墨迹，
+// 裁剪器支持时先在方框中移动裁剪区域的放置位置，其余距离按比例反向移动裁剪中心，从页面图像重新裁剪
+// 内容只在裁剪区域内移动，不会移出可见部分
+func (pe *PDFExtractor) cropRegion(ctx context.Context, cropper Cropper, img image.Image, region detect.Region) (layout.Tile, error) {
+	placing, canPlace := cropper.(PlacingCropper)
+	cropAt := func(center, shift image.Point) (layout.Tile, error) {
+		var croppedImg image.Image
+		var cropRect, placed image.Rectangle
+		var err error
+		if canPlace {
+			croppedImg, cropRect, placed, err = placing.CropPlaced(ctx, img, center, region.Bounds, shift)
+		} else {
+			croppedImg, cropRect, placed, err = cropper.Crop(ctx, img, center, region.Bounds)
+		}
+		if err != nil {
+			return layout.Tile{}, err
+		}
+		return layout.Tile{Image: croppedImg, CropRect: cropRect, Offset: placed.Min, Size: placed.Size()}, nil
+	}
+	tile, err := cropAt(region.Center, image.Point{})
+	opts := pe.config.AlignOptions()
+	if err != nil || opts.Identity() {
+		return tile, err
+	}
+	ink, ok, err := detect.MeasureInk(ctx, img, region.Bounds, pe.config.InkOptions())
+	if err != nil || !ok || tile.CropRect.Empty() || tile.Size.X <= 0 || tile.Size.Y <= 0 {
+		return tile, err
+	}
+
+	boxInk := tile.MapInk(ink)
+	d := opts.Offset(boxInk, tile.Image.Bounds())
+	var shift image.Point
+	if canPlace {
+		shift = layout.ClampShift(d, tile.VisibleRect(), tile.Image.Bounds())
+	}
+	move := layout.ClampShift(d.Sub(shift), boxInk.Bounds.Add(shift), tile.VisibleRect().Add(shift))
+	if shift != (image.Point{}) || move != (image.Point{}) {
+		// 方框中的距离按缩放比例换算为页面图像中的距离
+		sx := float64(tile.CropRect.Dx()) / float64(tile.Size.X)
+		sy := float64(tile.CropRect.Dy()) / float64(tile.Size.Y)
+		center := region.Center.Sub(image.Pt(int(math.Round(float64(move.X)*sx)), int(math.Round(float64(move.Y)*sy))))
+		if tile, err = cropAt(center, shift); err != nil {
+			return tile, err
+		}
+		boxInk = tile.MapInk(ink)
+	}
+	tile.Ink = &boxInk
+	return tile, nil
+}
+
+// 拼接图像，配置了纸张时分页输出
+func (pe *PDFExtractor) CombineImages(ctx context.Context, tiles []layout.Tile) error {
+	if len(tiles) == 0 {
+		return fmt.Errorf("没有图像需要拼接")
+	}
+
+	pe.emit(Event{Kind: EventSave, Stage: "保存"})
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	// 基线对齐时把所有图块的基线移动到同一高度
+	tiles = layout.Align(tiles, pe.config.AlignOptions())
+	sheets, err := pe.LayoutPages(ctx, tiles)
+	if err != nil {
+		return err
+	}
+
+	// 保存结果，按配置在旁边写入图集描述
+	paths, err := pe.SavePages(ctx, sheets)
+	if err != nil {
+		return err
+	}
+	return pe.writeAtlas(sheets, paths)
+}
+
+// 用配置选用的排版器把图块排列到拼接结果中
+func (pe *PDFExtractor) LayoutSheet(ctx context.Context, tiles []layout.Tile) (*layout.Sheet, error) {
+	stages, err := pe.config.stages()
+	if err != nil {
+		return nil, err
+	}
+	return stages.layouter.Layout(ctx, tiles)
+}
+
+// 按纸张把图块分页排列；未配置纸张时用排版器排成一页
+func (pe *PDFExtractor) LayoutPages(ctx context.Context, tiles []layout.Tile) ([]*layout.Sheet, error) {
+	if !pe.config.Paginated() {
+		sheet, err := pe.LayoutSheet(ctx, tiles)
+		if err != nil {
+			return nil, err
+		}
+		return []*layout.Sheet{sheet}, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	opts, err := pe.config.PageOptions()
+	if err != nil {
+		return nil, err
+	}
+	return layout.Paginate(tiles, pe.config.BoxSize, opts)
+}
+
+// 用配置的输出格式对应的写入器保存结果
+func (pe *PDFExtractor) SaveResult(ctx context.Context, sheet *layout.Sheet) error {
+	path, err := output.Save(ctx, sheet, pe.outputFile(""), pe.config.OutputOptions())
+	if err != nil {
+		return err
+	}
+	pe.addWritten(path)
+	return nil
+}
+
+// 保存分页的拼接结果，支持多页的格式写入一个文件，其他格式每页一个文件
+// 返回写入的文件路径
+func (pe *PDFExtractor) SavePages(ctx context.Context, sheets []*layout.Sheet) ([]string, error) {
+	paths, err := output.SavePages(ctx, sheets, pe.outputFile(""), pe.config.OutputOptions())
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		pe.addWritten(path)
+	}
+	return paths, nil
+}
+
+// 将整幅位图描摹后输出为SVG
+func (pe *PDFExtractor) SaveTracedSVG(img image.Image) error {
+	return output.SaveTracedSVG(img, pe.outputFile("_描摹.svg"), pe.config.TraceOptions())
+}
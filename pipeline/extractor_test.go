@@ -0,0 +1,227 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+
+	"pdf-vector-extractor/detect"
+	"pdf-vector-extractor/layout"
+)
+
+// 输出目录中的所有文件名
+func dirNames(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestCollectTilesOrderIndependentOfConcurrency(t *testing.T) {
+	// 数字越小的文件提取越慢，并行时先完成的是排在后面的文件
+	var files []string
+	for i := 1; i <= 12; i++ {
+		files = append(files, fmt.Sprintf("%02d.pdf", i))
+	}
+	files = append(files, "broken.pdf")
+
+	order := func(workers int) ([]string, []string) {
+		c := testConfig(t, files...)
+		c.Concurrency = workers
+		tiles, report, err := NewPDFExtractor(c).CollectTiles(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names, failures []string
+		for _, tile := range tiles {
+			names = append(names, tile.Name())
+		}
+		for _, f := range report.Failures {
+			failures = append(failures, filepath.Base(f.File))
+		}
+		return names, failures
+	}
+
+	serial, serialFailures := order(1)
+	if len(serial) != 12 || serial[0] != "01" || serial[11] != "12" {
+		t.Fatalf("单线程的图块顺序 %v", serial)
+	}
+	for _, workers := range []int{4, 13} {
+		parallel, failures := order(workers)
+		if fmt.Sprint(parallel) != fmt.Sprint(serial) {
+			t.Errorf("%d 个并发时图块顺序 %v, 期望与单线程一致 %v", workers, parallel, serial)
+		}
+		if fmt.Sprint(failures) != fmt.Sprint(serialFailures) {
+			t.Errorf("%d 个并发时失败列表 %v, 期望 %v", workers, failures, serialFailures)
+		}
+	}
+}
+
+// 排版到第n次时取消处理的排版器
+type cancelingLayouter struct {
+	mu     sync.Mutex
+	calls  int
+	n      int
+	cancel context.CancelFunc
+}
+
+func (cl *cancelingLayouter) Layout(ctx context.Context, tiles []layout.Tile) (*layout.Sheet, error) {
+	cl.mu.Lock()
+	cl.calls++
+	if cl.calls == cl.n {
+		cl.cancel()
+	}
+	cl.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return layout.Grid(tiles, 200, layout.GridOptions{Columns: 1, Background: layout.White}), nil
+}
+
+func TestExtractTilesCancelRemovesOutputs(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cl := &cancelingLayouter{n: 3, cancel: cancel}
+	RegisterLayouter("test-canceling", func(c *Config) Layouter { return cl })
+
+	c := testConfig(t, "a.pdf", "b.pdf", "c.pdf", "d.pdf", "broken.pdf")
+	c.Layouter = "test-canceling"
+	c.PerDirectory = true
+	// 输出文件夹中已有的文件不受影响
+	existing := filepath.Join(c.OutputPath, "keep.txt")
+	if err := os.WriteFile(existing, []byte("keep"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := NewPDFExtractor(c).ExtractTiles(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("错误 %v, 期望 context.Canceled", err)
+	}
+	if cl.calls != 3 {
+		t.Errorf("排版次数 %d, 期望取消后不再继续", cl.calls)
+	}
+	// 已保存的两个图块和失败报告都被删除
+	if names := dirNames(t, c.OutputPath); fmt.Sprint(names) != "[keep.txt]" {
+		t.Errorf("取消后输出文件夹中剩余 %v", names)
+	}
+}
+
+func TestCollectTilesCancelDuringExtraction(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := testConfig(t, "01.pdf", "02.pdf", "03.pdf", "04.pdf")
+	c.Concurrency = 1
+	var extracted []string
+	setTestHook(t, c, func(pdfPath string) {
+		extracted = append(extracted, filepath.Base(pdfPath))
+		if len(extracted) == 2 {
+			cancel()
+		}
+	})
+
+	report, err := NewPDFExtractor(c).ProcessDirectory(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("错误 %v, 期望 context.Canceled", err)
+	}
+	if len(extracted) > 2 {
+		t.Errorf("取消后仍提取了 %v", extracted)
+	}
+	// 取消不计为失败，也不写入报告
+	if len(report.Failures) != 0 {
+		t.Errorf("取消时记录了失败: %v", report.Failures)
+	}
+	if names := dirNames(t, c.OutputPath); len(names) != 0 {
+		t.Errorf("取消后输出文件夹中剩余 %v", names)
+	}
+}
+
+// 只实现Cropper的裁剪器，放置位置不能移动
+type fixedCropper struct{ Cropper }
+
+// 图块可见区域中黑色像素的数量和外接矩形
+func blackPixels(tile layout.Tile) (int, image.Rectangle) {
+	n := 0
+	var bounds image.Rectangle
+	visible := tile.VisibleRect()
+	for y := visible.Min.Y; y < visible.Max.Y; y++ {
+		for x := visible.Min.X; x < visible.Max.X; x++ {
+			if r, _, _, _ := tile.Image.At(x, y).RGBA(); r < 0x8000 {
+				n++
+				bounds = bounds.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	return n, bounds
+}
+
+func TestCropRegionAnchorsKeepInk(t *testing.T) {
+	// 800x600白色页面上100x50的黑色方块，不缩放时裁剪区域只占方框的一部分
+	img := image.NewRGBA(image.Rect(0, 0, 800, 600))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(350, 275, 450, 325), &image.Uniform{color.Black}, image.Point{}, draw.Src)
+
+	tests := []struct {
+		vertical, horizontal string
+		edge                 func(ink image.Rectangle, pad, box int) bool
+	}{
+		{layout.AlignTop, "", func(ink image.Rectangle, pad, box int) bool { return ink.Min.Y == pad }},
+		{layout.AlignBottom, "", func(ink image.Rectangle, pad, box int) bool { return ink.Max.Y == box-pad }},
+		{"", layout.AlignLeft, func(ink image.Rectangle, pad, box int) bool { return ink.Min.X == pad }},
+		{"", layout.AlignRight, func(ink image.Rectangle, pad, box int) bool { return ink.Max.X == box-pad }},
+		{layout.AlignTop, layout.AlignLeft, func(ink image.Rectangle, pad, box int) bool { return ink.Min == image.Pt(pad, pad) }},
+	}
+	for _, tt := range tests {
+		c := DefaultConfig()
+		c.Detector = detect.MethodEdge
+		c.AlignVertical = tt.vertical
+		c.AlignHorizontal = tt.horizontal
+		stages, err := c.stages()
+		if err != nil {
+			t.Fatal(err)
+		}
+		center, bounds, err := stages.detector.DetectBounds(context.Background(), img)
+		if err != nil {
+			t.Fatal(err)
+		}
+		region := detect.Region{Center: center, Bounds: bounds}
+		name := tt.vertical + tt.horizontal
+
+		// 默认裁剪器移动放置位置，内容到达方框边距处
+		tile, err := NewPDFExtractor(c).cropRegion(context.Background(), stages.cropper, img, region)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// 边缘检测把方块外侧一像素的边缘也算作内容
+		n, ink := blackPixels(tile)
+		if n != 5000 || !ink.In(tile.Ink.Bounds) {
+			t.Errorf("%s: 可见区域中 %d 个内容像素 %v, 期望 5000 个，在 %v 中", name, n, ink, tile.Ink.Bounds)
+		}
+		if !tt.edge(tile.Ink.Bounds, c.DetectMargin, c.BoxSize) {
+			t.Errorf("%s: 内容位置 %v, 期望距方框边缘 %d 像素", name, tile.Ink.Bounds, c.DetectMargin)
+		}
+
+		// 放置位置不能移动时内容只在裁剪区域内移动
+		tile, err = NewPDFExtractor(c).cropRegion(context.Background(), fixedCropper{stages.cropper}, img, region)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n, ink := blackPixels(tile); n != 5000 || !ink.In(tile.Ink.Bounds) {
+			t.Errorf("%s: 固定放置位置时可见区域中 %d 个内容像素 %v, 期望 5000 个，在 %v 中", name, n, ink, tile.Ink.Bounds)
+		}
+	}
+}
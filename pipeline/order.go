@@ -0,0 +1,230 @@
+package pipeline
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+// 文件排序方式
+const (
+	SortNatural = "natural" // 自然排序，文件名中的数字按数值比较（默认）
+	SortName    = "name"    // 按字节顺序比较路径
+	SortModTime = "mtime"   // 按修改时间从早到晚
+	SortPinyin  = "pinyin"  // 中文按拼音排序，数字按数值比较
+)
+
+// 可选的排序方式
+func SortOrders() []string {
+	return []string{SortNatural, SortName, SortModTime, SortPinyin}
+}
+
+// 按配置的排序方式排列文件，配置了排序清单时清单中的文件按清单顺序排在最前
+func (c *Config) sortFiles(files []string) error {
+	less, err := c.fileLess(files)
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(files, func(i, j int) bool { return less(files[i], files[j]) })
+
+	if c.OrderFile == "" {
+		return nil
+	}
+	entries, err := readOrderFile(c.OrderFile)
+	if err != nil {
+		return err
+	}
+	rank := make(map[string]int, len(files))
+	for i, entry := range entries {
+		matched := false
+		for _, file := range files {
+			if _, ok := rank[file]; !ok && c.matchOrderEntry(entry, file) {
+				rank[file] = i
+				matched = true
+			}
+		}
+		if !matched {
+			log.Printf("排序清单中的 %s 没有对应的文件", entry)
+		}
+	}
+	// 清单中的文件按清单顺序排在前面，其余文件保持原有顺序
+	sort.SliceStable(files, func(i, j int) bool {
+		ri, iok := rank[files[i]]
+		rj, jok := rank[files[j]]
+		if iok && jok {
+			return ri < rj
+		}
+		return iok && !jok
+	})
+	return nil
+}
+
+// 按排序方式生成比较函数
+func (c *Config) fileLess(files []string) (func(a, b string) bool, error) {
+	switch c.SortOrder {
+	case "", SortNatural:
+		return naturalLess, nil
+	case SortName:
+		return func(a, b string) bool { return a < b }, nil
+	case SortPinyin:
+		col := collate.New(language.Chinese, collate.Numeric, collate.IgnoreCase)
+		return func(a, b string) bool {
+			if r := col.CompareString(a, b); r != 0 {
+				return r < 0
+			}
+			return a < b
+		}, nil
+	case SortModTime:
+		modTimes := make(map[string]int64, len(files))
+		for _, file := range files {
+			info, err := os.Stat(file)
+			if err != nil {
+				return nil, fmt.Errorf("无法读取文件 %s 的修改时间: %v", file, err)
+			}
+			modTimes[file] = info.ModTime().UnixNano()
+		}
+		return func(a, b string) bool {
+			if modTimes[a] != modTimes[b] {
+				return modTimes[a] < modTimes[b]
+			}
+			return naturalLess(a, b)
+		}, nil
+	}
+	return nil, fmt.Errorf("未知的排序方式: %s（可选 %s）", c.SortOrder, strings.Join(SortOrders(), "、"))
+}
+
+// 自然排序：连续的数字按数值比较，其余字符按字节比较，如 2-a.pdf 排在 10-b.pdf 之前
+func naturalLess(a, b string) bool {
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if isDigit(a[i]) && isDigit(b[j]) {
+			si, sj := i, j
+			for i < len(a) && isDigit(a[i]) {
+				i++
+			}
+			for j < len(b) && isDigit(b[j]) {
+				j++
+			}
+			// 去掉前导零后先比较位数，再逐位比较
+			na := strings.TrimLeft(a[si:i], "0")
+			nb := strings.TrimLeft(b[sj:j], "0")
+			if len(na) != len(nb) {
+				return len(na) < len(nb)
+			}
+			if na != nb {
+				return na < nb
+			}
+			continue
+		}
+		if a[i] != b[j] {
+			return a[i] < b[j]
+		}
+		i++
+		j++
+	}
+	if len(a)-i != len(b)-j {
+		return len(a)-i < len(b)-j
+	}
+	// 数值相同时前导零较少（即较短）的在前，保证顺序确定
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	return a < b
+}
+
+func isDigit(ch byte) bool {
+	return '0' <= ch && ch <= '9'
+}
+
+// 读取排序清单：.csv文件取每行的第一列，可带表头；其他文件每行一个文件名，文件名中的逗号和引号保持原样
+// 空行和#开头的行忽略
+func readOrderFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("无法读取排序清单: %v", err)
+	}
+	defer f.Close()
+
+	var entries []string
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		entries, err = readOrderCSV(f)
+	} else {
+		entries, err = readOrderLines(f)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("排序清单 %s 格式错误: %v", path, err)
+	}
+	return entries, nil
+}
+
+// 逐行读取文件名列表
+func readOrderLines(r io.Reader) ([]string, error) {
+	var entries []string
+	scanner := bufio.NewScanner(r)
+	for first := true; scanner.Scan(); first = false {
+		line := scanner.Text()
+		if first {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		entries = append(entries, line)
+	}
+	return entries, scanner.Err()
+}
+
+// 读取CSV的第一列，跳过表头
+// 允许字段中出现未转义的引号（LazyQuotes），手工编辑的清单中常见
+func readOrderCSV(r io.Reader) ([]string, error) {
+	cr := csv.NewReader(r)
+	cr.Comment = '#'
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	cr.LazyQuotes = true
+	var entries []string
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		entry := strings.TrimSpace(strings.TrimPrefix(record[0], "\ufeff"))
+		if entry == "" {
+			continue
+		}
+		if len(entries) == 0 {
+			switch strings.ToLower(entry) {
+			case "file", "filename", "name", "path", "文件", "文件名":
+				continue
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// 判断清单条目是否指向文件：条目可以是文件名或相对于输入目录的路径，扩展名可省略，不区分大小写
+func (c *Config) matchOrderEntry(entry, file string) bool {
+	entry = strings.ToLower(filepath.ToSlash(entry))
+	rel := filepath.Base(file)
+	if strings.Contains(entry, "/") {
+		if r, err := filepath.Rel(c.InputDir, file); err == nil {
+			rel = r
+		}
+	}
+	rel = strings.ToLower(filepath.ToSlash(rel))
+	return entry == rel || entry == strings.TrimSuffix(rel, ".pdf")
+}
@@ -0,0 +1,115 @@
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestNaturalLess(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"2-a.pdf", "10-b.pdf", true},
+		{"10-b.pdf", "2-a.pdf", false},
+		{"a2.pdf", "a10.pdf", true},
+		{"a10.pdf", "a2.pdf", false},
+		{"a.pdf", "b.pdf", true},
+		{"a.pdf", "a.pdf", false},
+		// 前导零不影响数值，数值相同时前导零较少的在前
+		{"007.pdf", "8.pdf", true},
+		{"7.pdf", "007.pdf", true},
+		{"007.pdf", "7.pdf", false},
+		{"0.pdf", "00.pdf", true},
+		// 较长的数字数值更大
+		{"99999999999999999999.pdf", "100000000000000000000.pdf", true},
+		// 前缀较短的在前
+		{"a", "a1", true},
+		{"a1", "a", false},
+		{"v1.2.pdf", "v1.10.pdf", true},
+		{"", "a", true},
+	}
+	for _, tt := range tests {
+		if got := naturalLess(tt.a, tt.b); got != tt.want {
+			t.Errorf("naturalLess(%q, %q) = %v, 期望 %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestNaturalSort(t *testing.T) {
+	files := []string{"10.pdf", "1.pdf", "02.pdf", "2.pdf", "a10b.pdf", "a9b.pdf", "001.pdf"}
+	sort.Slice(files, func(i, j int) bool { return naturalLess(files[i], files[j]) })
+	want := []string{"1.pdf", "001.pdf", "2.pdf", "02.pdf", "10.pdf", "a9b.pdf", "a10b.pdf"}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("自然排序结果 %q, 期望 %q", files, want)
+	}
+}
+
+func TestReadOrderFile(t *testing.T) {
+	tests := []struct {
+		name, file, content string
+		want                []string
+	}{
+		{"纯文本", "list.txt", "b.pdf\na.pdf\n", []string{"b.pdf", "a.pdf"}},
+		{"空行和注释", "list.txt", "\n# 注释\nb\n\n  a  \n", []string{"b", "a"}},
+		{"文件名含逗号和引号", "list.txt", "x, y.pdf\n\"a\".pdf\nname\n", []string{"x, y.pdf", "\"a\".pdf", "name"}},
+		{"BOM", "list", "\ufeffb.pdf\na.pdf\n", []string{"b.pdf", "a.pdf"}},
+		{"CRLF换行", "list.txt", "b.pdf\r\na.pdf\r\n", []string{"b.pdf", "a.pdf"}},
+		{"CSV第一列", "list.csv", "b.pdf,第二\na.pdf,第一,多余\n", []string{"b.pdf", "a.pdf"}},
+		{"CSV注释", "list.csv", "# 注释\nb.pdf,1\n", []string{"b.pdf"}},
+		{"英文表头", "list.csv", "filename,note\nb.pdf,x\n", []string{"b.pdf"}},
+		{"中文表头", "list.CSV", "文件名,备注\nb.pdf,x\n", []string{"b.pdf"}},
+		{"BOM和表头", "list.csv", "\ufeffFile,note\nb.pdf\n", []string{"b.pdf"}},
+		{"表头只在首行", "list.csv", "b.pdf\nname\n", []string{"b.pdf", "name"}},
+		{"带引号", "list.csv", "\"x, y.pdf\",1\n", []string{"x, y.pdf"}},
+		{"未转义的引号", "list.csv", "a \"b\".pdf,1\n", []string{"a \"b\".pdf"}},
+		{"空文件", "list.txt", "", nil},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), tt.file)
+		if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		got, err := readOrderFile(path)
+		if err != nil {
+			t.Errorf("%s: 错误 %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: readOrderFile = %q, 期望 %q", tt.name, got, tt.want)
+		}
+	}
+
+	if _, err := readOrderFile(filepath.Join(t.TempDir(), "missing.csv")); err == nil {
+		t.Error("读取不存在的清单期望错误")
+	}
+}
+
+func TestMatchOrderEntry(t *testing.T) {
+	input := filepath.FromSlash("/in")
+	c := &Config{InputDir: input}
+	file := filepath.Join(input, "sub", "Logo.pdf")
+	tests := []struct {
+		entry string
+		want  bool
+	}{
+		{"Logo.pdf", true},
+		{"logo.PDF", true},
+		{"Logo", true},
+		{"sub/Logo.pdf", true},
+		{"sub/logo", true},
+		{`sub\Logo.pdf`, filepath.Separator == '\\'},
+		{"other/Logo.pdf", false},
+		{"Logo.ai", false},
+		{"Log", false},
+		{"Logo.pdf.pdf", false},
+	}
+	for _, tt := range tests {
+		if got := c.matchOrderEntry(tt.entry, file); got != tt.want {
+			t.Errorf("matchOrderEntry(%q, %q) = %v, 期望 %v", tt.entry, file, got, tt.want)
+		}
+	}
+}
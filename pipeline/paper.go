@@ -0,0 +1,111 @@
+package pipeline
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"pdf-vector-extractor/layout"
+)
+
+// 常用纸张尺寸 (毫米，纵向)
+var paperSizes = map[string][2]float64{
+	"a3":      {297, 420},
+	"a4":      {210, 297},
+	"a5":      {148, 210},
+	"letter":  {215.9, 279.4},
+	"legal":   {215.9, 355.6},
+	"tabloid": {279.4, 431.8},
+}
+
+// 分页输出的标记尺寸
+const (
+	cropMarkLength = 5.0  // 裁切线长度 (毫米)
+	cropMarkGap    = 2.0  // 裁切线与图块的距离 (毫米)
+	markLineWidth  = 0.25 // 裁切线宽度 (点)
+	pageLabelSize  = 9.0  // 页码字号 (点)
+)
+
+// 内置的纸张名称
+func PaperSizes() []string {
+	names := make([]string, 0, len(paperSizes))
+	for name := range paperSizes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// 解析纸张尺寸：内置名称或 宽x高（毫米），返回纵向的宽和高
+func parsePaperSize(s string) (width, height float64, err error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if size, ok := paperSizes[s]; ok {
+		return size[0], size[1], nil
+	}
+	parts := strings.Split(strings.ReplaceAll(s, "×", "x"), "x")
+	if len(parts) == 2 {
+		width, werr := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+		height, herr := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+		if werr == nil && herr == nil && width > 0 && height > 0 {
+			return width, height, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("无效的纸张尺寸: %s（可选 %s，或 宽x高 毫米，如 210x297）", s, strings.Join(PaperSizes(), "、"))
+}
+
+// 是否按纸张分页输出
+func (c *Config) Paginated() bool {
+	return c.PaperSize != ""
+}
+
+// 从配置生成分页排版参数，毫米和点按输出分辨率换算为像素
+func (c *Config) PageOptions() (layout.PageOptions, error) {
+	width, height, err := parsePaperSize(c.PaperSize)
+	if err != nil {
+		return layout.PageOptions{}, err
+	}
+	if c.Landscape {
+		width, height = height, width
+	}
+	dpi := float64(c.EffectiveOutputDPI())
+	mm := func(v float64) int { return int(math.Round(v / 25.4 * dpi)) }
+	pt := func(v float64) float64 { return v / 72 * dpi }
+
+	grid := c.GridOptions()
+	return layout.PageOptions{
+		Width:       mm(width),
+		Height:      mm(height),
+		Margin:      mm(c.PaperMargin),
+		SpacingX:    grid.SpacingX,
+		SpacingY:    grid.SpacingY,
+		Fill:        grid.Fill,
+		Background:  grid.Background,
+		CropMarks:   c.CropMarks,
+		MarkLength:  mm(cropMarkLength),
+		MarkGap:     mm(cropMarkGap),
+		LineWidth:   pt(markLineWidth),
+		PageNumbers: c.PageNumbers,
+		LabelSize:   pt(pageLabelSize),
+	}, nil
+}
+
+// 检查分页参数，裁剪框须能放入页面的可打印区域
+func (c *Config) validatePaper() error {
+	if c.PaperMargin < 0 {
+		return fmt.Errorf("页边距不能为负数: %v", c.PaperMargin)
+	}
+	if !c.Paginated() {
+		return nil
+	}
+	opts, err := c.PageOptions()
+	if err != nil {
+		return err
+	}
+	if c.BoxSize > opts.Width-2*opts.Margin || c.BoxSize > opts.Height-2*opts.Margin {
+		return fmt.Errorf("裁剪框 %d 像素超出纸张 %s 的可打印区域 %d x %d 像素（输出分辨率 %d DPI）",
+			c.BoxSize, c.PaperSize, opts.Width-2*opts.Margin, opts.Height-2*opts.Margin, c.EffectiveOutputDPI())
+	}
+	return nil
+}
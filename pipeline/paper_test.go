@@ -0,0 +1,80 @@
+package pipeline
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParsePaperSize(t *testing.T) {
+	tests := []struct {
+		in            string
+		width, height float64
+	}{
+		{"a4", 210, 297},
+		{" A3 ", 297, 420},
+		{"letter", 215.9, 279.4},
+		{"100x150", 100, 150},
+		{"100 × 150.5", 100, 150.5},
+	}
+	for _, tt := range tests {
+		w, h, err := parsePaperSize(tt.in)
+		if err != nil || w != tt.width || h != tt.height {
+			t.Errorf("parsePaperSize(%q) = %v, %v, %v", tt.in, w, h, err)
+		}
+	}
+	for _, in := range []string{"", "b5", "100", "0x100", "-1x100", "axb"} {
+		if _, _, err := parsePaperSize(in); err == nil {
+			t.Errorf("parsePaperSize(%q) 期望错误", in)
+		}
+	}
+}
+
+func TestPageOptions(t *testing.T) {
+	c := DefaultConfig()
+	c.PaperSize = "a4"
+	c.PaperMargin = 10
+	c.RenderDPI = 300
+	opts, err := c.PageOptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 毫米按输出分辨率换算为像素：210mm、297mm、10mm在300DPI下
+	if opts.Width != 2480 || opts.Height != 3508 || opts.Margin != 118 {
+		t.Errorf("A4 300DPI: %d x %d, 边距 %d", opts.Width, opts.Height, opts.Margin)
+	}
+	// 线宽和字号以点为单位
+	if math.Abs(opts.LineWidth-0.25*300/72) > 1e-9 || math.Abs(opts.LabelSize-9*300/72.0) > 1e-9 {
+		t.Errorf("线宽 %v, 字号 %v", opts.LineWidth, opts.LabelSize)
+	}
+
+	// 输出分辨率优先于渲染分辨率，横向时交换宽高
+	c.OutputDPI = 72
+	c.Landscape = true
+	opts, err = c.PageOptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.Width != 842 || opts.Height != 595 || opts.Margin != 28 {
+		t.Errorf("A4横向 72DPI: %d x %d, 边距 %d", opts.Width, opts.Height, opts.Margin)
+	}
+}
+
+func TestValidatePaper(t *testing.T) {
+	c := DefaultConfig()
+	c.PaperSize = "a5"
+	c.OutputDPI = 72
+	// A5在72DPI下为420x595点，减去两侧10mm边距后宽度约363像素
+	c.BoxSize = 360
+	if err := c.Validate(); err != nil {
+		t.Errorf("方框能放入可打印区域: %v", err)
+	}
+	c.BoxSize = 370
+	if err := c.Validate(); err == nil {
+		t.Error("方框超出可打印区域应返回错误")
+	}
+	c.BoxSize = 200
+	c.PaperMargin = -1
+	if err := c.Validate(); err == nil {
+		t.Error("负的页边距应返回错误")
+	}
+}
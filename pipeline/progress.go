@@ -0,0 +1,114 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"time"
+)
+
+// 进度事件类型
+type EventKind string
+
+const (
+	EventStart     EventKind = "start"      // 开始处理，Total为文件数
+	EventFileStart EventKind = "file_start" // 开始处理第Index个文件
+	EventStage     EventKind = "stage"      // 文件的某一页进入Stage阶段
+	EventFailure   EventKind = "failure"    // 文件或页面在Stage阶段失败，Err为原因
+	EventFileDone  EventKind = "file_done"  // 第Index个文件处理完成，Tiles为提取的图块数
+	EventSave      EventKind = "save"       // 开始排版和保存输出
+	EventDone      EventKind = "done"       // 全部处理结束，Err非nil表示失败或取消
+)
+
+// 处理进度事件
+type Event struct {
+	Kind    EventKind
+	File    string // 源文件路径，与文件无关的事件为空
+	Index   int    // 文件序号，从1开始
+	Total   int    // 文件总数
+	Done    int    // 已处理完成的文件数
+	Page    int    // 页码，与页面无关的事件为0
+	Stage   string // 阶段名称，与失败报告中的阶段一致
+	Tiles   int    // 文件提取出的图块数
+	Elapsed time.Duration
+	Err     error
+}
+
+// 已完成的比例 (0-1)
+func (e Event) Fraction() float64 {
+	if e.Total == 0 {
+		return 0
+	}
+	return float64(e.Done) / float64(e.Total)
+}
+
+// 以JSON输出时使用的字段，错误转为文本，耗时以毫秒表示
+func (e Event) MarshalJSON() ([]byte, error) {
+	v := struct {
+		Kind      EventKind `json:"kind"`
+		File      string    `json:"file,omitempty"`
+		Index     int       `json:"index,omitempty"`
+		Total     int       `json:"total"`
+		Done      int       `json:"done"`
+		Page      int       `json:"page,omitempty"`
+		Stage     string    `json:"stage,omitempty"`
+		Tiles     int       `json:"tiles,omitempty"`
+		ElapsedMS int64     `json:"elapsed_ms"`
+		Error     string    `json:"error,omitempty"`
+	}{
+		Kind:      e.Kind,
+		Index:     e.Index,
+		Total:     e.Total,
+		Done:      e.Done,
+		Page:      e.Page,
+		Stage:     e.Stage,
+		Tiles:     e.Tiles,
+		ElapsedMS: e.Elapsed.Milliseconds(),
+	}
+	if e.File != "" {
+		v.File = filepath.Base(e.File)
+	}
+	if e.Err != nil {
+		v.Error = e.Err.Error()
+	}
+	return json.Marshal(v)
+}
+
+// 进度回调，同一次处理中的事件按顺序逐个回调，不会并发调用
+type ProgressFunc func(Event)
+
+// 设置进度回调，nil表示不报告进度
+func (pe *PDFExtractor) OnProgress(fn ProgressFunc) {
+	pe.progressMu.Lock()
+	pe.onProgress = fn
+	pe.progressMu.Unlock()
+}
+
+// 开始新一次处理的进度统计
+func (pe *PDFExtractor) resetProgress() {
+	pe.progressMu.Lock()
+	pe.started, pe.total, pe.done = time.Now(), 0, 0
+	pe.progressMu.Unlock()
+}
+
+// 扫描到文件后报告文件总数
+func (pe *PDFExtractor) startProgress(total int) {
+	pe.progressMu.Lock()
+	pe.total = total
+	pe.progressMu.Unlock()
+	pe.emit(Event{Kind: EventStart})
+}
+
+// 发送进度事件，自动填入文件总数、完成数和耗时
+func (pe *PDFExtractor) emit(e Event) {
+	pe.progressMu.Lock()
+	defer pe.progressMu.Unlock()
+	if e.Kind == EventFileDone {
+		pe.done++
+	}
+	if pe.onProgress == nil {
+		return
+	}
+	e.Total, e.Done = pe.total, pe.done
+	e.Elapsed = time.Since(pe.started)
+	pe.onProgress(e)
+}
@@ -0,0 +1,100 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestProgressEvents(t *testing.T) {
+	c := testConfig(t, "01.pdf", "02.pdf", "03.pdf", "broken.pdf")
+	c.Concurrency = 2
+	pe := NewPDFExtractor(c)
+	var events []Event
+	pe.OnProgress(func(e Event) { events = append(events, e) })
+	if _, err := pe.ProcessDirectory(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) < 2 || events[0].Kind != EventStart || events[len(events)-1].Kind != EventDone {
+		t.Fatalf("事件应以start开始、done结束: %v", events)
+	}
+	counts := make(map[EventKind]int)
+	done := 0
+	for i, e := range events {
+		counts[e.Kind]++
+		if e.Total != 4 {
+			t.Errorf("事件 %d (%s) 的文件总数 %d, 期望 4", i, e.Kind, e.Total)
+		}
+		if e.Done < done {
+			t.Errorf("事件 %d (%s) 的完成数从 %d 减少到 %d", i, e.Kind, done, e.Done)
+		}
+		done = e.Done
+		switch e.Kind {
+		case EventFileDone:
+			if e.Index < 1 || e.Index > 4 || e.File == "" {
+				t.Errorf("file_done 事件缺少文件信息: %+v", e)
+			}
+		case EventFailure:
+			if e.Stage == "" || e.Err == nil {
+				t.Errorf("failure 事件缺少阶段或原因: %+v", e)
+			}
+		case EventSave:
+			if done != 4 {
+				t.Errorf("所有文件完成前开始保存: 完成 %d", done)
+			}
+		}
+	}
+	for kind, want := range map[EventKind]int{EventFileStart: 4, EventFileDone: 4, EventFailure: 1, EventSave: 1, EventDone: 1} {
+		if counts[kind] != want {
+			t.Errorf("%s 事件 %d 个, 期望 %d", kind, counts[kind], want)
+		}
+	}
+	last := events[len(events)-1]
+	if last.Err != nil || last.Fraction() != 1 {
+		t.Errorf("done 事件 %+v, 期望无错误且进度为1", last)
+	}
+}
+
+func TestProgressEventsOnFailure(t *testing.T) {
+	c := testConfig(t, "broken.pdf")
+	pe := NewPDFExtractor(c)
+	var last Event
+	pe.OnProgress(func(e Event) { last = e })
+	if _, err := pe.ProcessDirectory(context.Background()); err == nil {
+		t.Fatal("全部文件失败时应返回错误")
+	}
+	if last.Kind != EventDone || last.Err == nil {
+		t.Errorf("最后一个事件 %+v, 期望带错误的done事件", last)
+	}
+}
+
+func TestEventJSON(t *testing.T) {
+	e := Event{
+		Kind:    EventFailure,
+		File:    "/data/drawings/a.pdf",
+		Index:   2,
+		Total:   5,
+		Done:    1,
+		Page:    3,
+		Stage:   "检测",
+		Elapsed: 1500 * time.Millisecond,
+		Err:     errors.New("没有内容"),
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"kind":"failure","file":"a.pdf","index":2,"total":5,"done":1,"page":3,"stage":"检测","elapsed_ms":1500,"error":"没有内容"}`
+	if string(data) != want {
+		t.Errorf("JSON %s\n期望 %s", data, want)
+	}
+	if f := e.Fraction(); f != 0.2 {
+		t.Errorf("进度 %v, 期望 0.2", f)
+	}
+	if f := (Event{}).Fraction(); f != 0 {
+		t.Errorf("文件总数为0时进度 %v, 期望 0", f)
+	}
+}
@@ -0,0 +1,75 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"pdf-vector-extractor/extract"
+)
+
+// 单个文件的处理失败记录
+type FileFailure struct {
+	File  string
+	Page  int
+	Stage string
+	Err   error
+}
+
+// 批量处理报告
+type ProcessReport struct {
+	Total      int // 文件数
+	Succeeded  int // 成功提取的图块数
+	Failures   []FileFailure
+	ReportPath string
+}
+
+// 记录一次失败
+func (r *ProcessReport) AddFailure(file string, page int, stage string, err error) {
+	r.Failures = append(r.Failures, FileFailure{File: file, Page: page, Stage: stage, Err: err})
+}
+
+// 失败原因的简短描述
+func (f FileFailure) Reason() string {
+	for _, kind := range []error{extract.ErrEncrypted, extract.ErrNoContent, extract.ErrUnsupportedFilter, extract.ErrCorruptXRef, extract.ErrEmptyPage} {
+		if errors.Is(f.Err, kind) {
+			return kind.Error()
+		}
+	}
+	return "其他错误"
+}
+
+// 失败详情，去掉与文件名重复的前缀
+func (f FileFailure) Detail() error {
+	var ee *extract.Error
+	if errors.As(f.Err, &ee) && ee.Err != nil {
+		return ee.Err
+	}
+	return f.Err
+}
+
+// 生成报告文本
+func (r *ProcessReport) Summary() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "共处理 %d 个文件，成功提取 %d 个图块，失败 %d 项\n", r.Total, r.Succeeded, len(r.Failures))
+	for _, f := range r.Failures {
+		location := filepath.Base(f.File)
+		if f.Page > 0 {
+			location += fmt.Sprintf(" (第%d页)", f.Page)
+		}
+		fmt.Fprintf(&sb, "\n%s\n  阶段: %s\n  原因: %s\n  详情: %v\n",
+			location, f.Stage, f.Reason(), f.Detail())
+	}
+	return sb.String()
+}
+
+// 将报告写入文件
+func (r *ProcessReport) WriteFile(path string) error {
+	if err := os.WriteFile(path, []byte(r.Summary()), 0644); err != nil {
+		return err
+	}
+	r.ReportPath = path
+	return nil
+}
@@ -0,0 +1,199 @@
+package pipeline
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"pdf-vector-extractor/layout"
+)
+
+// 符号链接的处理方式
+const (
+	SymlinksFiles  = "files"  // 包含指向PDF文件的链接，不进入链接的目录（默认）
+	SymlinksFollow = "follow" // 同时进入链接的目录，按实际路径去重并避免循环
+	SymlinksSkip   = "skip"   // 忽略所有符号链接
+)
+
+// 扫描目录，返回符合条件的PDF文件路径
+type scanner struct {
+	config  *Config
+	visited map[string]bool // 已扫描目录的实际路径，避免链接循环
+	seen    map[string]bool // 已收录文件的实际路径
+	files   []string
+	links   []string // 指向文件的符号链接，扫描结束后再去重收录
+}
+
+func (c *Config) scanPDFFiles() ([]string, error) {
+	s := &scanner{
+		config:  c,
+		visited: make(map[string]bool),
+		seen:    make(map[string]bool),
+	}
+	if err := s.scanDir(c.InputDir, ""); err != nil {
+		return nil, err
+	}
+	// 链接指向的文件已在目录中时优先使用原文件
+	for _, link := range s.links {
+		s.add(link)
+	}
+	return s.files, nil
+}
+
+// 扫描一个目录，rel为相对于输入目录的路径（使用/分隔）
+func (s *scanner) scanDir(dir, rel string) error {
+	if real, err := filepath.EvalSymlinks(dir); err == nil {
+		if s.visited[real] {
+			return nil
+		}
+		s.visited[real] = true
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("无法读取目录 %s: %v", dir, err)
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		full := filepath.Join(dir, name)
+		relPath := path.Join(rel, name)
+
+		isDir := entry.IsDir()
+		isLink := entry.Type()&fs.ModeSymlink != 0
+		if isLink {
+			if s.config.Symlinks == SymlinksSkip {
+				continue
+			}
+			info, err := os.Stat(full)
+			if err != nil {
+				// 失效的链接
+				continue
+			}
+			isDir = info.IsDir()
+			if isDir && s.config.Symlinks != SymlinksFollow {
+				continue
+			}
+		}
+
+		if isDir {
+			if !s.config.Recursive || matchAny(s.config.Exclude, relPath) {
+				continue
+			}
+			if err := s.scanDir(full, relPath); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !strings.HasSuffix(strings.ToLower(name), ".pdf") {
+			continue
+		}
+		if len(s.config.Include) > 0 && !matchAny(s.config.Include, relPath) {
+			continue
+		}
+		if matchAny(s.config.Exclude, relPath) {
+			continue
+		}
+
+		if isLink {
+			s.links = append(s.links, full)
+			continue
+		}
+		s.add(full)
+	}
+	return nil
+}
+
+// 收录文件，通过不同路径到达的同一文件只处理一次
+func (s *scanner) add(file string) {
+	if real, err := filepath.EvalSymlinks(file); err == nil {
+		if s.seen[real] {
+			return
+		}
+		s.seen[real] = true
+	}
+	s.files = append(s.files, file)
+}
+
+// 判断相对路径是否匹配任一模式
+// 不含/的模式匹配文件或目录名，含/的模式匹配相对路径，**匹配任意层目录；不区分大小写
+func matchAny(patterns []string, relPath string) bool {
+	relPath = strings.ToLower(relPath)
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(filepath.ToSlash(pattern))
+		if !strings.Contains(pattern, "/") {
+			if ok, _ := path.Match(pattern, path.Base(relPath)); ok {
+				return true
+			}
+			continue
+		}
+		if matchSegments(strings.Split(strings.Trim(pattern, "/"), "/"), strings.Split(relPath, "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// 逐段匹配路径，**可匹配零个或多个目录
+func matchSegments(pattern, parts []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(parts); i++ {
+				if matchSegments(pattern[1:], parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(parts) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], parts[0]); !ok {
+			return false
+		}
+		pattern, parts = pattern[1:], parts[1:]
+	}
+	return len(parts) == 0
+}
+
+// 检查模式语法
+func validatePatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		for _, part := range strings.Split(filepath.ToSlash(pattern), "/") {
+			if _, err := path.Match(part, ""); err != nil {
+				return fmt.Errorf("无效的匹配模式 %q: %v", pattern, err)
+			}
+		}
+	}
+	return nil
+}
+
+// 一组共同输出的图块，Dir为来源文件相对于输入目录的子目录（根目录为空）
+type tileGroup struct {
+	Dir   string
+	Tiles []layout.Tile
+}
+
+// 按来源文件所在的子目录把图块分组，组的顺序与图块顺序一致
+func (c *Config) groupTiles(tiles []layout.Tile) []tileGroup {
+	var groups []tileGroup
+	index := make(map[string]int)
+	for _, tile := range tiles {
+		dir, err := filepath.Rel(c.InputDir, filepath.Dir(tile.SourceFile))
+		if err != nil || dir == "." {
+			dir = ""
+		}
+		i, ok := index[dir]
+		if !ok {
+			i = len(groups)
+			index[dir] = i
+			groups = append(groups, tileGroup{Dir: dir})
+		}
+		groups[i].Tiles = append(groups[i].Tiles, tile)
+	}
+	return groups
+}
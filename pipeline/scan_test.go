@@ -0,0 +1,79 @@
+package pipeline
+
+import "testing"
+
+func TestMatchAny(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		rel      string
+		want     bool
+	}{
+		// 不含/的模式匹配文件或目录名
+		{[]string{"*.pdf"}, "a.pdf", true},
+		{[]string{"*.pdf"}, "sub/dir/a.pdf", true},
+		{[]string{"*.pdf"}, "a.ai", false},
+		{[]string{"draft*"}, "draft_v2", true},
+		{[]string{"draft*"}, "final/draft_v2.pdf", true},
+		{[]string{"draft*"}, "final/v2.pdf", false},
+		{[]string{"*.PDF"}, "A.pdf", true},
+		{[]string{"a?.pdf"}, "ab.pdf", true},
+		{[]string{"a?.pdf"}, "abc.pdf", false},
+		// 含/的模式匹配相对路径
+		{[]string{"drawings/*.pdf"}, "drawings/a.pdf", true},
+		{[]string{"drawings/*.pdf"}, "drawings/sub/a.pdf", false},
+		{[]string{"drawings/*.pdf"}, "other/a.pdf", false},
+		{[]string{"/drawings/*.pdf/"}, "drawings/a.pdf", true},
+		// **匹配零个或多个目录
+		{[]string{"drawings/**/*.pdf"}, "drawings/a.pdf", true},
+		{[]string{"drawings/**/*.pdf"}, "drawings/x/y/a.pdf", true},
+		{[]string{"drawings/**/*.pdf"}, "other/x/a.pdf", false},
+		{[]string{"**/old"}, "old", true},
+		{[]string{"**/old"}, "a/b/old", true},
+		{[]string{"**/old"}, "a/b/old/c.pdf", false},
+		{[]string{"a/**"}, "a", true},
+		{[]string{"a/**"}, "a/b/c.pdf", true},
+		{[]string{"**/**/x.pdf"}, "x.pdf", true},
+		// 多个模式任一匹配
+		{[]string{"*.ai", "*.pdf"}, "a.pdf", true},
+		{nil, "a.pdf", false},
+	}
+	for _, tt := range tests {
+		if got := matchAny(tt.patterns, tt.rel); got != tt.want {
+			t.Errorf("matchAny(%q, %q) = %v, 期望 %v", tt.patterns, tt.rel, got, tt.want)
+		}
+	}
+}
+
+func TestMatchSegments(t *testing.T) {
+	tests := []struct {
+		pattern, parts []string
+		want           bool
+	}{
+		{[]string{}, []string{}, true},
+		{[]string{}, []string{"a"}, false},
+		{[]string{"a"}, []string{}, false},
+		{[]string{"**"}, []string{}, true},
+		{[]string{"**"}, []string{"a", "b"}, true},
+		{[]string{"a", "**", "b"}, []string{"a", "b"}, true},
+		{[]string{"a", "**", "b"}, []string{"a", "x", "y", "b"}, true},
+		{[]string{"a", "**", "b"}, []string{"a", "x", "y"}, false},
+		{[]string{"*", "b"}, []string{"a", "b"}, true},
+		{[]string{"*", "b"}, []string{"b"}, false},
+	}
+	for _, tt := range tests {
+		if got := matchSegments(tt.pattern, tt.parts); got != tt.want {
+			t.Errorf("matchSegments(%q, %q) = %v, 期望 %v", tt.pattern, tt.parts, got, tt.want)
+		}
+	}
+}
+
+func TestValidatePatterns(t *testing.T) {
+	if err := validatePatterns([]string{"*.pdf", "drawings/**/*.pdf", "a?[0-9]"}); err != nil {
+		t.Errorf("validatePatterns 有效模式返回错误: %v", err)
+	}
+	for _, pattern := range []string{"[", "a/[b", `x\`} {
+		if err := validatePatterns([]string{pattern}); err == nil {
+			t.Errorf("validatePatterns(%q) 期望错误", pattern)
+		}
+	}
+}
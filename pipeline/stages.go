@@ -0,0 +1,310 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"sort"
+	"strings"
+	"sync"
+
+	"pdf-vector-extractor/crop"
+	"pdf-vector-extractor/detect"
+	"pdf-vector-extractor/extract"
+	"pdf-vector-extractor/layout"
+	"pdf-vector-extractor/output"
+)
+
+// 处理流程各阶段的接口，实现通过名称注册后即可在Config中选用
+// 各阶段在ctx取消时应尽快返回ctx的错误
+// 多个文件并行处理：提取器每个文件创建一个，检测器和裁剪器在所有文件间共用，须可并发调用
+
+// 页面提取：读取PDF页数并把指定页面提取为图像（页码从1开始）
+type Extractor interface {
+	PageCount(pdfPath string) (int, error)
+	ExtractPage(ctx context.Context, pdfPath string, pageNr int) (*extract.Page, error)
+}
+
+// 内容检测：返回内容中心点和内容区域
+type BoundsDetector interface {
+	DetectBounds(ctx context.Context, img image.Image) (image.Point, image.Rectangle, error)
+}
+
+// 分割：返回页面中每个独立内容的中心点和区域，一页生成多个图块
+// 检测器实现该接口时按分割结果处理，否则每页一个图块
+type Segmenter interface {
+	BoundsDetector
+	Segment(ctx context.Context, img image.Image) ([]detect.Region, error)
+}
+
+// 按页面检测：可以使用页面的矢量数据，不依赖渲染分辨率
+// 检测器实现该接口时传入整个页面，否则只传入页面图像
+type PageDetector interface {
+	BoundsDetector
+	DetectPage(ctx context.Context, page *extract.Page) (image.Point, image.Rectangle, error)
+}
+
+// 裁剪：返回方框大小的图块、源图像中的裁剪区域及其（缩放后）在方框中的位置
+type Cropper interface {
+	Crop(ctx context.Context, img image.Image, center image.Point, bounds image.Rectangle) (image.Image, image.Rectangle, image.Rectangle, error)
+}
+
+// 可移动放置位置的裁剪：裁剪结果在方框中的位置按shift移动，移动后仍在方框内
+// 裁剪器实现该接口时，靠边对齐先把裁剪区域移到方框边缘，否则只在裁剪区域内移动内容
+type PlacingCropper interface {
+	Cropper
+	CropPlaced(ctx context.Context, img image.Image, center image.Point, bounds image.Rectangle, shift image.Point) (image.Image, image.Rectangle, image.Rectangle, error)
+}
+
+// 排版：把图块排列到拼接结果中
+type Layouter interface {
+	Layout(ctx context.Context, tiles []layout.Tile) (*layout.Sheet, error)
+}
+
+// 输出：把拼接结果写入文件，由output包注册
+type Writer = output.Writer
+
+// 各阶段的默认实现名称
+const (
+	DefaultExtractor = "pdf"
+	DefaultDetector  = detect.MethodVector // 矢量页面按路径几何检测，其他页面按VectorFallback检测
+	DefaultCropper   = "smart"
+	DefaultLayouter  = "grid"
+)
+
+// 各阶段按配置创建实例，同一次处理中每个文件创建一个提取器
+type (
+	ExtractorFactory func(c *Config) Extractor
+	DetectorFactory  func(c *Config) BoundsDetector
+	CropperFactory   func(c *Config) Cropper
+	LayouterFactory  func(c *Config) Layouter
+)
+
+var (
+	extractors = newRegistry[ExtractorFactory]("提取器")
+	detectors  = newRegistry[DetectorFactory]("检测器")
+	croppers   = newRegistry[CropperFactory]("裁剪器")
+	layouters  = newRegistry[LayouterFactory]("排版器")
+)
+
+func init() {
+	RegisterExtractor(DefaultExtractor, func(c *Config) Extractor {
+		return extract.NewProcessor(c.RenderDPI)
+	})
+	for _, method := range detect.Methods() {
+		method := method
+		RegisterDetector(method, func(c *Config) BoundsDetector {
+			opts := c.DetectOptions(method)
+			return DetectorFunc(func(ctx context.Context, img image.Image) (image.Point, image.Rectangle, error) {
+				return detect.Detect(ctx, img, opts)
+			})
+		})
+	}
+	RegisterDetector(detect.MethodVector, func(c *Config) BoundsDetector {
+		return vectorDetector{opts: c.DetectOptions(c.VectorFallback)}
+	})
+	RegisterDetector("segment", func(c *Config) BoundsDetector {
+		return segmentDetector{opts: detect.SegmentOptions{
+			Options:       c.DetectOptions(c.SegmentMethod),
+			MergeDistance: c.MergeDistance,
+			MinArea:       c.MinArea,
+		}}
+	})
+	RegisterCropper(DefaultCropper, func(c *Config) Cropper {
+		return smartCropper{boxSize: c.BoxSize, fit: c.FitMode, filter: c.ResampleFilter, fill: c.TileFill()}
+	})
+	RegisterLayouter(DefaultLayouter, func(c *Config) Layouter {
+		return gridLayouter{boxSize: c.BoxSize, opts: c.GridOptions()}
+	})
+	RegisterLayouter("vertical", func(c *Config) Layouter {
+		return verticalLayouter{boxSize: c.BoxSize, spacing: c.Spacing}
+	})
+	RegisterLayouter("pack", func(c *Config) Layouter {
+		return packLayouter{opts: c.PackOptions()}
+	})
+}
+
+// 注册页面提取器，同名实现会被替换
+func RegisterExtractor(name string, f ExtractorFactory) { extractors.register(name, f) }
+
+// 注册内容检测器，同名实现会被替换
+func RegisterDetector(name string, f DetectorFactory) { detectors.register(name, f) }
+
+// 注册裁剪器，同名实现会被替换
+func RegisterCropper(name string, f CropperFactory) { croppers.register(name, f) }
+
+// 注册排版器，同名实现会被替换
+func RegisterLayouter(name string, f LayouterFactory) { layouters.register(name, f) }
+
+// 注册输出格式，格式名同时用作文件扩展名
+func RegisterWriter(format string, w Writer) { output.Register(format, w) }
+
+// 已注册的各阶段实现名称
+func Extractors() []string { return extractors.names() }
+func Detectors() []string  { return detectors.names() }
+func Croppers() []string   { return croppers.names() }
+func Layouters() []string  { return layouters.names() }
+func Writers() []string    { return output.Formats() }
+
+// 以函数实现BoundsDetector
+type DetectorFunc func(ctx context.Context, img image.Image) (image.Point, image.Rectangle, error)
+
+func (f DetectorFunc) DetectBounds(ctx context.Context, img image.Image) (image.Point, image.Rectangle, error) {
+	return f(ctx, img)
+}
+
+// 矢量检测器：矢量页面按路径几何计算内容区域，非矢量页面或没有可见路径时按配置的像素检测方法
+type vectorDetector struct {
+	opts detect.Options
+}
+
+func (vd vectorDetector) DetectPage(ctx context.Context, page *extract.Page) (image.Point, image.Rectangle, error) {
+	if err := ctx.Err(); err != nil {
+		return image.Point{}, image.Rectangle{}, err
+	}
+	if center, bounds, ok := detect.VectorBounds(page, vd.opts.Margin); ok {
+		return center, bounds, nil
+	}
+	return vd.DetectBounds(ctx, page.Image)
+}
+
+func (vd vectorDetector) DetectBounds(ctx context.Context, img image.Image) (image.Point, image.Rectangle, error) {
+	return detect.Detect(ctx, img, vd.opts)
+}
+
+// 按连通区域分割，每个独立内容生成一个图块
+type segmentDetector struct {
+	opts detect.SegmentOptions
+}
+
+func (sd segmentDetector) Segment(ctx context.Context, img image.Image) ([]detect.Region, error) {
+	return detect.Segments(ctx, img, sd.opts)
+}
+
+// 不分割时返回所有内容的合并区域
+func (sd segmentDetector) DetectBounds(ctx context.Context, img image.Image) (image.Point, image.Rectangle, error) {
+	regions, err := sd.Segment(ctx, img)
+	if err != nil {
+		return image.Point{}, image.Rectangle{}, err
+	}
+	if len(regions) == 0 {
+		return detect.BoundsContext(ctx, img)
+	}
+	bounds := regions[0].Bounds
+	for _, r := range regions[1:] {
+		bounds = bounds.Union(r.Bounds)
+	}
+	return image.Pt((bounds.Min.X+bounds.Max.X)/2, (bounds.Min.Y+bounds.Max.Y)/2), bounds, nil
+}
+
+// 默认裁剪器：按缩放方式把内容放入方框并居中，不缩放时按内容比例裁剪窗口
+// 方框中内容以外的部分填充fill
+type smartCropper struct {
+	boxSize     int
+	fit, filter string
+	fill        color.RGBA
+}
+
+func (sc smartCropper) Crop(ctx context.Context, img image.Image, center image.Point, bounds image.Rectangle) (image.Image, image.Rectangle, image.Rectangle, error) {
+	return sc.CropPlaced(ctx, img, center, bounds, image.Point{})
+}
+
+func (sc smartCropper) CropPlaced(ctx context.Context, img image.Image, center image.Point, bounds image.Rectangle, shift image.Point) (image.Image, image.Rectangle, image.Rectangle, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, image.Rectangle{}, image.Rectangle{}, err
+	}
+	cropRect, dst := crop.FitWindow(center, bounds, sc.boxSize, sc.fit)
+	dst = dst.Add(layout.ClampShift(shift, dst, image.Rect(0, 0, sc.boxSize, sc.boxSize)))
+	tile, err := crop.Resample(img, cropRect, dst, sc.boxSize, sc.filter, sc.fill)
+	if err != nil {
+		return nil, image.Rectangle{}, image.Rectangle{}, err
+	}
+	return tile, cropRect, dst, nil
+}
+
+// 默认排版器：按配置的行列数、填充顺序、间距、外边距和背景排成网格
+type gridLayouter struct {
+	boxSize int
+	opts    layout.GridOptions
+}
+
+func (gl gridLayouter) Layout(ctx context.Context, tiles []layout.Tile) (*layout.Sheet, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return layout.Grid(tiles, gl.boxSize, gl.opts), nil
+}
+
+// 纵向排成一列，只使用行间距
+type verticalLayouter struct {
+	boxSize, spacing int
+}
+
+func (vl verticalLayouter) Layout(ctx context.Context, tiles []layout.Tile) (*layout.Sheet, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return layout.Arrange(tiles, vl.boxSize, vl.spacing), nil
+}
+
+// 按图块可见内容的大小紧密排列，适合制作精灵图
+type packLayouter struct {
+	opts layout.PackOptions
+}
+
+func (pl packLayouter) Layout(ctx context.Context, tiles []layout.Tile) (*layout.Sheet, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return layout.Pack(tiles, pl.opts), nil
+}
+
+// 按名称保存的实现集合
+type registry[T any] struct {
+	mu    sync.RWMutex
+	kind  string
+	items map[string]T
+}
+
+func newRegistry[T any](kind string) *registry[T] {
+	return &registry[T]{kind: kind, items: make(map[string]T)}
+}
+
+func (r *registry[T]) register(name string, item T) {
+	if name == "" {
+		panic(fmt.Sprintf("pipeline: 注册%s时名称不能为空", r.kind))
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.items[name] = item
+}
+
+// 查找实现，名称为空时使用默认实现
+func (r *registry[T]) lookup(name, fallback string) (T, error) {
+	if name == "" {
+		name = fallback
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	item, ok := r.items[name]
+	if !ok {
+		return item, fmt.Errorf("未知的%s: %s（可选 %s）", r.kind, name, strings.Join(r.namesLocked(), "、"))
+	}
+	return item, nil
+}
+
+func (r *registry[T]) names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.namesLocked()
+}
+
+func (r *registry[T]) namesLocked() []string {
+	names := make([]string, 0, len(r.items))
+	for name := range r.items {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
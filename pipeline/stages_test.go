@@ -0,0 +1,271 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"pdf-vector-extractor/detect"
+	"pdf-vector-extractor/extract"
+	"pdf-vector-extractor/layout"
+)
+
+// 测试用提取器：不解析文件内容，按文件名生成一页白底黑方块的图像
+// 文件名为数字时，数字越小提取越慢，使并行处理的完成顺序与文件顺序相反；
+// 文件名含 broken 时读取失败
+type testExtractor struct {
+	onExtract func(pdfPath string) // 每次提取页面前调用
+}
+
+var (
+	testHooksMu sync.Mutex
+	testHooks   = make(map[string]func(pdfPath string))
+)
+
+func init() {
+	RegisterExtractor("test", func(c *Config) Extractor {
+		testHooksMu.Lock()
+		defer testHooksMu.Unlock()
+		return testExtractor{onExtract: testHooks[c.InputDir]}
+	})
+}
+
+func (testExtractor) PageCount(pdfPath string) (int, error) {
+	if strings.Contains(filepath.Base(pdfPath), "broken") {
+		return 0, &extract.Error{File: pdfPath, Kind: extract.ErrCorruptXRef, Err: fmt.Errorf("测试文件损坏")}
+	}
+	return 1, nil
+}
+
+func (te testExtractor) ExtractPage(ctx context.Context, pdfPath string, pageNr int) (*extract.Page, error) {
+	if te.onExtract != nil {
+		te.onExtract(pdfPath)
+	}
+	name := strings.TrimSuffix(filepath.Base(pdfPath), filepath.Ext(pdfPath))
+	if n, err := strconv.Atoi(name); err == nil {
+		select {
+		case <-time.After(time.Duration(20-n) * time.Millisecond):
+		case <-ctx.Done():
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	img := image.NewRGBA(image.Rect(0, 0, 300, 300))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(100, 100, 200, 200), image.Black, image.Point{}, draw.Src)
+	return &extract.Page{Image: img, Scale: 1}, nil
+}
+
+// 使用测试提取器的配置，inputDir中的文件由files创建（内容为空）
+func testConfig(t *testing.T, files ...string) *Config {
+	t.Helper()
+	c := DefaultConfig()
+	c.InputDir = t.TempDir()
+	c.OutputPath = t.TempDir()
+	c.Extractor = "test"
+	for _, name := range files {
+		if err := os.WriteFile(filepath.Join(c.InputDir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return c
+}
+
+// 为配置的测试提取器设置提取页面前的回调
+func setTestHook(t *testing.T, c *Config, hook func(pdfPath string)) {
+	testHooksMu.Lock()
+	testHooks[c.InputDir] = hook
+	testHooksMu.Unlock()
+	t.Cleanup(func() {
+		testHooksMu.Lock()
+		delete(testHooks, c.InputDir)
+		testHooksMu.Unlock()
+	})
+}
+
+// 记录收到的图块的排版器
+type recordingLayouter struct {
+	mu    sync.Mutex
+	tiles []layout.Tile
+}
+
+func (rl *recordingLayouter) Layout(ctx context.Context, tiles []layout.Tile) (*layout.Sheet, error) {
+	rl.mu.Lock()
+	rl.tiles = append(rl.tiles, tiles...)
+	rl.mu.Unlock()
+	return layout.Grid(tiles, 200, layout.GridOptions{Columns: 1, Background: layout.White}), nil
+}
+
+func TestRegisteredStages(t *testing.T) {
+	rec := &recordingLayouter{}
+	RegisterLayouter("test-recording", func(c *Config) Layouter { return rec })
+	RegisterDetector("test-fixed", func(c *Config) BoundsDetector {
+		return DetectorFunc(func(ctx context.Context, img image.Image) (image.Point, image.Rectangle, error) {
+			return image.Pt(150, 150), image.Rect(120, 120, 180, 180), nil
+		})
+	})
+	for _, name := range []string{"test", "test-fixed", "test-recording"} {
+		found := false
+		for _, names := range [][]string{Extractors(), Detectors(), Layouters()} {
+			for _, n := range names {
+				found = found || n == name
+			}
+		}
+		if !found {
+			t.Errorf("注册的实现 %s 未出现在列表中", name)
+		}
+	}
+
+	c := testConfig(t, "a.pdf", "b.pdf")
+	c.Detector = "test-fixed"
+	c.Layouter = "test-recording"
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	report, err := NewPDFExtractor(c).ProcessDirectory(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Succeeded != 2 || len(rec.tiles) != 2 {
+		t.Fatalf("成功 %d 个图块，排版器收到 %d 个，期望 2", report.Succeeded, len(rec.tiles))
+	}
+	// 裁剪器按检测器返回的区域裁剪
+	for _, tile := range rec.tiles {
+		if tile.CropRect != image.Rect(50, 50, 250, 250) {
+			t.Errorf("%s 的裁剪区域 %v, 期望以检测器的中心点裁剪", tile.Name(), tile.CropRect)
+		}
+	}
+}
+
+func TestUnknownStage(t *testing.T) {
+	for _, set := range []func(c *Config){
+		func(c *Config) { c.Extractor = "nope" },
+		func(c *Config) { c.Detector = "nope" },
+		func(c *Config) { c.Cropper = "nope" },
+		func(c *Config) { c.Layouter = "nope" },
+		func(c *Config) { c.OutputType = "nope" },
+	} {
+		c := DefaultConfig()
+		set(c)
+		if err := c.Validate(); err == nil || !strings.Contains(err.Error(), "nope") {
+			t.Errorf("未注册的实现应返回包含名称的错误: %v", err)
+		}
+	}
+	// 空名称使用默认实现
+	c := DefaultConfig()
+	c.Extractor, c.Detector, c.Cropper, c.Layouter = "", "", "", ""
+	if err := c.Validate(); err != nil {
+		t.Errorf("空名称应使用默认实现: %v", err)
+	}
+}
+
+func TestCropperFillsBackground(t *testing.T) {
+	// 100x20的横向内容，方框上下为内容以外的部分
+	img := image.NewRGBA(image.Rect(0, 0, 300, 300))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(100, 140, 200, 160), image.Black, image.Point{}, draw.Src)
+
+	tests := []struct {
+		background string
+		want       color.RGBA
+	}{
+		{"#ffe0c0", color.RGBA{255, 224, 192, 255}},
+		// 透明或半透明背景时保持透明，由拼接结果的背景透出
+		{"#ffe0c080", color.RGBA{}},
+		{"none", color.RGBA{}},
+	}
+	for _, tt := range tests {
+		c := DefaultConfig()
+		c.Background = tt.background
+		stages, err := c.stages()
+		if err != nil {
+			t.Fatal(err)
+		}
+		tile, _, _, err := stages.cropper.Crop(context.Background(), img, image.Pt(150, 150), image.Rect(100, 140, 200, 160))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := color.RGBAModel.Convert(tile.At(100, 10)).(color.RGBA); got != tt.want {
+			t.Errorf("%s: 方框空白处 %v, 期望 %v", tt.background, got, tt.want)
+		}
+		if got := color.RGBAModel.Convert(tile.At(100, 100)).(color.RGBA); got != (color.RGBA{0, 0, 0, 255}) {
+			t.Errorf("%s: 内容 %v, 期望黑色", tt.background, got)
+		}
+	}
+}
+
+func TestVectorDetectorFallback(t *testing.T) {
+	// 白色页面上100x50的黑色方块
+	img := image.NewRGBA(image.Rect(0, 0, 300, 300))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(100, 150, 200, 200), image.Black, image.Point{}, draw.Src)
+
+	c := DefaultConfig()
+	c.VectorFallback = detect.MethodOtsu
+	stages, err := c.stages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pd, ok := stages.detector.(PageDetector)
+	if !ok {
+		t.Fatalf("默认检测器 %T 应按页面检测", stages.detector)
+	}
+	ctx := context.Background()
+	wantCenter, wantBounds, err := detect.Detect(ctx, img, c.DetectOptions(detect.MethodOtsu))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 同一方块的矢量路径，页面空间y向上
+	square := []extract.PathSegment{
+		{Op: extract.SegmentMoveTo, Points: []extract.VectorPoint{{X: 100, Y: 100}}},
+		{Op: extract.SegmentLineTo, Points: []extract.VectorPoint{{X: 200, Y: 100}}},
+		{Op: extract.SegmentLineTo, Points: []extract.VectorPoint{{X: 200, Y: 150}}},
+		{Op: extract.SegmentLineTo, Points: []extract.VectorPoint{{X: 100, Y: 150}}},
+		{Op: extract.SegmentClose},
+	}
+	vector := &extract.VectorPage{
+		MediaBox: extract.PageBox{URX: 300, URY: 300},
+		Paths: []extract.VectorPath{{
+			Segments:  square,
+			CTM:       extract.IdentityMatrix,
+			Fill:      true,
+			FillColor: color.RGBA{0, 0, 0, 255},
+			FillAlpha: 1,
+		}},
+	}
+	// 空白的渲染结果上按像素检测找不到内容，矢量页面仍按路径几何得到方块
+	blank := image.NewRGBA(img.Bounds())
+	draw.Draw(blank, blank.Bounds(), image.White, image.Point{}, draw.Src)
+	m := c.DetectMargin
+	_, bounds, err := pd.DetectPage(ctx, &extract.Page{Image: blank, Vector: vector, Scale: 1})
+	if err != nil || bounds != image.Rect(100-m, 150-m, 200+m, 200+m) {
+		t.Errorf("矢量页面的内容区域 %v %v, 期望按路径几何计算", bounds, err)
+	}
+
+	// 位图页面和没有路径的矢量页面使用备用方法
+	for name, page := range map[string]*extract.Page{
+		"位图页面":    {Image: img, Scale: 1},
+		"没有路径的页面": {Image: img, Vector: &extract.VectorPage{MediaBox: vector.MediaBox}, Scale: 1},
+	} {
+		center, bounds, err := pd.DetectPage(ctx, page)
+		if err != nil || center != wantCenter || bounds != wantBounds {
+			t.Errorf("%s: %v %v %v, 期望备用方法的结果 %v %v", name, center, bounds, err, wantCenter, wantBounds)
+		}
+	}
+
+	c.VectorFallback = detect.MethodVector
+	if err := c.Validate(); err == nil {
+		t.Error("备用方法不能为矢量检测")
+	}
+}
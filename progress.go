@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"pdf-vector-extractor/pipeline"
+)
+
+// 命令行进度输出方式
+const (
+	progressLog  = "log"  // 逐条输出日志
+	progressLine = "line" // 在标准错误中单行刷新进度
+	progressJSON = "json" // 每个进度事件输出一行JSON到标准输出
+)
+
+// 未指定进度输出方式时，终端中使用单行进度，否则输出日志
+func defaultProgressMode(w io.Writer) string {
+	if f, ok := w.(*os.File); ok {
+		if info, err := f.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+			return progressLine
+		}
+	}
+	return progressLog
+}
+
+// 按输出方式创建进度回调，log方式返回nil
+func newProgressPrinter(mode string, stdout, stderr io.Writer) (pipeline.ProgressFunc, error) {
+	switch mode {
+	case progressLog:
+		return nil, nil
+	case progressJSON:
+		enc := json.NewEncoder(stdout)
+		return func(e pipeline.Event) {
+			enc.Encode(e)
+		}, nil
+	case progressLine:
+		return lineProgress(stderr), nil
+	}
+	return nil, fmt.Errorf("不支持的进度输出方式: %s（可选 log、line、json）", mode)
+}
+
+// 单行刷新的进度显示，失败信息单独成行保留
+func lineProgress(w io.Writer) pipeline.ProgressFunc {
+	return func(e pipeline.Event) {
+		status := ""
+		switch e.Kind {
+		case pipeline.EventStart:
+			status = fmt.Sprintf("找到 %d 个PDF文件", e.Total)
+		case pipeline.EventFileStart, pipeline.EventStage, pipeline.EventFileDone:
+			status = filepath.Base(e.File)
+			if e.Page > 0 {
+				status += fmt.Sprintf(" 第%d页", e.Page)
+			}
+			if e.Stage != "" && e.Kind == pipeline.EventStage {
+				status += " " + e.Stage
+			}
+		case pipeline.EventSave:
+			status = "正在保存"
+			if e.File != "" {
+				status += " " + filepath.Base(e.File)
+			}
+		case pipeline.EventFailure:
+			location := filepath.Base(e.File)
+			if e.Page > 0 {
+				location += fmt.Sprintf(" 第%d页", e.Page)
+			}
+			f := pipeline.FileFailure{File: e.File, Page: e.Page, Stage: e.Stage, Err: e.Err}
+			fmt.Fprintf(w, "\r\033[K失败: %s [%s] %s: %v\n", location, e.Stage, f.Reason(), f.Detail())
+			return
+		case pipeline.EventDone:
+			status = "完成"
+			if e.Err != nil {
+				status = "未完成"
+			}
+		}
+
+		fmt.Fprintf(w, "\r\033[K[%d/%d] %3.0f%% %s %s", e.Done, e.Total, e.Fraction()*100,
+			e.Elapsed.Truncate(time.Second), status)
+		if e.Kind == pipeline.EventDone {
+			fmt.Fprintln(w)
+		}
+	}
+}